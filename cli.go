@@ -0,0 +1,609 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"journalCli/client"
+	"journalCli/config"
+	"journalCli/e2ee"
+	"journalCli/export"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+)
+
+const cliUsage = `usage: journalCli [flags] [command]
+
+Without a command, journalCli opens the journal in the terminal UI.
+
+commands:
+  login [--email address]          log in and remember the session
+  logout                           end the remembered session
+  new [-m text] [--mood 1-5]       write an entry, in $EDITOR without -m
+  list [--since 7d] [--tag tag]    list entries, newest first
+  show <id>                        print an entry
+  search <query>                   search entries
+  export [--format markdown] <path>
+                                   export every entry as Markdown, json, jsonl
+                                   or an html site; - writes json to stdout
+  import [--dry-run] <path>        import a Day One export, a jrnl file or a
+                                   folder of Markdown files, skipping entries
+                                   already in the journal
+  remind [--at 20:00] [--once]     notify at the reminder time from Settings
+                                   when nothing was written that day
+
+Every command takes --json to print JSON instead of text. Encrypted journals
+are unlocked with $JOURNALCLI_PASSPHRASE, or a passphrase prompt.`
+
+// errUsage makes runCommand exit with status 2 like a flag error.
+var errUsage = errors.New(cliUsage)
+
+// stdin is shared by the prompts so that piped input isn't lost to buffering.
+var stdin = bufio.NewReader(os.Stdin)
+
+// runCommand runs the non-interactive command args[0], for scripting.
+func runCommand(cfg config.Config, args []string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	api := client.New(cfg.Client.ServerURL)
+	name, args := args[0], args[1:]
+
+	switch name {
+	case "help":
+		fmt.Println(cliUsage)
+		return nil
+	case "login":
+		return loginCommand(ctx, api, cfg.Client.ServerURL, args)
+	}
+
+	run, ok := map[string]func(context.Context, *client.Client, []string) error{
+		"logout": logoutCommand,
+		"new":    newCommand,
+		"list":   listCommand,
+		"show":   showCommand,
+		"search": searchCommand,
+		"export": exportCommand,
+		"import": importCommand,
+		"remind": func(ctx context.Context, api *client.Client, args []string) error {
+			return remindCommand(ctx, api, cfg.Client.ReminderTime, args)
+		},
+	}[name]
+	if !ok {
+		return fmt.Errorf("unknown command %q\n\n%w", name, errUsage)
+	}
+
+	session, err := loadSession(cfg.Client.ServerURL)
+	if err != nil {
+		return err
+	}
+	api.SetToken(session.Token)
+
+	err = run(ctx, api, args)
+	if errors.Is(err, client.ErrUnauthorized) {
+		deleteSession()
+		return errors.New("session expired, run `journalCli login` again")
+	}
+	return err
+}
+
+// newFlagSet returns the flags of a command, with --json.
+func newFlagSet(name string) (*flag.FlagSet, *bool) {
+	fs := flag.NewFlagSet("journalCli "+name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	asJSON := fs.Bool("json", false, "print JSON instead of text")
+	return fs, asJSON
+}
+
+// parseArgs parses flags wherever they are among the arguments, so that
+// `search garden --json` works, and returns the other arguments, of which
+// there must be between minArgs and maxArgs (-1 for any number).
+func parseArgs(fs *flag.FlagSet, args []string, minArgs, maxArgs int) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				fs.SetOutput(os.Stderr)
+				fmt.Fprintf(os.Stderr, "Usage of %s:\n", fs.Name())
+				fs.PrintDefaults()
+				return nil, err
+			}
+			return nil, fmt.Errorf("%v\n\n%w", err, errUsage)
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	if len(positional) < minArgs || (maxArgs >= 0 && len(positional) > maxArgs) {
+		return nil, errUsage
+	}
+	return positional, nil
+}
+
+// flagPassed reports whether the flag name was on the command line, rather
+// than left at its default.
+func flagPassed(fs *flag.FlagSet, name string) bool {
+	passed := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			passed = true
+		}
+	})
+	return passed
+}
+
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(v)
+}
+
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(f.Fd())
+}
+
+// prompt reads a line from stdin, asking for it first when stdin is a
+// terminal.
+func prompt(label string) (string, error) {
+	if isTerminal(os.Stdin) {
+		fmt.Fprint(os.Stderr, label)
+	}
+	line, err := stdin.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// promptSecret is prompt without echoing what is typed.
+func promptSecret(label string) (string, error) {
+	if !isTerminal(os.Stdin) {
+		return prompt(label)
+	}
+	fmt.Fprint(os.Stderr, label)
+	secret, err := term.ReadPassword(os.Stdin.Fd())
+	fmt.Fprintln(os.Stderr)
+	return string(secret), err
+}
+
+func loginCommand(ctx context.Context, api *client.Client, serverURL string, args []string) error {
+	fs, asJSON := newFlagSet("login")
+	email := fs.String("email", "", "email address to log in with")
+	if _, err := parseArgs(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	var err error
+	if *email == "" {
+		if *email, err = prompt("Email: "); err != nil {
+			return err
+		}
+	}
+	password, err := promptSecret("Password: ")
+	if err != nil {
+		return err
+	}
+
+	auth, err := api.Login(ctx, strings.TrimSpace(*email), password)
+	if err != nil {
+		return err
+	}
+	session := savedSession{ServerURL: serverURL, User: auth.User, Token: auth.Token, ExpiresAt: auth.ExpiresAt}
+	if err := saveSession(session); err != nil {
+		return fmt.Errorf("logged in but failed to remember the session: %w", err)
+	}
+
+	if *asJSON {
+		return printJSON(auth.User)
+	}
+	fmt.Printf("Logged in as %s (%s)\n", auth.User.Username, auth.User.Email)
+	return nil
+}
+
+func logoutCommand(ctx context.Context, api *client.Client, args []string) error {
+	fs, _ := newFlagSet("logout")
+	all := fs.Bool("all", false, "end every session of the user, not only this one")
+	if _, err := parseArgs(fs, args, 0, 0); err != nil {
+		return err
+	}
+	// The saved session goes even when the server can't be reached, or this
+	// device would stay logged in.
+	err := api.Logout(ctx, *all)
+	if err != nil {
+		err = fmt.Errorf("logged out on this device, but the server session may still be open: %w", err)
+	}
+	return errors.Join(deleteSession(), err)
+}
+
+// userLocation returns the time zone of the user's settings, or the device's
+// when they don't name one.
+func userLocation(ctx context.Context, api *client.Client) (*time.Location, error) {
+	settings, err := api.Settings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if settings.TimeZone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(settings.TimeZone)
+}
+
+// cliVault unlocks the journal of a user who set up encryption. Commands only
+// open it when they meet an encrypted entry or write one.
+type cliVault struct {
+	api   *client.Client
+	vault *vault
+}
+
+func (v *cliVault) open(ctx context.Context) (*vault, error) {
+	if v.vault != nil {
+		return v.vault, nil
+	}
+
+	opened := &vault{}
+	envelope, err := v.api.GetKey(ctx)
+	if errors.Is(err, client.ErrEncryptionDisabled) {
+		v.vault = opened
+		return opened, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	opened.SetEnvelope(envelope)
+
+	passphrase, ok := os.LookupEnv("JOURNALCLI_PASSPHRASE")
+	if !ok {
+		if !isTerminal(os.Stdin) {
+			return nil, errors.New("journal is encrypted, set JOURNALCLI_PASSPHRASE to unlock it")
+		}
+		if passphrase, err = promptSecret("Passphrase: "); err != nil {
+			return nil, err
+		}
+	}
+	key, err := e2ee.Unwrap(*envelope, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	opened.SetKey(&key)
+
+	v.vault = opened
+	return opened, nil
+}
+
+// openEntries decrypts the entries that are encrypted, unlocking the journal
+// only if there are any.
+func (v *cliVault) openEntries(ctx context.Context, entries []client.Entry) error {
+	for i := range entries {
+		if entries[i].Nonce == "" {
+			continue
+		}
+		opened, err := v.open(ctx)
+		if err != nil {
+			return err
+		}
+		opened.openEntry(&entries[i])
+	}
+	return nil
+}
+
+func newCommand(ctx context.Context, api *client.Client, args []string) error {
+	fs, asJSON := newFlagSet("new")
+	message := fs.String("m", "", "body of the entry; read from stdin or $EDITOR when empty")
+	mood := fs.Int("mood", 0, "mood from 1 to 5")
+	if _, err := parseArgs(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	body := *message
+	var err error
+	switch {
+	case body != "":
+	case !isTerminal(os.Stdin):
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return err
+		}
+		body = string(data)
+	default:
+		if body, err = editBody(""); err != nil {
+			return err
+		}
+	}
+	// Editors and echo end files with a newline.
+	body = strings.TrimRight(body, "\r\n")
+	if strings.TrimSpace(body) == "" {
+		return errors.New("entry is empty, nothing saved")
+	}
+
+	req := client.CreateEntryRequest{}
+	if *mood != 0 {
+		req.Mood = mood
+	}
+
+	vault, err := (&cliVault{api: api}).open(ctx)
+	if err != nil {
+		return err
+	}
+	if req.Body, req.Encryption, err = vault.seal(body); err != nil {
+		return err
+	}
+
+	entry, err := api.CreateEntry(ctx, req)
+	if err != nil {
+		return err
+	}
+	vault.openEntry(entry)
+
+	if *asJSON {
+		return printJSON(entry)
+	}
+	loc, err := userLocation(ctx, api)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Saved entry %s at %s\n", entry.ID, entry.CreatedAt.In(loc).Format("2006-01-02 15:04"))
+	return nil
+}
+
+// editBody opens $VISUAL or $EDITOR on a temporary file holding body and
+// returns what was saved.
+func editBody(body string) (string, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	f, err := os.CreateTemp("", "journalcli-*.md")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(body); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	// The editor can come with arguments, e.g. "code --wait".
+	fields := strings.Fields(editor)
+	cmd := exec.Command(fields[0], append(fields[1:], f.Name())...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s failed: %w", editor, err)
+	}
+
+	data, err := os.ReadFile(f.Name())
+	return string(data), err
+}
+
+// parseSince reads how far back to list entries from, either a duration like
+// 7d, 2w or 36h, or a date like 2006-01-02 starting in the time zone of now.
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if date, err := time.ParseInLocation(time.DateOnly, value, now.Location()); err == nil {
+		return date, nil
+	}
+
+	units := map[byte]time.Duration{'d': 24 * time.Hour, 'w': 7 * 24 * time.Hour}
+	if unit, ok := units[value[len(value)-1]]; ok && len(value) > 1 {
+		n, err := strconv.Atoi(value[:len(value)-1])
+		if err == nil && n >= 0 {
+			return now.Add(-time.Duration(n) * unit), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q, expected e.g. 7d, 2w, 36h or 2006-01-02", value)
+}
+
+func listCommand(ctx context.Context, api *client.Client, args []string) error {
+	fs, asJSON := newFlagSet("list")
+	since := fs.String("since", "", "only entries written since then, e.g. 7d, 2w, 36h or 2006-01-02")
+	tag := fs.String("tag", "", "only entries with this tag")
+	limit := fs.Int("limit", 20, "list at most this many entries, 0 for no limit")
+	if _, err := parseArgs(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	loc, err := userLocation(ctx, api)
+	if err != nil {
+		return err
+	}
+	var after time.Time
+	if *since != "" {
+		if after, err = parseSince(*since, time.Now().In(loc)); err != nil {
+			return err
+		}
+	}
+
+	entries, err := listEntriesSince(ctx, api, *tag, after, *limit)
+	if err != nil {
+		return err
+	}
+	if err := (&cliVault{api: api}).openEntries(ctx, entries); err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return printJSON(entries)
+	}
+	if len(entries) == 0 {
+		fmt.Println("No entries")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tDATE\tMOOD\tTAGS\tTITLE")
+	for _, entry := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", entry.ID, entry.CreatedAt.In(loc).Format("2006-01-02 15:04"),
+			moodEmoji(entry.Mood), entryTagsLine(entry.Tags), entryTitle(entry.Body))
+	}
+	return w.Flush()
+}
+
+// listEntriesSince pages through the entries written after a time, newest
+// first, stopping after limit of them unless limit is 0.
+func listEntriesSince(ctx context.Context, api *client.Client, tag string, after time.Time, limit int) ([]client.Entry, error) {
+	entries := []client.Entry{}
+	opts := client.ListEntriesOptions{Limit: 100, Tag: tag}
+	for {
+		page, err := api.ListEntries(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range page.Entries {
+			if entry.CreatedAt.Before(after) || (limit > 0 && len(entries) == limit) {
+				return entries, nil
+			}
+			entries = append(entries, entry)
+		}
+		if page.NextCursor == "" {
+			return entries, nil
+		}
+		opts.Cursor = page.NextCursor
+	}
+}
+
+func showCommand(ctx context.Context, api *client.Client, args []string) error {
+	fs, asJSON := newFlagSet("show")
+	rest, err := parseArgs(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	entry, err := api.GetEntry(ctx, rest[0])
+	if errors.Is(err, client.ErrNotFound) {
+		return fmt.Errorf("no entry with id %s", rest[0])
+	}
+	if err != nil {
+		return err
+	}
+	entries := []client.Entry{*entry}
+	if err := (&cliVault{api: api}).openEntries(ctx, entries); err != nil {
+		return err
+	}
+	entry = &entries[0]
+
+	if *asJSON {
+		return printJSON(entry)
+	}
+	loc, err := userLocation(ctx, api)
+	if err != nil {
+		return err
+	}
+	header := []string{entry.CreatedAt.In(loc).Format("Monday, 2006-01-02 15:04")}
+	if mood := moodEmoji(entry.Mood); mood != "" {
+		header = append(header, mood)
+	}
+	if tags := entryTagsLine(entry.Tags); tags != "" {
+		header = append(header, tags)
+	}
+	fmt.Println(strings.Join(header, "  "))
+	fmt.Println()
+	fmt.Println(strings.TrimRight(entry.Body, "\n"))
+	return nil
+}
+
+func searchCommand(ctx context.Context, api *client.Client, args []string) error {
+	fs, asJSON := newFlagSet("search")
+	limit := fs.Int("limit", 0, "show at most this many results, 0 for the server's default")
+	rest, err := parseArgs(fs, args, 1, -1)
+	if err != nil {
+		return err
+	}
+
+	results, err := api.SearchEntries(ctx, strings.Join(rest, " "), *limit)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return printJSON(results)
+	}
+	if len(results) == 0 {
+		fmt.Println("No matches")
+		return nil
+	}
+	loc, err := userLocation(ctx, api)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tDATE\tMATCH")
+	for _, result := range results {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", result.ID, result.CreatedAt.In(loc).Format("2006-01-02 15:04"),
+			highlightSnippet(result.Snippet))
+	}
+	return w.Flush()
+}
+
+func exportCommand(ctx context.Context, api *client.Client, args []string) error {
+	fs, asJSON := newFlagSet("export")
+	formatName := fs.String("format", string(export.Markdown), "markdown, json, jsonl or html")
+	rest, err := parseArgs(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	format, err := export.ParseFormat(*formatName)
+	if err != nil {
+		return err
+	}
+
+	// Days and months are grouped in the time zone of the Settings page, like
+	// exports from the TUI.
+	loc, err := userLocation(ctx, api)
+	if err != nil {
+		return err
+	}
+
+	path := rest[0]
+	var exp export.Exporter
+	switch {
+	case path != "-":
+		if exp, err = export.Create(format, path, loc); err != nil {
+			return err
+		}
+	case format == export.JSON:
+		exp = export.NewJSON(os.Stdout)
+	case format == export.JSONL:
+		exp = export.NewJSONL(os.Stdout)
+	default:
+		return fmt.Errorf("%s exports go to a directory, not stdout", format)
+	}
+
+	vault := &cliVault{api: api}
+	count, err := exportJournal(ctx, api, exp, func(entries []client.Entry) error {
+		return vault.openEntries(ctx, entries)
+	})
+	if err := errors.Join(err, exp.Close()); err != nil {
+		return err
+	}
+
+	switch {
+	case path == "-":
+	case *asJSON:
+		return printJSON(map[string]any{"format": format, "path": path, "entries": count})
+	default:
+		fmt.Printf("Exported %d entries to %s\n", count, path)
+	}
+	return nil
+}
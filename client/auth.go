@@ -0,0 +1,66 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// Login logs in with a password. The token of the new session is used for
+// the requests that follow.
+func (c *Client) Login(ctx context.Context, email, password string) (*AuthResponse, error) {
+	var auth AuthResponse
+	req := LoginRequest{Email: email, Password: password}
+	if err := c.do(ctx, http.MethodPost, "/login", req, http.StatusOK, &auth); err != nil {
+		return nil, err
+	}
+	c.SetToken(auth.Token)
+	return &auth, nil
+}
+
+// Signup creates an account and logs into it like Login. Taken emails and
+// usernames fail with ErrEmailTaken and ErrUsernameTaken.
+func (c *Client) Signup(ctx context.Context, username, email, password string) (*AuthResponse, error) {
+	var auth AuthResponse
+	req := SignupRequest{Username: username, Email: email, Password: password}
+	if err := c.do(ctx, http.MethodPost, "/signup", req, http.StatusCreated, &auth); err != nil {
+		return nil, err
+	}
+	c.SetToken(auth.Token)
+	return &auth, nil
+}
+
+// Logout ends the current session, or every session of the user when all is
+// set. A session that already expired counts as logged out. The client
+// forgets its token even when the server couldn't be told.
+func (c *Client) Logout(ctx context.Context, all bool) error {
+	err := c.do(ctx, http.MethodPost, "/logout", LogoutRequest{All: all}, http.StatusNoContent, nil)
+	c.SetToken("")
+	if err != nil && !errors.Is(err, ErrUnauthorized) {
+		return err
+	}
+	return nil
+}
+
+// StartGoogleLogin starts a login with Google. It fails with ErrNotConfigured
+// when the server has no Google client.
+func (c *Client) StartGoogleLogin(ctx context.Context) (*DeviceAuthorization, error) {
+	var auth DeviceAuthorization
+	if err := c.do(ctx, http.MethodPost, "/auth/google/device", nil, http.StatusOK, &auth); err != nil {
+		return nil, err
+	}
+	return &auth, nil
+}
+
+// PollGoogleLogin logs in once the user approved a device authorization. Until
+// then it fails with ErrAuthorizationPending, or ErrSlowDown when polled
+// faster than the interval; ErrAccessDenied and ErrExpired end the login.
+func (c *Client) PollGoogleLogin(ctx context.Context, deviceCode string) (*AuthResponse, error) {
+	var auth AuthResponse
+	req := DeviceTokenRequest{DeviceCode: deviceCode}
+	if err := c.do(ctx, http.MethodPost, "/auth/google/token", req, http.StatusOK, &auth); err != nil {
+		return nil, err
+	}
+	c.SetToken(auth.Token)
+	return &auth, nil
+}
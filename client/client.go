@@ -0,0 +1,158 @@
+// Package client talks to the journal API. It holds the types the API sends
+// and receives, shared by the server, the TUI and scripts.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// APIPath is the version of the API this package speaks, under the server URL.
+const APIPath = "/api/v1"
+
+const (
+	// DefaultTimeout bounds each attempt of a request.
+	DefaultTimeout = 10 * time.Second
+
+	// DefaultRetries is how many times GET and HEAD requests are retried after
+	// network errors and 502, 503 or 504 responses.
+	DefaultRetries = 2
+
+	maxBackoff = 5 * time.Second
+)
+
+// Client is a session with a server. It is safe for concurrent use, so the
+// token set after logging in is seen by requests already queued up.
+type Client struct {
+	serverURL string
+	baseURL   string
+	http      *http.Client
+
+	// Retries and Backoff configure retrying safe requests: the wait
+	// before retry n is a random duration up to Backoff * 2^n.
+	Retries int
+	Backoff time.Duration
+
+	mu    sync.RWMutex
+	token string
+}
+
+// New returns a client for the server at serverURL, e.g.
+// "http://localhost:8080".
+func New(serverURL string) *Client {
+	return &Client{
+		serverURL: serverURL,
+		baseURL:   strings.TrimSuffix(serverURL, "/") + APIPath,
+		http:      &http.Client{Timeout: DefaultTimeout},
+		Retries:   DefaultRetries,
+		Backoff:   200 * time.Millisecond,
+	}
+}
+
+// ServerURL returns the server URL the client was created with.
+func (c *Client) ServerURL() string {
+	return c.serverURL
+}
+
+// SetToken sets the session token sent with every request, "" for none.
+func (c *Client) SetToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = token
+}
+
+func (c *Client) Token() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.token
+}
+
+// safe reports whether a request only reads, so it can be sent again when its
+// response was lost. PUT and DELETE are left out: a lost update already
+// bumped the entry's version, and sending it again fails as a conflict.
+func safe(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
+
+// retryable reports whether a failed attempt may succeed when tried again.
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.Status {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	// Anything else failed before a response came back.
+	return true
+}
+
+// do sends a JSON request to path and decodes the response into out when it
+// has the status want. Other responses come back as *APIError.
+func (c *Client) do(ctx context.Context, method, path string, in any, want int, out any) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+
+	retries := 0
+	if safe(method) {
+		retries = c.Retries
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := c.send(ctx, method, path, body, want, out)
+		if err == nil || attempt >= retries || !retryable(ctx, err) {
+			return err
+		}
+
+		wait := rand.N(min(c.Backoff<<attempt, maxBackoff) + 1)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, path string, body []byte, want int, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if token := c.Token(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != want {
+		return responseError(res)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// dropFirst passes requests on but loses the response of the first one with
+// the given method, as if the connection broke after the server answered.
+type dropFirst struct {
+	method  string
+	mu      sync.Mutex
+	dropped bool
+}
+
+func (d *dropFirst) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil || req.Method != d.method {
+		return res, err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.dropped {
+		return res, nil
+	}
+	d.dropped = true
+	res.Body.Close()
+	return nil, errors.New("connection reset")
+}
+
+func TestUpdateEntryNotRetriedAfterLostResponse(t *testing.T) {
+	// The server keeps one entry, checking base_version like the real one.
+	var mu sync.Mutex
+	version, revisions, conflicts := 1, 0, 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			var req UpdateEntryRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			if req.BaseVersion != nil && *req.BaseVersion != version {
+				conflicts++
+				w.WriteHeader(http.StatusConflict)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: APIError{Code: CodeVersionConflict}})
+				return
+			}
+			revisions++
+			version++
+			json.NewEncoder(w).Encode(Entry{ID: "1", Body: req.Body, Version: version})
+		}
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL)
+	c.Backoff = 0
+	c.http.Transport = &dropFirst{method: http.MethodPut}
+
+	base := 1
+	_, err := c.UpdateEntry(context.Background(), "1", UpdateEntryRequest{Body: "edited", BaseVersion: &base})
+	if err == nil {
+		t.Fatal("UpdateEntry with its response lost: err = nil, want the network error")
+	}
+	if errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("UpdateEntry was sent again and conflicted with itself: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if revisions != 1 || version != 2 {
+		t.Errorf("after one update: %d revisions at version %d, want 1 at version 2", revisions, version)
+	}
+	// Offline sync pushes the edit as a new entry on a conflict.
+	if conflicts != 0 {
+		t.Errorf("after one update: %d conflicts, want none to fork the entry over", conflicts)
+	}
+}
@@ -0,0 +1,114 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ListEntries returns a page of entries, newest first. The next page is
+// listed by passing NextCursor back in opts, until it is "".
+func (c *Client) ListEntries(ctx context.Context, opts ListEntriesOptions) (*ListEntriesResponse, error) {
+	params := url.Values{}
+	if opts.Limit > 0 {
+		params.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Cursor != "" {
+		params.Set("cursor", opts.Cursor)
+	}
+	if opts.Tag != "" {
+		params.Set("tag", opts.Tag)
+	}
+	if opts.MoodMin != nil {
+		params.Set("mood_min", strconv.Itoa(*opts.MoodMin))
+	}
+	if opts.MoodMax != nil {
+		params.Set("mood_max", strconv.Itoa(*opts.MoodMax))
+	}
+
+	var page ListEntriesResponse
+	if err := c.do(ctx, http.MethodGet, "/entries?"+params.Encode(), nil, http.StatusOK, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (c *Client) GetEntry(ctx context.Context, id string) (*Entry, error) {
+	var entry Entry
+	if err := c.do(ctx, http.MethodGet, "/entries/"+url.PathEscape(id), nil, http.StatusOK, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (c *Client) CreateEntry(ctx context.Context, req CreateEntryRequest) (*Entry, error) {
+	var entry Entry
+	if err := c.do(ctx, http.MethodPost, "/entries", req, http.StatusCreated, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (c *Client) UpdateEntry(ctx context.Context, id string, req UpdateEntryRequest) (*Entry, error) {
+	var entry Entry
+	if err := c.do(ctx, http.MethodPut, "/entries/"+url.PathEscape(id), req, http.StatusOK, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (c *Client) DeleteEntry(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/entries/"+url.PathEscape(id), nil, http.StatusNoContent, nil)
+}
+
+// EntryRevisions returns the previous versions of an entry, newest first.
+func (c *Client) EntryRevisions(ctx context.Context, id string) ([]EntryRevision, error) {
+	var revisions []EntryRevision
+	path := "/entries/" + url.PathEscape(id) + "/revisions"
+	if err := c.do(ctx, http.MethodGet, path, nil, http.StatusOK, &revisions); err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+// EntryChanges returns the entries changed and deleted since a cursor from a
+// previous call, or all of them for the zero time.
+func (c *Client) EntryChanges(ctx context.Context, since time.Time) (*EntryChangesResponse, error) {
+	params := url.Values{}
+	if !since.IsZero() {
+		params.Set("since", since.Format(time.RFC3339Nano))
+	}
+
+	var changes EntryChangesResponse
+	if err := c.do(ctx, http.MethodGet, "/entries/changes?"+params.Encode(), nil, http.StatusOK, &changes); err != nil {
+		return nil, err
+	}
+	return &changes, nil
+}
+
+// SearchEntries runs a full-text search, best match first. q supports quoted
+// phrases, "or" and -negation. A limit of 0 uses the server's default.
+func (c *Client) SearchEntries(ctx context.Context, q string, limit int) ([]SearchResult, error) {
+	params := url.Values{}
+	params.Set("q", q)
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+
+	var results []SearchResult
+	if err := c.do(ctx, http.MethodGet, "/entries/search?"+params.Encode(), nil, http.StatusOK, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Tags returns the tags of the user's entries, most used first.
+func (c *Client) Tags(ctx context.Context) ([]TagCount, error) {
+	var tags []TagCount
+	if err := c.do(ctx, http.MethodGet, "/tags", nil, http.StatusOK, &tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
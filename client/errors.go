@@ -0,0 +1,108 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Error codes tell clients what went wrong without them parsing messages.
+const (
+	CodeInvalidRequest     = "invalid_request"
+	CodeValidationFailed   = "validation_failed"
+	CodePayloadTooLarge    = "payload_too_large"
+	CodeUnauthorized       = "unauthorized"
+	CodeInvalidCredentials = "invalid_credentials"
+	CodeForbidden          = "forbidden"
+	CodeNotFound           = "not_found"
+	CodeMethodNotAllowed   = "method_not_allowed"
+	CodeEmailTaken         = "email_taken"
+	CodeUsernameTaken      = "username_taken"
+	CodeVersionConflict    = "version_conflict"
+	CodeKeyMismatch        = "key_mismatch"
+	CodeEncryptionDisabled = "encryption_not_set_up"
+	CodeNotConfigured      = "not_configured"
+	CodeAuthPending        = "authorization_pending"
+	CodeSlowDown           = "slow_down"
+	CodeAccessDenied       = "access_denied"
+	CodeExpired            = "expired"
+	CodeProviderError      = "provider_error"
+	CodeTimeout            = "timeout"
+	CodeInternal           = "internal_error"
+)
+
+// APIError is the body of every error response, inside ErrorResponse. Fields
+// maps request fields to what is wrong with them, for validation errors.
+//
+// Errors returned by Client for failed requests are *APIError, and match the
+// errors below with errors.Is by their code:
+//
+//	if errors.Is(err, client.ErrVersionConflict) {
+type APIError struct {
+	// Status is the HTTP status of the response, filled in by Client.
+	Status    int               `json:"-"`
+	Code      string            `json:"code"`
+	Message   string            `json:"message"`
+	Fields    map[string]string `json:"fields,omitempty"`
+	RequestID string            `json:"request_id,omitempty"`
+}
+
+type ErrorResponse struct {
+	Error APIError `json:"error"`
+}
+
+var (
+	ErrUnauthorized         = &APIError{Code: CodeUnauthorized, Message: "Not logged in"}
+	ErrInvalidCredentials   = &APIError{Code: CodeInvalidCredentials, Message: "Wrong email or password"}
+	ErrNotFound             = &APIError{Code: CodeNotFound, Message: "Not found"}
+	ErrEmailTaken           = &APIError{Code: CodeEmailTaken, Message: "Email already registered"}
+	ErrUsernameTaken        = &APIError{Code: CodeUsernameTaken, Message: "Username already taken"}
+	ErrVersionConflict      = &APIError{Code: CodeVersionConflict, Message: "Entry was changed since it was loaded"}
+	ErrEncryptionDisabled   = &APIError{Code: CodeEncryptionDisabled, Message: "Encryption is not set up"}
+	ErrNotConfigured        = &APIError{Code: CodeNotConfigured, Message: "Not available on this server"}
+	ErrAuthorizationPending = &APIError{Code: CodeAuthPending, Message: "Authorization pending"}
+	ErrSlowDown             = &APIError{Code: CodeSlowDown, Message: "Polling too fast"}
+	ErrAccessDenied         = &APIError{Code: CodeAccessDenied, Message: "Access denied"}
+	ErrExpired              = &APIError{Code: CodeExpired, Message: "Expired"}
+)
+
+// Error reads like "Can't sign up: email is not a valid email address", so it
+// can be shown as is.
+func (e *APIError) Error() string {
+	if len(e.Fields) == 0 {
+		return e.Message
+	}
+
+	names := make([]string, 0, len(e.Fields))
+	for name := range e.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	problems := make([]string, len(names))
+	for i, name := range names {
+		problems[i] = name + " " + e.Fields[name]
+	}
+	return e.Message + ": " + strings.Join(problems, ", ")
+}
+
+// Is reports whether target is an *APIError with the same code.
+func (e *APIError) Is(target error) bool {
+	t, ok := target.(*APIError)
+	return ok && t.Code != "" && t.Code == e.Code
+}
+
+// responseError reads the error out of a failed response, falling back to its
+// status when the body isn't an error envelope, e.g. from a proxy.
+func responseError(res *http.Response) error {
+	var envelope struct {
+		Error *APIError `json:"error"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&envelope); err != nil || envelope.Error == nil || envelope.Error.Message == "" {
+		return &APIError{Status: res.StatusCode, Message: fmt.Sprintf("server returned status: %s", res.Status)}
+	}
+	envelope.Error.Status = res.StatusCode
+	return envelope.Error
+}
@@ -0,0 +1,23 @@
+package client
+
+import (
+	"context"
+	"journalCli/e2ee"
+	"net/http"
+)
+
+// GetKey returns the user's wrapped data key. It fails with
+// ErrEncryptionDisabled when the user hasn't set up encryption.
+func (c *Client) GetKey(ctx context.Context) (*e2ee.KeyEnvelope, error) {
+	var envelope e2ee.KeyEnvelope
+	if err := c.do(ctx, http.MethodGet, "/keys", nil, http.StatusOK, &envelope); err != nil {
+		return nil, err
+	}
+	return &envelope, nil
+}
+
+// PutKey stores the user's wrapped data key, setting up encryption or
+// replacing the key after a passphrase change.
+func (c *Client) PutKey(ctx context.Context, envelope e2ee.KeyEnvelope) error {
+	return c.do(ctx, http.MethodPut, "/keys", envelope, http.StatusOK, nil)
+}
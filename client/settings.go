@@ -0,0 +1,26 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Settings returns the user's settings, the server's defaults until they save
+// some.
+func (c *Client) Settings(ctx context.Context) (*Settings, error) {
+	var settings Settings
+	if err := c.do(ctx, http.MethodGet, "/settings", nil, http.StatusOK, &settings); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// PutSettings replaces the user's settings. Invalid ones fail with an
+// APIError of CodeValidationFailed naming the fields.
+func (c *Client) PutSettings(ctx context.Context, settings Settings) (*Settings, error) {
+	var saved Settings
+	if err := c.do(ctx, http.MethodPut, "/settings", settings, http.StatusOK, &saved); err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
@@ -0,0 +1,42 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Streak returns how many days in a row the user has written, counting days
+// in the IANA time zone tz, e.g. "Europe/Berlin", or UTC when it is "".
+func (c *Client) Streak(ctx context.Context, tz string) (*Streak, error) {
+	params := url.Values{}
+	if tz != "" {
+		params.Set("tz", tz)
+	}
+
+	var streak Streak
+	if err := c.do(ctx, http.MethodGet, "/stats/streak?"+params.Encode(), nil, http.StatusOK, &streak); err != nil {
+		return nil, err
+	}
+	return &streak, nil
+}
+
+// Stats sums up the entries of the last days days, today included, counting
+// days and hours in the IANA time zone tz like Streak. days <= 0 leaves the
+// span to the server, a year.
+func (c *Client) Stats(ctx context.Context, tz string, days int) (*Stats, error) {
+	params := url.Values{}
+	if tz != "" {
+		params.Set("tz", tz)
+	}
+	if days > 0 {
+		params.Set("days", strconv.Itoa(days))
+	}
+
+	var stats Stats
+	if err := c.do(ctx, http.MethodGet, "/stats?"+params.Encode(), nil, http.StatusOK, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
@@ -0,0 +1,187 @@
+package client
+
+import "time"
+
+type User struct {
+	ID       string `json:"id"`
+	Email    string `json:"email"`
+	Username string `json:"username"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type SignupRequest struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// LogoutRequest ends every session of the user instead of only the current
+// one when All is set.
+type LogoutRequest struct {
+	All bool `json:"all"`
+}
+
+type AuthResponse struct {
+	User      User      `json:"user"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// DeviceAuthorization is a pending login with Google: the user approves it by
+// entering UserCode at VerificationURI while the client polls with DeviceCode.
+type DeviceAuthorization struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+type DeviceTokenRequest struct {
+	DeviceCode string `json:"device_code"`
+}
+
+// Encryption describes how an encrypted body was sealed. The zero value means
+// the body is plaintext.
+type Encryption struct {
+	Nonce    string `json:"nonce,omitempty"`
+	KeyCheck string `json:"key_check,omitempty"`
+}
+
+type Entry struct {
+	ID     string `json:"id"`
+	UserID string `json:"user_id"`
+	Body   string `json:"body"`
+	Encryption
+	Mood      *int      `json:"mood"`
+	Tags      []string  `json:"tags"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Locked is for clients to mark encrypted entries they could not decrypt;
+	// Body is then a placeholder.
+	Locked bool `json:"-"`
+}
+
+type CreateEntryRequest struct {
+	Body string `json:"body"`
+	Encryption
+	Mood *int `json:"mood"`
+	// CreatedAt backdates the entry, e.g. one written offline. The zero
+	// value means now.
+	CreatedAt time.Time `json:"created_at,omitzero"`
+}
+
+// UpdateEntryRequest replaces the body of an entry. Mood is left alone when
+// nil. With BaseVersion set, the update fails with ErrVersionConflict if the
+// entry was changed since that version.
+type UpdateEntryRequest struct {
+	Body string `json:"body"`
+	Encryption
+	Mood        *int `json:"mood"`
+	BaseVersion *int `json:"base_version,omitempty"`
+}
+
+// ListEntriesOptions pages through and filters ListEntries. Zero values use
+// the server's defaults and don't filter.
+type ListEntriesOptions struct {
+	Limit   int
+	Cursor  string
+	Tag     string
+	MoodMin *int
+	MoodMax *int
+}
+
+type ListEntriesResponse struct {
+	Entries    []Entry `json:"entries"`
+	NextCursor string  `json:"next_cursor"`
+}
+
+// EntryChangesResponse lists what changed since a cursor; the next call passes
+// Cursor to get what changed after this one.
+type EntryChangesResponse struct {
+	Entries []Entry   `json:"entries"`
+	Deleted []string  `json:"deleted"`
+	Cursor  time.Time `json:"cursor"`
+}
+
+// EntryRevision is a previous body of an entry. CreatedAt is when that
+// version was written, not when it was replaced.
+type EntryRevision struct {
+	ID      string `json:"id"`
+	EntryID string `json:"entry_id"`
+	Body    string `json:"body"`
+	Encryption
+	CreatedAt time.Time `json:"created_at"`
+	Locked    bool      `json:"-"`
+}
+
+// SearchResult is an entry matching a search. The matching words of Snippet
+// are wrapped in <mark> and </mark>.
+type SearchResult struct {
+	Entry
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+type TagCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// Streak is how regularly the user writes: Current is the days in a row up to
+// today, or up to yesterday while today's entry isn't written yet.
+type Streak struct {
+	Current       int  `json:"current"`
+	Longest       int  `json:"longest"`
+	DaysThisMonth int  `json:"days_this_month"`
+	WroteToday    bool `json:"wrote_today"`
+}
+
+// Stats sums up the user's entries for the Insights page. Encrypted entries
+// count as zero words, since the server can't read them.
+type Stats struct {
+	Entries int `json:"entries"`
+	Words   int `json:"words"`
+	// Days lists the days with entries, oldest first.
+	Days []DayStats `json:"days"`
+	// Hours counts entries by the hour of the day they were written at.
+	Hours       [24]int    `json:"hours"`
+	MoodAverage *float64   `json:"mood_average"`
+	Tags        []TagCount `json:"tags"`
+}
+
+type DayStats struct {
+	// Date is the day as 2006-01-02.
+	Date    string   `json:"date"`
+	Entries int      `json:"entries"`
+	Words   int      `json:"words"`
+	Mood    *float64 `json:"mood"`
+}
+
+// Editor modes of Settings.EditorMode.
+const (
+	EditorStandard = "standard"
+	EditorVim      = "vim"
+)
+
+// Settings are the user's preferences, kept on the server so that every
+// client they log in from picks them up.
+type Settings struct {
+	EditorMode string `json:"editor_mode"`
+	// TimeZone is an IANA time zone name, the device's own when empty.
+	TimeZone string `json:"time_zone"`
+	// DateFormat is the Go layout dates are shown with.
+	DateFormat string `json:"date_format"`
+	// ReminderTime is when to be reminded to write, as 15:04, or empty to
+	// use the config's reminder_time.
+	ReminderTime string    `json:"reminder_time"`
+	Theme        string    `json:"theme"`
+	LineNumbers  bool      `json:"line_numbers"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
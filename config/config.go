@@ -0,0 +1,391 @@
+// Package config loads the settings shared by the client, the server and the
+// db package.
+//
+// Settings are resolved in this order, later sources winning:
+//
+//  1. built-in defaults
+//  2. the config file, $XDG_CONFIG_HOME/journalcli/config.toml unless
+//     -config or JOURNALCLI_CONFIG points somewhere else
+//  3. JOURNALCLI_* environment variables
+//  4. command-line flags
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	neturl "net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+const envPrefix = "JOURNALCLI_"
+
+type Config struct {
+	Client   Client   `toml:"client"`
+	Server   Server   `toml:"server"`
+	Database Database `toml:"database"`
+	OAuth    OAuth    `toml:"oauth"`
+	// Keys remaps the key bindings of the TUI, per key map and binding as
+	// named on its Help page, e.g. Keys["journal"]["save"]. It can only be set
+	// in the config file.
+	Keys map[string]map[string][]string `toml:"keys"`
+}
+
+type Client struct {
+	// ServerURL is where the TUI sends its requests.
+	ServerURL string `toml:"server_url"`
+	// ReminderTime is when `journalCli remind` reminds the user to write if
+	// they haven't yet that day, as 15:04 in local time. The reminder time in
+	// the user's settings on the server wins over it.
+	ReminderTime string `toml:"reminder_time"`
+}
+
+type Server struct {
+	// Listen is the address the server listens on, as host:port.
+	Listen string `toml:"listen"`
+	// RequestTimeout is how long a request may take before the server gives
+	// up on it with 503.
+	RequestTimeout time.Duration `toml:"request_timeout"`
+	// ShutdownTimeout is how long in-flight requests get to finish once the
+	// server is asked to stop.
+	ShutdownTimeout time.Duration `toml:"shutdown_timeout"`
+	// MaxBodyBytes caps the size of request bodies.
+	MaxBodyBytes int `toml:"max_body_bytes"`
+}
+
+// Database selects the store the server keeps its data in. Driver is
+// "postgres", "sqlite" (a file at Path) or "memory" (lost on restart); the
+// other fields only apply to Postgres.
+type Database struct {
+	Driver   string `toml:"driver"`
+	Path     string `toml:"path"`
+	Host     string `toml:"host"`
+	Port     int    `toml:"port"`
+	User     string `toml:"user"`
+	Password string `toml:"password"`
+	Name     string `toml:"name"`
+	SSLMode  string `toml:"sslmode"`
+}
+
+type OAuth struct {
+	Google OAuthProvider `toml:"google"`
+}
+
+// OAuthProvider is an OpenID Connect provider supporting the device
+// authorization grant. Login with it is disabled while ClientID is empty. The
+// endpoints default to Google's and can point to a local fake for testing.
+type OAuthProvider struct {
+	ClientID      string `toml:"client_id"`
+	ClientSecret  string `toml:"client_secret"`
+	DeviceAuthURL string `toml:"device_auth_url"`
+	TokenURL      string `toml:"token_url"`
+	JWKSURL       string `toml:"jwks_url"`
+	Issuer        string `toml:"issuer"`
+}
+
+func (p OAuthProvider) Enabled() bool {
+	return p.ClientID != ""
+}
+
+// DSN is the connection string for lib/pq.
+func (d Database) DSN() string {
+	u := neturl.URL{
+		Scheme:   "postgres",
+		User:     neturl.UserPassword(d.User, d.Password),
+		Host:     net.JoinHostPort(d.Host, strconv.Itoa(d.Port)),
+		Path:     "/" + d.Name,
+		RawQuery: neturl.Values{"sslmode": {d.SSLMode}}.Encode(),
+	}
+	return u.String()
+}
+
+// Default matches the docker-compose setup.
+func Default() Config {
+	return Config{
+		Client: Client{ServerURL: "http://localhost:8080", ReminderTime: "20:00"},
+		Server: Server{
+			Listen:          ":8080",
+			RequestTimeout:  30 * time.Second,
+			ShutdownTimeout: 15 * time.Second,
+			MaxBodyBytes:    1 << 20,
+		},
+		Database: Database{
+			Driver:   "postgres",
+			Path:     "journal.db",
+			Host:     "localhost",
+			Port:     5432,
+			User:     "postgres",
+			Password: "password",
+			Name:     "journaldb",
+			SSLMode:  "disable",
+		},
+		OAuth: OAuth{
+			Google: OAuthProvider{
+				DeviceAuthURL: "https://oauth2.googleapis.com/device/code",
+				TokenURL:      "https://oauth2.googleapis.com/token",
+				JWKSURL:       "https://www.googleapis.com/oauth2/v3/certs",
+				Issuer:        "https://accounts.google.com",
+			},
+		},
+	}
+}
+
+// DefaultPath is the config file under the user's config directory, which is
+// $XDG_CONFIG_HOME on Linux.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "journalcli", "config.toml"), nil
+}
+
+// setting is one value that can be set from the environment and the command
+// line; its field in the config file is set by toml.
+type setting struct {
+	name  string
+	usage string
+	set   func(cfg *Config, value string) error
+}
+
+func setString(field func(cfg *Config) *string) func(*Config, string) error {
+	return func(cfg *Config, value string) error {
+		*field(cfg) = value
+		return nil
+	}
+}
+
+func setInt(field func(cfg *Config) *int) func(*Config, string) error {
+	return func(cfg *Config, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		*field(cfg) = n
+		return nil
+	}
+}
+
+func setDuration(field func(cfg *Config) *time.Duration) func(*Config, string) error {
+	return func(cfg *Config, value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration like 30s or 1m", value)
+		}
+		*field(cfg) = d
+		return nil
+	}
+}
+
+var settings = []setting{
+	{"server-url", "URL of the journal server", setString(func(c *Config) *string { return &c.Client.ServerURL })},
+	{"reminder-time", "time of day to be reminded to write, as HH:MM", setString(func(c *Config) *string { return &c.Client.ReminderTime })},
+	{"listen", "address the server listens on", setString(func(c *Config) *string { return &c.Server.Listen })},
+	{"request-timeout", "how long a request may take", setDuration(func(c *Config) *time.Duration { return &c.Server.RequestTimeout })},
+	{"shutdown-timeout", "how long in-flight requests get to finish on shutdown", setDuration(func(c *Config) *time.Duration { return &c.Server.ShutdownTimeout })},
+	{"max-body-bytes", "maximum size of a request body in bytes", setInt(func(c *Config) *int { return &c.Server.MaxBodyBytes })},
+	{"db-driver", "database driver: postgres, sqlite or memory", setString(func(c *Config) *string { return &c.Database.Driver })},
+	{"db-path", "SQLite database file", setString(func(c *Config) *string { return &c.Database.Path })},
+	{"db-host", "Postgres host", setString(func(c *Config) *string { return &c.Database.Host })},
+	{"db-port", "Postgres port", setInt(func(c *Config) *int { return &c.Database.Port })},
+	{"db-user", "Postgres user", setString(func(c *Config) *string { return &c.Database.User })},
+	{"db-password", "Postgres password", setString(func(c *Config) *string { return &c.Database.Password })},
+	{"db-name", "Postgres database name", setString(func(c *Config) *string { return &c.Database.Name })},
+	{"db-sslmode", "Postgres sslmode", setString(func(c *Config) *string { return &c.Database.SSLMode })},
+	{"google-client-id", "Google OAuth client ID", setString(func(c *Config) *string { return &c.OAuth.Google.ClientID })},
+	{"google-client-secret", "Google OAuth client secret", setString(func(c *Config) *string { return &c.OAuth.Google.ClientSecret })},
+	{"google-device-auth-url", "Google device authorization endpoint", setString(func(c *Config) *string { return &c.OAuth.Google.DeviceAuthURL })},
+	{"google-token-url", "Google token endpoint", setString(func(c *Config) *string { return &c.OAuth.Google.TokenURL })},
+	{"google-jwks-url", "Google ID token signing keys", setString(func(c *Config) *string { return &c.OAuth.Google.JWKSURL })},
+	{"google-issuer", "Google ID token issuer", setString(func(c *Config) *string { return &c.OAuth.Google.Issuer })},
+}
+
+// envName is the environment variable of a setting, e.g. JOURNALCLI_DB_HOST.
+func envName(name string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// Load resolves the configuration of a program from its command-line
+// arguments (without the program name) and the environment, and validates it.
+// It also returns the arguments left after the flags. It returns flag.ErrHelp
+// when -h was passed, after printing the usage.
+func Load(program string, args []string) (Config, []string, error) {
+	fs := flag.NewFlagSet(program, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	configPath := fs.String("config", "", "path of the config file (env "+envName("config")+")")
+	flags := map[string]*string{}
+	for _, s := range settings {
+		flags[s.name] = fs.String(s.name, "", s.usage+" (env "+envName(s.name)+")")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			fs.SetOutput(os.Stderr)
+			fmt.Fprintf(os.Stderr, "Usage of %s:\n", program)
+			fs.PrintDefaults()
+		}
+		return Config{}, nil, err
+	}
+
+	cfg := Default()
+
+	path, explicit := *configPath, true
+	if path == "" {
+		path = os.Getenv(envName("config"))
+	}
+	if path == "" {
+		var err error
+		if path, err = DefaultPath(); err != nil {
+			return Config{}, nil, err
+		}
+		explicit = false
+	}
+	if err := loadFile(&cfg, path, explicit); err != nil {
+		return Config{}, nil, err
+	}
+
+	for _, s := range settings {
+		if value, ok := os.LookupEnv(envName(s.name)); ok {
+			if err := s.set(&cfg, value); err != nil {
+				return Config{}, nil, fmt.Errorf("invalid %s: %w", envName(s.name), err)
+			}
+		}
+	}
+
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.name == f.Name && err == nil {
+				if serr := s.set(&cfg, *flags[s.name]); serr != nil {
+					err = fmt.Errorf("invalid -%s: %w", s.name, serr)
+				}
+			}
+		}
+	})
+	if err != nil {
+		return Config{}, nil, err
+	}
+
+	return cfg, fs.Args(), cfg.Validate()
+}
+
+// loadFile reads a config file over cfg. A missing file is only an error when
+// it was asked for explicitly.
+func loadFile(cfg *Config, path string, explicit bool) error {
+	meta, err := toml.DecodeFile(path, cfg)
+	if errors.Is(err, os.ErrNotExist) && !explicit {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, len(undecoded))
+		for i, key := range undecoded {
+			keys[i] = key.String()
+		}
+		return fmt.Errorf("unknown settings in config file %s: %s", path, strings.Join(keys, ", "))
+	}
+	return nil
+}
+
+func isHTTPURL(s string) bool {
+	u, err := neturl.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+var sslModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+func (d Database) validatePostgres() []error {
+	var errs []error
+
+	if d.Host == "" {
+		errs = append(errs, errors.New("database.host: must not be empty"))
+	}
+	if d.Port < 1 || d.Port > 65535 {
+		errs = append(errs, fmt.Errorf("database.port: %d is not between 1 and 65535", d.Port))
+	}
+	if d.User == "" {
+		errs = append(errs, errors.New("database.user: must not be empty"))
+	}
+	if d.Name == "" {
+		errs = append(errs, errors.New("database.name: must not be empty"))
+	}
+	validSSLMode := false
+	for _, mode := range sslModes {
+		validSSLMode = validSSLMode || d.SSLMode == mode
+	}
+	if !validSSLMode {
+		errs = append(errs, fmt.Errorf("database.sslmode: %q is not one of %s", d.SSLMode, strings.Join(sslModes, ", ")))
+	}
+
+	return errs
+}
+
+// Validate reports every invalid setting at once.
+func (c Config) Validate() error {
+	var errs []error
+
+	if !isHTTPURL(c.Client.ServerURL) {
+		errs = append(errs, fmt.Errorf("client.server_url: %q is not an http(s) URL", c.Client.ServerURL))
+	}
+	if _, err := time.Parse("15:04", c.Client.ReminderTime); err != nil {
+		errs = append(errs, fmt.Errorf("client.reminder_time: %q is not a time like 20:00", c.Client.ReminderTime))
+	}
+
+	if _, port, err := net.SplitHostPort(c.Server.Listen); err != nil {
+		errs = append(errs, fmt.Errorf("server.listen: %q is not a host:port address", c.Server.Listen))
+	} else if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+		errs = append(errs, fmt.Errorf("server.listen: %q is not a valid port", port))
+	}
+	if c.Server.RequestTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("server.request_timeout: %s is not positive", c.Server.RequestTimeout))
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("server.shutdown_timeout: %s is not positive", c.Server.ShutdownTimeout))
+	}
+	if c.Server.MaxBodyBytes <= 0 {
+		errs = append(errs, fmt.Errorf("server.max_body_bytes: %d is not positive", c.Server.MaxBodyBytes))
+	}
+
+	switch c.Database.Driver {
+	case "postgres":
+		errs = append(errs, c.Database.validatePostgres()...)
+	case "sqlite":
+		if c.Database.Path == "" {
+			errs = append(errs, errors.New("database.path: must not be empty"))
+		}
+	case "memory":
+	default:
+		errs = append(errs, fmt.Errorf("database.driver: %q is not one of postgres, sqlite, memory", c.Database.Driver))
+	}
+
+	if google := c.OAuth.Google; google.Enabled() {
+		for _, endpoint := range []struct{ key, url string }{
+			{"oauth.google.device_auth_url", google.DeviceAuthURL},
+			{"oauth.google.token_url", google.TokenURL},
+			{"oauth.google.jwks_url", google.JWKSURL},
+		} {
+			if !isHTTPURL(endpoint.url) {
+				errs = append(errs, fmt.Errorf("%s: %q is not an http(s) URL", endpoint.key, endpoint.url))
+			}
+		}
+		if google.Issuer == "" {
+			errs = append(errs, errors.New("oauth.google.issuer: must not be empty"))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"journalCli/client"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/zalando/go-keyring"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// savedSession is the session kept between runs, so that the TUI and the
+// commands don't ask for the password every time.
+type savedSession struct {
+	ServerURL string      `json:"server_url"`
+	User      client.User `json:"user"`
+	Token     string      `json:"token"`
+	ExpiresAt time.Time   `json:"expires_at"`
+}
+
+var errNotLoggedIn = errors.New("not logged in, run `journalCli login` first")
+
+// credentialStore keeps the saved session secret at rest. load returns
+// os.ErrNotExist when nothing is saved.
+type credentialStore interface {
+	load() ([]byte, error)
+	save(data []byte) error
+	remove() error
+}
+
+// Where the session is kept in the OS keyring: the Secret Service on Linux,
+// the Keychain on macOS and the Credential Manager on Windows.
+const (
+	keyringService = "journalcli"
+	keyringUser    = "session"
+)
+
+type keyringStore struct{}
+
+func (keyringStore) load() ([]byte, error) {
+	secret, err := keyring.Get(keyringService, keyringUser)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return nil, os.ErrNotExist
+	}
+	return []byte(secret), err
+}
+
+func (keyringStore) save(data []byte) error {
+	return keyring.Set(keyringService, keyringUser, string(data))
+}
+
+func (keyringStore) remove() error {
+	err := keyring.Delete(keyringService, keyringUser)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// fileStore is the fallback for systems without a keyring, e.g. a server over
+// SSH. The session is encrypted with a random key kept in a separate file;
+// both are readable by the user only. That doesn't stop someone who can read
+// the user's files, but keeps the token out of backups and dotfile repos that
+// only pick up one of them.
+type fileStore struct {
+	dir string
+}
+
+func newFileStore() (fileStore, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return fileStore{}, err
+	}
+	return fileStore{dir: filepath.Join(dir, "journalcli")}, nil
+}
+
+func (s fileStore) keyPath() string {
+	return filepath.Join(s.dir, "session.key")
+}
+
+func (s fileStore) sessionPath() string {
+	return filepath.Join(s.dir, "session.enc")
+}
+
+// key reads the local key, creating it first if create is set.
+func (s fileStore) key(create bool) ([]byte, error) {
+	key, err := os.ReadFile(s.keyPath())
+	if err == nil && len(key) != chacha20poly1305.KeySize {
+		return nil, fmt.Errorf("%s is corrupted, delete it and log in again", s.keyPath())
+	}
+	if !errors.Is(err, os.ErrNotExist) || !create {
+		return key, err
+	}
+
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return nil, err
+	}
+	key = make([]byte, chacha20poly1305.KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(s.keyPath(), key, 0600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (s fileStore) load() ([]byte, error) {
+	sealed, err := os.ReadFile(s.sessionPath())
+	if err != nil {
+		return nil, err
+	}
+	key, err := s.key(false)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("%s is corrupted", s.sessionPath())
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	data, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: %w", s.sessionPath(), err)
+	}
+	return data, nil
+}
+
+func (s fileStore) save(data []byte) error {
+	key, err := s.key(true)
+	if err != nil {
+		return err
+	}
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(data)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	return os.WriteFile(s.sessionPath(), aead.Seal(nonce, nonce, data, nil), 0600)
+}
+
+func (s fileStore) remove() error {
+	if err := os.Remove(s.sessionPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// credentialStores returns where sessions are looked for, the keyring first.
+func credentialStores() []credentialStore {
+	stores := []credentialStore{keyringStore{}}
+	if files, err := newFileStore(); err == nil {
+		stores = append(stores, files)
+	}
+	return stores
+}
+
+// saveSession keeps the session in the keyring, or in the encrypted file when
+// there is no keyring to use.
+func saveSession(session savedSession) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	stores := credentialStores()
+	for i, store := range stores {
+		err := store.save(data)
+		if err == nil {
+			// Don't leave an older session behind in the stores after it.
+			for _, other := range stores[i+1:] {
+				other.remove()
+			}
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return fmt.Errorf("failed to save the session: %w", errors.Join(errs...))
+}
+
+// loadSession returns the saved session for serverURL, or errNotLoggedIn when
+// there is none or it has expired.
+func loadSession(serverURL string) (savedSession, error) {
+	for _, store := range credentialStores() {
+		data, err := store.load()
+		if err != nil {
+			// A keyring that can't be reached is the same as an empty one.
+			continue
+		}
+
+		var session savedSession
+		if err := json.Unmarshal(data, &session); err != nil {
+			return savedSession{}, fmt.Errorf("failed to read the saved session: %w", err)
+		}
+		if session.ServerURL != serverURL || session.Token == "" || time.Now().After(session.ExpiresAt) {
+			return savedSession{}, errNotLoggedIn
+		}
+		return session, nil
+	}
+	return savedSession{}, errNotLoggedIn
+}
+
+// deleteSession forgets the saved session wherever it is.
+func deleteSession() error {
+	var errs []error
+	for _, store := range credentialStores() {
+		if _, isKeyring := store.(keyringStore); isKeyring {
+			// Without a keyring there is nothing to delete from it.
+			store.remove()
+			continue
+		}
+		if err := store.remove(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -2,36 +2,42 @@ package db
 
 import (
 	"database/sql"
-	"fmt"
+	"journalCli/config"
 	"log"
-	"sync"
 
 	_ "github.com/lib/pq"
 )
 
-var (
-	instance *sql.DB
-	once     sync.Once
-)
+// settings are the connection settings used by InitDB.
+var settings = config.Default().Database
 
-const (
-	host     = "localhost"
-	port     = 5432
-	user     = "postgres"
-	password = "password"
-	dbname   = "journaldb"
-)
+// Configure sets the connection settings; call it before Connect or InitDB.
+func Configure(cfg config.Database) {
+	settings = cfg
+}
+
+// Connect opens and pings the database without checking its schema, for the
+// migration commands.
+func Connect() (*sql.DB, error) {
+	db, err := sql.Open("postgres", settings.DSN())
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
 
 func InitDB() *sql.DB {
-	psqlInfo := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable", host, port, user, password, dbname)
-	db, err := sql.Open("postgres", psqlInfo)
+	db, err := Connect()
 	if err != nil {
 		log.Fatal(err)
 	}
-	if err := db.Ping(); err != nil {
+	if err := CheckSchema(db); err != nil {
 		log.Fatal(err)
 	}
-	instance = db
 	return db
 }
 
@@ -40,13 +46,3 @@ func CloseDB(db *sql.DB) {
 		log.Fatal(err)
 	}
 }
-
-func GetDB() *sql.DB {
-	if instance == nil {
-		//if the databse is not initialized, initialize it (once.Do ensures that this code only runs once)
-		once.Do(func() {
-			instance = InitDB()
-		})
-	}
-	return instance
-}
@@ -0,0 +1,426 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"journalCli/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	MinMood = 1
+	MaxMood = 5
+)
+
+// Encryption describes how the body of an end-to-end encrypted entry was
+// sealed by the client. The zero value means the body is plaintext.
+type Encryption struct {
+	Nonce    string `json:"nonce,omitempty"`
+	KeyCheck string `json:"key_check,omitempty"`
+}
+
+type Entry struct {
+	ID     string `json:"id"`
+	UserID string `json:"user_id"`
+	Body   string `json:"body"`
+	Encryption
+	Mood      *int      `json:"mood"`
+	Tags      []string  `json:"tags"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// EntryFilter narrows down ListEntriesPage. Zero values don't filter.
+type EntryFilter struct {
+	Tag     string
+	MoodMin *int
+	MoodMax *int
+}
+
+// entryColumns selects an entry aliased as e, in the order scanEntry expects.
+const entryColumns = `e.id, e.user_id, e.body, COALESCE(e.nonce, ''), COALESCE(e.key_check, ''), e.mood, e.version, e.created_at, e.updated_at,
+	ARRAY(SELECT t.name FROM entry_tags et JOIN tags t ON t.id = et.tag_id WHERE et.entry_id = e.id ORDER BY t.name)`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanEntry scans entryColumns into entry, followed by any extra columns the
+// query selected after them.
+func scanEntry(row rowScanner, entry *Entry, extra ...any) error {
+	var mood sql.NullInt16
+	dest := []any{&entry.ID, &entry.UserID, &entry.Body, &entry.Nonce, &entry.KeyCheck, &mood, &entry.Version,
+		&entry.CreatedAt, &entry.UpdatedAt, pq.Array(&entry.Tags)}
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return err
+	}
+	entry.Mood = nil
+	if mood.Valid {
+		value := int(mood.Int16)
+		entry.Mood = &value
+	}
+	if entry.Tags == nil {
+		entry.Tags = []string{}
+	}
+	return nil
+}
+
+// setEntryTags replaces the tags of an entry, creating missing tags for the user.
+func setEntryTags(tx *sql.Tx, entryID, userID string, tags []string) error {
+	if _, err := tx.Exec(`DELETE FROM entry_tags WHERE entry_id = $1`, entryID); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		var tagID int
+		query := `INSERT INTO tags (user_id, name) VALUES ($1, $2) ON CONFLICT (user_id, name) DO UPDATE SET name = EXCLUDED.name RETURNING id`
+		if err := tx.QueryRow(query, userID, tag).Scan(&tagID); err != nil {
+			return fmt.Errorf("failed to insert tag: %w", err)
+		}
+		query = `INSERT INTO entry_tags (entry_id, tag_id) VALUES ($1, $2)`
+		if _, err := tx.Exec(query, entryID, tagID); err != nil {
+			return fmt.Errorf("failed to tag entry: %w", err)
+		}
+	}
+	return nil
+}
+
+// nullable stores empty strings as NULL.
+func nullable(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// BodyTags returns the hashtags of a body, or none when it is ciphertext.
+func BodyTags(body string, enc Encryption) []string {
+	if enc.Nonce != "" {
+		return []string{}
+	}
+	return utils.ParseHashtags(body)
+}
+
+// CreateEntry saves a new entry, tagging it with the hashtags of its body. A
+// zero createdAt means now; clients that wrote the entry offline pass the time
+// it was actually written.
+func CreateEntry(db *sql.DB, userID, body string, enc Encryption, mood *int, createdAt time.Time) (*Entry, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var id int
+	var createdAtArg any
+	if !createdAt.IsZero() {
+		createdAtArg = createdAt
+	}
+	entry := Entry{UserID: userID, Body: body, Encryption: enc, Mood: mood, Tags: BodyTags(body, enc)}
+	query := `INSERT INTO entries (user_id, body, nonce, key_check, mood, created_at) VALUES ($1, $2, $3, $4, $5, COALESCE($6, NOW())) RETURNING id, version, created_at, updated_at`
+	err = tx.QueryRow(query, userID, body, nullable(enc.Nonce), nullable(enc.KeyCheck), mood, createdAtArg).
+		Scan(&id, &entry.Version, &entry.CreatedAt, &entry.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert entry: %w", err)
+	}
+	entry.ID = strconv.Itoa(id)
+
+	if err := setEntryTags(tx, entry.ID, userID, entry.Tags); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// EntryRevision is a previous body of an entry, kept every time it is edited.
+// CreatedAt is when that version was written, not when it was replaced.
+type EntryRevision struct {
+	ID      string `json:"id"`
+	EntryID string `json:"entry_id"`
+	Body    string `json:"body"`
+	Encryption
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func GetEntryByID(db *sql.DB, id string) (*Entry, error) {
+	var entry Entry
+	query := `SELECT ` + entryColumns + ` FROM entries e WHERE e.id = $1`
+	err := scanEntry(db.QueryRow(query, id), &entry)
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func ListEntriesByUser(db *sql.DB, userID string) ([]Entry, error) {
+	query := `SELECT ` + entryColumns + ` FROM entries e WHERE e.user_id = $1 ORDER BY e.created_at DESC, e.id DESC`
+	rows, err := db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []Entry{}
+	for rows.Next() {
+		var entry Entry
+		if err := scanEntry(rows, &entry); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+var (
+	ErrInvalidCursor   = errors.New("invalid cursor")
+	ErrVersionConflict = errors.New("entry was changed by someone else")
+)
+
+// EncodeEntryCursor returns an opaque cursor pointing just after the given entry
+// in the (created_at DESC, id DESC) ordering used by ListEntriesPage.
+func EncodeEntryCursor(entry Entry) string {
+	raw := fmt.Sprintf("%s|%s", entry.CreatedAt.UTC().Format(time.RFC3339Nano), entry.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeEntryCursor returns the created_at and id a cursor points after.
+func DecodeEntryCursor(cursor string) (time.Time, int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	id, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	return createdAt, id, nil
+}
+
+// ListEntriesPage returns up to limit entries of a user matching filter, newest
+// first, starting after cursor (empty for the first page). The returned cursor
+// is empty when there are no more entries.
+func ListEntriesPage(db *sql.DB, userID string, filter EntryFilter, limit int, cursor string) ([]Entry, string, error) {
+	conditions := []string{"e.user_id = $1"}
+	args := []any{userID}
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if cursor != "" {
+		createdAt, id, err := DecodeEntryCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		conditions = append(conditions, fmt.Sprintf("(e.created_at, e.id) < (%s, %s)", arg(createdAt), arg(id)))
+	}
+	if filter.Tag != "" {
+		conditions = append(conditions, fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM entry_tags et JOIN tags t ON t.id = et.tag_id WHERE et.entry_id = e.id AND t.name = %s)",
+			arg(strings.ToLower(filter.Tag))))
+	}
+	if filter.MoodMin != nil {
+		conditions = append(conditions, "e.mood >= "+arg(*filter.MoodMin))
+	}
+	if filter.MoodMax != nil {
+		conditions = append(conditions, "e.mood <= "+arg(*filter.MoodMax))
+	}
+
+	query := `SELECT ` + entryColumns + ` FROM entries e WHERE ` + strings.Join(conditions, " AND ") +
+		` ORDER BY e.created_at DESC, e.id DESC LIMIT ` + arg(limit+1)
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	entries := []Entry{}
+	for rows.Next() {
+		var entry Entry
+		if err := scanEntry(rows, &entry); err != nil {
+			return nil, "", err
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	nextCursor := ""
+	if len(entries) > limit {
+		entries = entries[:limit]
+		nextCursor = EncodeEntryCursor(entries[len(entries)-1])
+	}
+	return entries, nextCursor, nil
+}
+
+// UpdateEntry replaces the body of an entry owned by userID, saving the
+// previous body as a revision and re-tagging it from the new body. A nil mood
+// leaves the mood unchanged. When baseVersion is set and the entry has moved
+// past it, nothing is changed and ErrVersionConflict is returned. It returns
+// sql.ErrNoRows if the user has no such entry.
+func UpdateEntry(db *sql.DB, id, userID, body string, enc Encryption, mood *int, baseVersion *int) (*Entry, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var oldBody string
+	var oldNonce, oldKeyCheck sql.NullString
+	var oldVersion int
+	var oldUpdatedAt time.Time
+	query := `SELECT body, nonce, key_check, version, updated_at FROM entries WHERE id = $1 AND user_id = $2 FOR UPDATE`
+	err = tx.QueryRow(query, id, userID).Scan(&oldBody, &oldNonce, &oldKeyCheck, &oldVersion, &oldUpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if baseVersion != nil && *baseVersion != oldVersion {
+		return nil, ErrVersionConflict
+	}
+
+	query = `INSERT INTO entry_revisions (entry_id, body, nonce, key_check, created_at) VALUES ($1, $2, $3, $4, $5)`
+	if _, err := tx.Exec(query, id, oldBody, oldNonce, oldKeyCheck, oldUpdatedAt); err != nil {
+		return nil, fmt.Errorf("failed to insert entry revision: %w", err)
+	}
+
+	query = `UPDATE entries SET body = $1, nonce = $2, key_check = $3, mood = COALESCE($4, mood), version = version + 1, updated_at = NOW() WHERE id = $5`
+	if _, err := tx.Exec(query, body, nullable(enc.Nonce), nullable(enc.KeyCheck), mood, id); err != nil {
+		return nil, fmt.Errorf("failed to update entry: %w", err)
+	}
+
+	if err := setEntryTags(tx, id, userID, BodyTags(body, enc)); err != nil {
+		return nil, err
+	}
+
+	var entry Entry
+	query = `SELECT ` + entryColumns + ` FROM entries e WHERE e.id = $1`
+	if err := scanEntry(tx.QueryRow(query, id), &entry); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// DeleteEntry deletes an entry owned by userID together with its revisions,
+// leaving a tombstone so syncing clients learn about it. It returns
+// sql.ErrNoRows if the user has no such entry.
+func DeleteEntry(db *sql.DB, id, userID string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `DELETE FROM entries WHERE id = $1 AND user_id = $2`
+	res, err := tx.Exec(query, id, userID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	query = `INSERT INTO entry_deletions (entry_id, user_id) VALUES ($1, $2)`
+	if _, err := tx.Exec(query, id, userID); err != nil {
+		return fmt.Errorf("failed to record entry deletion: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// ChangesOverlap is how far back the next since returned by ListEntryChanges
+// reaches, so changes committed by transactions that started before it was
+// taken are still picked up.
+const ChangesOverlap = time.Minute
+
+// ListEntryChanges returns the entries of a user created or updated at or
+// after since, and the ids of those deleted at or after it, oldest first.
+// Passing the returned time as the next since doesn't miss changes, at the
+// cost of repeating the most recent ones.
+func ListEntryChanges(db *sql.DB, userID string, since time.Time) ([]Entry, []string, time.Time, error) {
+	var now time.Time
+	if err := db.QueryRow(`SELECT NOW()`).Scan(&now); err != nil {
+		return nil, nil, time.Time{}, err
+	}
+
+	query := `SELECT ` + entryColumns + ` FROM entries e WHERE e.user_id = $1 AND e.updated_at >= $2 ORDER BY e.updated_at, e.id`
+	rows, err := db.Query(query, userID, since)
+	if err != nil {
+		return nil, nil, time.Time{}, err
+	}
+	defer rows.Close()
+
+	entries := []Entry{}
+	for rows.Next() {
+		var entry Entry
+		if err := scanEntry(rows, &entry); err != nil {
+			return nil, nil, time.Time{}, err
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, time.Time{}, err
+	}
+
+	query = `SELECT entry_id FROM entry_deletions WHERE user_id = $1 AND deleted_at >= $2 ORDER BY deleted_at`
+	deletedRows, err := db.Query(query, userID, since)
+	if err != nil {
+		return nil, nil, time.Time{}, err
+	}
+	defer deletedRows.Close()
+
+	deleted := []string{}
+	for deletedRows.Next() {
+		var id string
+		if err := deletedRows.Scan(&id); err != nil {
+			return nil, nil, time.Time{}, err
+		}
+		deleted = append(deleted, id)
+	}
+	return entries, deleted, now.Add(-ChangesOverlap), deletedRows.Err()
+}
+
+// ListEntryRevisions returns the previous bodies of an entry, newest first.
+func ListEntryRevisions(db *sql.DB, entryID string) ([]EntryRevision, error) {
+	query := `SELECT id, entry_id, body, COALESCE(nonce, ''), COALESCE(key_check, ''), created_at FROM entry_revisions WHERE entry_id = $1 ORDER BY created_at DESC, id DESC`
+	rows, err := db.Query(query, entryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []EntryRevision{}
+	for rows.Next() {
+		var revision EntryRevision
+		err := rows.Scan(&revision.ID, &revision.EntryID, &revision.Body, &revision.Nonce, &revision.KeyCheck, &revision.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, rows.Err()
+}
@@ -0,0 +1,44 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// UserKey is the end-to-end encryption key envelope of a user: their data key
+// wrapped with a key derived from their passphrase. The server can't unwrap it;
+// it only hands it back to the user's clients.
+type UserKey struct {
+	KDF        string    `json:"kdf"`
+	KDFParams  string    `json:"kdf_params"`
+	Salt       string    `json:"salt"`
+	WrappedKey string    `json:"wrapped_key"`
+	WrapNonce  string    `json:"wrap_nonce"`
+	KeyCheck   string    `json:"key_check"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func GetUserKey(db *sql.DB, userID string) (*UserKey, error) {
+	var key UserKey
+	query := `SELECT kdf, kdf_params, salt, wrapped_key, wrap_nonce, key_check, updated_at FROM user_keys WHERE user_id = $1`
+	err := db.QueryRow(query, userID).Scan(&key.KDF, &key.KDFParams, &key.Salt, &key.WrappedKey, &key.WrapNonce, &key.KeyCheck, &key.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// PutUserKey stores the key envelope of a user, replacing the previous one.
+func PutUserKey(db *sql.DB, userID string, key UserKey) (*UserKey, error) {
+	query := `INSERT INTO user_keys (user_id, kdf, kdf_params, salt, wrapped_key, wrap_nonce, key_check)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id) DO UPDATE SET kdf = EXCLUDED.kdf, kdf_params = EXCLUDED.kdf_params, salt = EXCLUDED.salt,
+			wrapped_key = EXCLUDED.wrapped_key, wrap_nonce = EXCLUDED.wrap_nonce, key_check = EXCLUDED.key_check, updated_at = NOW()
+		RETURNING updated_at`
+	err := db.QueryRow(query, userID, key.KDF, key.KDFParams, key.Salt, key.WrappedKey, key.WrapNonce, key.KeyCheck).Scan(&key.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store user key: %w", err)
+	}
+	return &key, nil
+}
@@ -0,0 +1,601 @@
+// Package memory is a db.Store that keeps everything in maps. It loses its
+// data when the server stops, which makes it handy for trying the server out
+// and for testing handlers without a database.
+package memory
+
+import (
+	"fmt"
+	"journalCli/db"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type session struct {
+	userID    string
+	expiresAt time.Time
+}
+
+type deletion struct {
+	entryID   string
+	userID    string
+	deletedAt time.Time
+}
+
+type Store struct {
+	mu     sync.Mutex
+	lastID int
+
+	users      map[string]*db.User
+	identities map[string]string
+	sessions   map[string]session
+	entries    map[string]*db.Entry
+	revisions  map[string][]db.EntryRevision
+	deletions  []deletion
+	keys       map[string]db.UserKey
+	settings   map[string]db.UserSettings
+}
+
+func New() *Store {
+	return &Store{
+		users:      map[string]*db.User{},
+		identities: map[string]string{},
+		sessions:   map[string]session{},
+		entries:    map[string]*db.Entry{},
+		revisions:  map[string][]db.EntryRevision{},
+		keys:       map[string]db.UserKey{},
+		settings:   map[string]db.UserSettings{},
+	}
+}
+
+// nextID hands out ids that look like the serial ones of the SQL stores.
+func (s *Store) nextID() string {
+	s.lastID++
+	return strconv.Itoa(s.lastID)
+}
+
+func (s *Store) userByEmail(email string) *db.User {
+	for _, u := range s.users {
+		if u.Email == email {
+			return u
+		}
+	}
+	return nil
+}
+
+func (s *Store) usernameTaken(username string) bool {
+	for _, u := range s.users {
+		if u.Username == username {
+			return true
+		}
+	}
+	return false
+}
+
+// public returns a copy of a user without their password hash.
+func public(u *db.User) *db.User {
+	return &db.User{ID: u.ID, Email: u.Email, Username: u.Username}
+}
+
+func (s *Store) CreateUser(username, email, passwordHash string) (*db.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.userByEmail(email) != nil {
+		return nil, db.ErrEmailTaken
+	}
+	if s.usernameTaken(username) {
+		return nil, db.ErrUsernameTaken
+	}
+	u := &db.User{ID: s.nextID(), Email: email, Username: username, Password_hash: passwordHash}
+	s.users[u.ID] = u
+	return public(u), nil
+}
+
+func (s *Store) GetUserByEmail(email string) (*db.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := s.userByEmail(email)
+	if u == nil {
+		return nil, db.ErrNotFound
+	}
+	found := *u
+	return &found, nil
+}
+
+func (s *Store) GetUserByID(id string) (*db.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[id]
+	if !ok {
+		return nil, db.ErrNotFound
+	}
+	return public(u), nil
+}
+
+func (s *Store) FindOrCreateOAuthUser(provider, subject, email string) (*db.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	identity := provider + "\x00" + subject
+	if userID, ok := s.identities[identity]; ok {
+		return public(s.users[userID]), nil
+	}
+
+	u := s.userByEmail(email)
+	if u == nil {
+		base := db.UsernameFromEmail(email)
+		username := base
+		for i := 2; s.usernameTaken(username); i++ {
+			username = base + strconv.Itoa(i)
+		}
+		u = &db.User{ID: s.nextID(), Email: email, Username: username}
+		s.users[u.ID] = u
+	}
+
+	s.identities[identity] = u.ID
+	return public(u), nil
+}
+
+func (s *Store) CreateSession(userID string) (*db.Session, error) {
+	token, err := db.NewSessionToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate session token: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[userID]; !ok {
+		return nil, fmt.Errorf("failed to insert session: no user %s", userID)
+	}
+	expiresAt := time.Now().Add(db.SessionTTL)
+	s.sessions[db.HashSessionToken(token)] = session{userID: userID, expiresAt: expiresAt}
+	return &db.Session{Token: token, UserID: userID, ExpiresAt: expiresAt}, nil
+}
+
+func (s *Store) GetUserBySessionToken(token string) (*db.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.sessions[db.HashSessionToken(token)]
+	if !ok || !sess.expiresAt.After(time.Now()) {
+		return nil, db.ErrNotFound
+	}
+	return public(s.users[sess.userID]), nil
+}
+
+func (s *Store) DeleteSession(token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, db.HashSessionToken(token))
+	return nil
+}
+
+func (s *Store) DeleteUserSessions(userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for hash, sess := range s.sessions {
+		if sess.userID == userID {
+			delete(s.sessions, hash)
+		}
+	}
+	return nil
+}
+
+func (s *Store) DeleteExpiredSessions() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for hash, sess := range s.sessions {
+		if !sess.expiresAt.After(now) {
+			delete(s.sessions, hash)
+		}
+	}
+	return nil
+}
+
+// sortedTags returns the tags of a body sorted by name, as the SQL stores
+// return them.
+func sortedTags(body string, enc db.Encryption) []string {
+	tags := db.BodyTags(body, enc)
+	sort.Strings(tags)
+	return tags
+}
+
+// copyEntry returns a copy of an entry that shares nothing with the store.
+func copyEntry(entry *db.Entry) db.Entry {
+	c := *entry
+	c.Tags = append([]string{}, entry.Tags...)
+	if entry.Mood != nil {
+		mood := *entry.Mood
+		c.Mood = &mood
+	}
+	return c
+}
+
+func (s *Store) CreateEntry(userID, body string, enc db.Encryption, mood *int, createdAt time.Time) (*db.Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[userID]; !ok {
+		return nil, fmt.Errorf("failed to insert entry: no user %s", userID)
+	}
+
+	now := time.Now()
+	if createdAt.IsZero() {
+		createdAt = now
+	}
+	entry := &db.Entry{
+		ID:         s.nextID(),
+		UserID:     userID,
+		Body:       body,
+		Encryption: enc,
+		Tags:       sortedTags(body, enc),
+		Version:    1,
+		CreatedAt:  createdAt,
+		UpdatedAt:  now,
+	}
+	if mood != nil {
+		value := *mood
+		entry.Mood = &value
+	}
+	s.entries[entry.ID] = entry
+
+	c := copyEntry(entry)
+	return &c, nil
+}
+
+func (s *Store) GetEntryByID(id string) (*db.Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[id]
+	if !ok {
+		return nil, db.ErrNotFound
+	}
+	c := copyEntry(entry)
+	return &c, nil
+}
+
+// newerFirst orders entries by (created_at DESC, id DESC) like the cursors of
+// ListEntriesPage expect.
+func newerFirst(a, b *db.Entry) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	return idNumber(a.ID) > idNumber(b.ID)
+}
+
+func idNumber(id string) int {
+	n, _ := strconv.Atoi(id)
+	return n
+}
+
+func (s *Store) userEntries(userID string) []*db.Entry {
+	entries := []*db.Entry{}
+	for _, entry := range s.entries {
+		if entry.UserID == userID {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func (s *Store) ListEntriesPage(userID string, filter db.EntryFilter, limit int, cursor string) ([]db.Entry, string, error) {
+	var after *db.Entry
+	if cursor != "" {
+		createdAt, id, err := db.DecodeEntryCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		after = &db.Entry{ID: strconv.Itoa(id), CreatedAt: createdAt}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	matching := []*db.Entry{}
+	for _, entry := range s.userEntries(userID) {
+		switch {
+		case after != nil && !newerFirst(after, entry):
+		case filter.Tag != "" && !slices.Contains(entry.Tags, strings.ToLower(filter.Tag)):
+		case filter.MoodMin != nil && (entry.Mood == nil || *entry.Mood < *filter.MoodMin):
+		case filter.MoodMax != nil && (entry.Mood == nil || *entry.Mood > *filter.MoodMax):
+		default:
+			matching = append(matching, entry)
+		}
+	}
+	sort.Slice(matching, func(i, j int) bool {
+		return newerFirst(matching[i], matching[j])
+	})
+
+	nextCursor := ""
+	if len(matching) > limit {
+		matching = matching[:limit]
+		nextCursor = db.EncodeEntryCursor(*matching[len(matching)-1])
+	}
+
+	entries := make([]db.Entry, len(matching))
+	for i, entry := range matching {
+		entries[i] = copyEntry(entry)
+	}
+	return entries, nextCursor, nil
+}
+
+func (s *Store) UpdateEntry(id, userID, body string, enc db.Encryption, mood *int, baseVersion *int) (*db.Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[id]
+	if !ok || entry.UserID != userID {
+		return nil, db.ErrNotFound
+	}
+	if baseVersion != nil && *baseVersion != entry.Version {
+		return nil, db.ErrVersionConflict
+	}
+
+	s.revisions[id] = append(s.revisions[id], db.EntryRevision{
+		ID:         s.nextID(),
+		EntryID:    id,
+		Body:       entry.Body,
+		Encryption: entry.Encryption,
+		CreatedAt:  entry.UpdatedAt,
+	})
+
+	entry.Body = body
+	entry.Encryption = enc
+	if mood != nil {
+		value := *mood
+		entry.Mood = &value
+	}
+	entry.Tags = sortedTags(body, enc)
+	entry.Version++
+	entry.UpdatedAt = time.Now()
+
+	c := copyEntry(entry)
+	return &c, nil
+}
+
+func (s *Store) DeleteEntry(id, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[id]
+	if !ok || entry.UserID != userID {
+		return db.ErrNotFound
+	}
+	delete(s.entries, id)
+	delete(s.revisions, id)
+	s.deletions = append(s.deletions, deletion{entryID: id, userID: userID, deletedAt: time.Now()})
+	return nil
+}
+
+func (s *Store) ListEntryRevisions(entryID string) ([]db.EntryRevision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := s.revisions[entryID]
+	revisions := make([]db.EntryRevision, len(stored))
+	for i, revision := range stored {
+		revisions[len(stored)-1-i] = revision
+	}
+	return revisions, nil
+}
+
+func (s *Store) ListEntryChanges(userID string, since time.Time) ([]db.Entry, []string, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	changed := []*db.Entry{}
+	for _, entry := range s.userEntries(userID) {
+		if !entry.UpdatedAt.Before(since) {
+			changed = append(changed, entry)
+		}
+	}
+	sort.Slice(changed, func(i, j int) bool {
+		if !changed[i].UpdatedAt.Equal(changed[j].UpdatedAt) {
+			return changed[i].UpdatedAt.Before(changed[j].UpdatedAt)
+		}
+		return idNumber(changed[i].ID) < idNumber(changed[j].ID)
+	})
+	entries := make([]db.Entry, len(changed))
+	for i, entry := range changed {
+		entries[i] = copyEntry(entry)
+	}
+
+	deleted := []string{}
+	for _, d := range s.deletions {
+		if d.userID == userID && !d.deletedAt.Before(since) {
+			deleted = append(deleted, d.entryID)
+		}
+	}
+	return entries, deleted, now.Add(-db.ChangesOverlap), nil
+}
+
+func (s *Store) SearchEntries(userID, q string, limit int) ([]db.SearchResult, error) {
+	search := db.ParseWebSearch(q)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := []db.SearchResult{}
+	if len(search.Groups) == 0 {
+		return results, nil
+	}
+	for _, entry := range s.userEntries(userID) {
+		if entry.Nonce != "" {
+			continue
+		}
+		if rank, ok := match(search, db.SearchWords(entry.Body)); ok {
+			results = append(results, db.SearchResult{
+				Entry:   copyEntry(entry),
+				Rank:    rank,
+				Snippet: snippet(entry.Body, search),
+			})
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		return newerFirst(&results[i].Entry, &results[j].Entry)
+	})
+	return results[:min(len(results), limit)], nil
+}
+
+func (s *Store) ListTags(userID string) ([]db.TagCount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts := map[string]int{}
+	for _, entry := range s.userEntries(userID) {
+		for _, tag := range entry.Tags {
+			counts[tag]++
+		}
+	}
+
+	tags := []db.TagCount{}
+	for name, count := range counts {
+		tags = append(tags, db.TagCount{Name: name, Count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Name < tags[j].Name
+	})
+	return tags, nil
+}
+
+func (s *Store) ListEntryDays(userID string, loc *time.Location) ([]time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var times []time.Time
+	for _, entry := range s.userEntries(userID) {
+		times = append(times, entry.CreatedAt)
+	}
+	slices.SortFunc(times, func(a, b time.Time) int { return b.Compare(a) })
+	return db.EntryDays(times, loc), nil
+}
+
+func (s *Store) GetStats(userID string, loc *time.Location, since time.Time) (*db.Stats, error) {
+	tags, err := s.ListTags(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := &db.Stats{Days: []db.DayStats{}, Tags: tags[:min(len(tags), db.TopTags)]}
+	days := map[string]*db.DayStats{}
+	dayMoods := map[string][]int{}
+	var moods []int
+	for _, entry := range s.userEntries(userID) {
+		if entry.CreatedAt.Before(since) {
+			continue
+		}
+		createdAt := entry.CreatedAt.In(loc)
+		date := createdAt.Format(time.DateOnly)
+		day, ok := days[date]
+		if !ok {
+			day = &db.DayStats{Date: date}
+			days[date] = day
+		}
+		day.Entries++
+		if entry.Nonce == "" {
+			day.Words += db.WordCount(entry.Body)
+		}
+		if entry.Mood != nil {
+			dayMoods[date] = append(dayMoods[date], *entry.Mood)
+			moods = append(moods, *entry.Mood)
+		}
+		stats.Hours[createdAt.Hour()]++
+	}
+
+	for date, day := range days {
+		day.Mood = average(dayMoods[date])
+		stats.Days = append(stats.Days, *day)
+		stats.Entries += day.Entries
+		stats.Words += day.Words
+	}
+	sort.Slice(stats.Days, func(i, j int) bool { return stats.Days[i].Date < stats.Days[j].Date })
+	stats.MoodAverage = average(moods)
+	return stats, nil
+}
+
+// average returns the mean of moods, or nil like SQL's AVG when there are
+// none.
+func average(moods []int) *float64 {
+	if len(moods) == 0 {
+		return nil
+	}
+	sum := 0
+	for _, mood := range moods {
+		sum += mood
+	}
+	avg := float64(sum) / float64(len(moods))
+	return &avg
+}
+
+func (s *Store) GetUserKey(userID string) (*db.UserKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.keys[userID]
+	if !ok {
+		return nil, db.ErrNotFound
+	}
+	return &key, nil
+}
+
+func (s *Store) PutUserKey(userID string, key db.UserKey) (*db.UserKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[userID]; !ok {
+		return nil, fmt.Errorf("failed to store user key: no user %s", userID)
+	}
+	key.UpdatedAt = time.Now()
+	s.keys[userID] = key
+	return &key, nil
+}
+
+func (s *Store) GetUserSettings(userID string) (*db.UserSettings, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	settings, ok := s.settings[userID]
+	if !ok {
+		return nil, db.ErrNotFound
+	}
+	return &settings, nil
+}
+
+func (s *Store) PutUserSettings(userID string, settings db.UserSettings) (*db.UserSettings, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[userID]; !ok {
+		return nil, fmt.Errorf("failed to store user settings: no user %s", userID)
+	}
+	settings.UpdatedAt = time.Now()
+	s.settings[userID] = settings
+	return &settings, nil
+}
+
+func (s *Store) Close() error {
+	return nil
+}
@@ -0,0 +1,15 @@
+package memory_test
+
+import (
+	"testing"
+
+	"journalCli/db"
+	"journalCli/db/memory"
+	"journalCli/db/storetest"
+)
+
+func TestStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) db.Store {
+		return memory.New()
+	})
+}
@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"journalCli/db"
+	"regexp"
+	"strings"
+)
+
+// snippetWords is about how many words ts_headline shows around a match.
+const snippetWords = 20
+
+// phraseAt reports whether phrase starts at words[i].
+func phraseAt(words []string, i int, phrase []string) bool {
+	if i+len(phrase) > len(words) {
+		return false
+	}
+	for j, word := range phrase {
+		if words[i+j] != word {
+			return false
+		}
+	}
+	return true
+}
+
+func countPhrase(words []string, phrase []string) int {
+	count := 0
+	for i := range words {
+		if phraseAt(words, i, phrase) {
+			count++
+		}
+	}
+	return count
+}
+
+// match reports whether the words of a body satisfy a search, ranking it by
+// how many times the phrases it asked for occur.
+func match(search db.WebSearch, words []string) (float64, bool) {
+	for _, phrase := range search.Excluded {
+		if countPhrase(words, phrase) > 0 {
+			return 0, false
+		}
+	}
+
+	rank := 0
+	for _, group := range search.Groups {
+		found := false
+		for _, phrase := range group {
+			if n := countPhrase(words, phrase); n > 0 {
+				found = true
+				rank += n
+			}
+		}
+		if !found {
+			return 0, false
+		}
+	}
+	return float64(rank), true
+}
+
+var snippetWordPattern = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// snippet returns the words of body around its first match, with the words of
+// the searched phrases wrapped in db.HighlightStart and db.HighlightStop.
+func snippet(body string, search db.WebSearch) string {
+	searched := map[string]bool{}
+	for _, group := range search.Groups {
+		for _, phrase := range group {
+			for _, word := range phrase {
+				searched[word] = true
+			}
+		}
+	}
+
+	spans := snippetWordPattern.FindAllStringIndex(body, -1)
+	first := 0
+	for i, span := range spans {
+		if searched[strings.ToLower(body[span[0]:span[1]])] {
+			first = i
+			break
+		}
+	}
+	start := max(0, first-snippetWords/4)
+	end := min(len(spans), start+snippetWords)
+
+	var b strings.Builder
+	for i := start; i < end; i++ {
+		word := body[spans[i][0]:spans[i][1]]
+		if i > start {
+			b.WriteString(body[spans[i-1][1]:spans[i][0]])
+		}
+		if searched[strings.ToLower(word)] {
+			b.WriteString(db.HighlightStart + word + db.HighlightStop)
+		} else {
+			b.WriteString(word)
+		}
+	}
+	return b.String()
+}
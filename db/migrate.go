@@ -0,0 +1,260 @@
+package db
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Migrations are pairs of files named NNNN_name.up.sql and NNNN_name.down.sql.
+// New tables and columns must be added as a new migration, never by editing
+// one that has been released.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// ErrSchemaOutdated is returned by CheckSchema when the database is not at
+// the version this binary expects.
+var ErrSchemaOutdated = errors.New("database schema is not up to date")
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus is a migration and when it was applied, nil if it wasn't.
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrations returns the embedded migrations, oldest first.
+func Migrations() ([]Migration, error) {
+	files, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, file := range files {
+		base := path.Base(file)
+		stem, direction, ok := strings.Cut(strings.TrimSuffix(base, ".sql"), ".")
+		versionPart, name, hasName := strings.Cut(stem, "_")
+		version, err := strconv.Atoi(versionPart)
+		if !ok || !hasName || err != nil || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("migration %s is not named NNNN_name.up.sql or NNNN_name.down.sql", base)
+		}
+
+		sqlText, err := migrationFiles.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		migration, found := byVersion[version]
+		if !found {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		} else if migration.Name != name {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, migration.Name, name)
+		}
+		if direction == "up" {
+			migration.Up = string(sqlText)
+		} else {
+			migration.Down = string(sqlText)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+const createMigrationsTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+)`
+
+// appliedMigrations returns when each applied migration was applied, by
+// version. A database that was never migrated has none.
+func appliedMigrations(db *sql.DB) (map[int]time.Time, error) {
+	applied := map[int]time.Time{}
+
+	var exists bool
+	if err := db.QueryRow(`SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil || !exists {
+		return applied, err
+	}
+
+	rows, err := db.Query(`SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// MigrationStatuses lists every embedded migration and whether it is applied.
+func MigrationStatuses(db *sql.DB) ([]MigrationStatus, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, migration := range migrations {
+		status := MigrationStatus{Migration: migration}
+		if appliedAt, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// CheckSchema makes sure every embedded migration has been applied and that
+// the database isn't ahead of this binary.
+func CheckSchema(db *sql.DB) error {
+	migrations, err := Migrations()
+	if err != nil {
+		return err
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	known := map[int]bool{}
+	pending := 0
+	for _, migration := range migrations {
+		known[migration.Version] = true
+		if _, ok := applied[migration.Version]; !ok {
+			pending++
+		}
+	}
+	for version := range applied {
+		if !known[version] {
+			return fmt.Errorf("%w: migration %d is applied but unknown to this binary, is it outdated?", ErrSchemaOutdated, version)
+		}
+	}
+	if pending > 0 {
+		return fmt.Errorf("%w: %d migration(s) pending, run `server migrate up`", ErrSchemaOutdated, pending)
+	}
+	return nil
+}
+
+// runMigration applies one direction of a migration and records it, all in one
+// transaction. The table lock keeps two servers from migrating at once.
+func runMigration(db *sql.DB, migration Migration, up bool) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(createMigrationsTable); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`LOCK TABLE schema_migrations IN EXCLUSIVE MODE`); err != nil {
+		return err
+	}
+
+	var applied bool
+	err = tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)`, migration.Version).Scan(&applied)
+	if err != nil {
+		return err
+	}
+	if applied == up {
+		// Someone else got there first.
+		return tx.Commit()
+	}
+
+	sqlText, record := migration.Down, `DELETE FROM schema_migrations WHERE version = $1`
+	args := []any{migration.Version}
+	if up {
+		sqlText, record = migration.Up, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`
+		args = append(args, migration.Name)
+	}
+
+	if _, err := tx.Exec(sqlText); err != nil {
+		return fmt.Errorf("migration %04d_%s failed: %w", migration.Version, migration.Name, err)
+	}
+	if _, err := tx.Exec(record, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// MigrateUp applies up to steps pending migrations, oldest first, or all of
+// them when steps is 0. It returns the migrations it applied.
+func MigrateUp(db *sql.DB, steps int) ([]Migration, error) {
+	statuses, err := MigrationStatuses(db)
+	if err != nil {
+		return nil, err
+	}
+
+	done := []Migration{}
+	for _, status := range statuses {
+		if status.AppliedAt != nil {
+			continue
+		}
+		if steps > 0 && len(done) == steps {
+			break
+		}
+		if err := runMigration(db, status.Migration, true); err != nil {
+			return done, err
+		}
+		done = append(done, status.Migration)
+	}
+	return done, nil
+}
+
+// MigrateDown reverts the steps most recently applied migrations, newest
+// first. It returns the migrations it reverted.
+func MigrateDown(db *sql.DB, steps int) ([]Migration, error) {
+	statuses, err := MigrationStatuses(db)
+	if err != nil {
+		return nil, err
+	}
+
+	done := []Migration{}
+	for i := len(statuses) - 1; i >= 0 && len(done) < steps; i-- {
+		if statuses[i].AppliedAt == nil {
+			continue
+		}
+		if err := runMigration(db, statuses[i].Migration, false); err != nil {
+			return done, err
+		}
+		done = append(done, statuses[i].Migration)
+	}
+	return done, nil
+}
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS entry_revisions (
+    id SERIAL PRIMARY KEY,
+    entry_id INTEGER NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS entry_revisions_entry_id_idx ON entry_revisions (entry_id, created_at DESC);
@@ -0,0 +1,4 @@
+ALTER TABLE entries ADD COLUMN IF NOT EXISTS search tsvector
+    GENERATED ALWAYS AS (to_tsvector('english', body)) STORED;
+
+CREATE INDEX IF NOT EXISTS entries_search_idx ON entries USING GIN (search);
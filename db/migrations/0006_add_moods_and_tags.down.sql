@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS entry_tags;
+DROP TABLE IF EXISTS tags;
+ALTER TABLE entries DROP COLUMN IF EXISTS mood;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS entry_deletions;
+DROP INDEX IF EXISTS entries_user_id_updated_at_idx;
+ALTER TABLE entries DROP COLUMN IF EXISTS version;
@@ -0,0 +1,11 @@
+ALTER TABLE entries ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+CREATE INDEX IF NOT EXISTS entries_user_id_updated_at_idx ON entries (user_id, updated_at);
+
+CREATE TABLE IF NOT EXISTS entry_deletions (
+    entry_id INTEGER PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    deleted_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS entry_deletions_user_id_deleted_at_idx ON entry_deletions (user_id, deleted_at);
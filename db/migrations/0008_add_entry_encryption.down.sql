@@ -0,0 +1,21 @@
+-- Encrypted entries can't be turned back into plaintext here; this refuses to
+-- run while there are any.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM entries WHERE nonce IS NOT NULL)
+        OR EXISTS (SELECT 1 FROM entry_revisions WHERE nonce IS NOT NULL) THEN
+        RAISE EXCEPTION 'cannot remove encryption columns while encrypted entries exist';
+    END IF;
+END $$;
+
+DROP TABLE IF EXISTS user_keys;
+
+ALTER TABLE entries DROP COLUMN IF EXISTS search;
+ALTER TABLE entries ADD COLUMN search tsvector
+    GENERATED ALWAYS AS (to_tsvector('english', body)) STORED;
+CREATE INDEX IF NOT EXISTS entries_search_idx ON entries USING GIN (search);
+
+ALTER TABLE entry_revisions DROP COLUMN IF EXISTS key_check;
+ALTER TABLE entry_revisions DROP COLUMN IF EXISTS nonce;
+ALTER TABLE entries DROP COLUMN IF EXISTS key_check;
+ALTER TABLE entries DROP COLUMN IF EXISTS nonce;
@@ -0,0 +1,23 @@
+-- End-to-end encryption: encrypted entries keep base64 ciphertext in body, the
+-- AEAD nonce in nonce, and the key check of the data key that sealed them.
+ALTER TABLE entries ADD COLUMN IF NOT EXISTS nonce TEXT;
+ALTER TABLE entries ADD COLUMN IF NOT EXISTS key_check TEXT;
+ALTER TABLE entry_revisions ADD COLUMN IF NOT EXISTS nonce TEXT;
+ALTER TABLE entry_revisions ADD COLUMN IF NOT EXISTS key_check TEXT;
+
+-- Ciphertext must not end up in the search index.
+ALTER TABLE entries DROP COLUMN IF EXISTS search;
+ALTER TABLE entries ADD COLUMN search tsvector
+    GENERATED ALWAYS AS (to_tsvector('english', CASE WHEN nonce IS NULL THEN body ELSE '' END)) STORED;
+CREATE INDEX IF NOT EXISTS entries_search_idx ON entries USING GIN (search);
+
+CREATE TABLE IF NOT EXISTS user_keys (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    kdf TEXT NOT NULL,
+    kdf_params TEXT NOT NULL,
+    salt TEXT NOT NULL,
+    wrapped_key TEXT NOT NULL,
+    wrap_nonce TEXT NOT NULL,
+    key_check TEXT NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
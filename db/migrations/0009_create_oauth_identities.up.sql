@@ -0,0 +1,13 @@
+-- Users created through an OAuth login have an empty password_hash, so they
+-- can't log in with a password.
+CREATE TABLE IF NOT EXISTS oauth_identities (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    email TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (provider, subject)
+);
+
+CREATE INDEX IF NOT EXISTS oauth_identities_user_id_idx ON oauth_identities (user_id);
@@ -0,0 +1,11 @@
+-- A user without a row here has the defaults of db.DefaultUserSettings.
+CREATE TABLE IF NOT EXISTS user_settings (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    editor_mode TEXT NOT NULL,
+    time_zone TEXT NOT NULL,
+    date_format TEXT NOT NULL,
+    reminder_time TEXT NOT NULL,
+    theme TEXT NOT NULL,
+    line_numbers BOOLEAN NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var nonUsernameChars = regexp.MustCompile(`[^a-z0-9_.-]+`)
+
+// UsernameFromEmail derives a username from the local part of an email.
+func UsernameFromEmail(email string) string {
+	local, _, _ := strings.Cut(strings.ToLower(email), "@")
+	username := nonUsernameChars.ReplaceAllString(local, "")
+	if len(username) < 3 {
+		username = "user" + username
+	}
+	return username[:min(len(username), 40)]
+}
+
+// FindOrCreateOAuthUser returns the user an OAuth identity belongs to. An
+// identity seen for the first time is linked to the user with the same
+// (provider-verified) email, or to a new user when there is none.
+func FindOrCreateOAuthUser(db *sql.DB, provider, subject, email string) (*User, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var user User
+	query := `SELECT u.id, u.email, u.username FROM oauth_identities oi JOIN users u ON u.id = oi.user_id
+		WHERE oi.provider = $1 AND oi.subject = $2`
+	err = tx.QueryRow(query, provider, subject).Scan(&user.ID, &user.Email, &user.Username)
+	if err == nil {
+		return &user, tx.Commit()
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	query = `SELECT id, email, username FROM users WHERE email = $1`
+	err = tx.QueryRow(query, email).Scan(&user.ID, &user.Email, &user.Username)
+	if errors.Is(err, sql.ErrNoRows) {
+		if err := createOAuthUser(tx, email, &user); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	query = `INSERT INTO oauth_identities (user_id, provider, subject, email) VALUES ($1, $2, $3, $4)`
+	if _, err := tx.Exec(query, user.ID, provider, subject, email); err != nil {
+		return nil, fmt.Errorf("failed to link %s identity: %w", provider, err)
+	}
+
+	return &user, tx.Commit()
+}
+
+// createOAuthUser inserts a user without a password, numbering the username
+// when it is taken.
+func createOAuthUser(tx *sql.Tx, email string, user *User) error {
+	base := UsernameFromEmail(email)
+	username := base
+
+	for i := 2; ; i++ {
+		var taken bool
+		if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM users WHERE username = $1)`, username).Scan(&taken); err != nil {
+			return err
+		}
+		if !taken {
+			break
+		}
+		username = base + strconv.Itoa(i)
+	}
+
+	var id int
+	query := `INSERT INTO users (username, email, password_hash) VALUES ($1, $2, '') RETURNING id`
+	if err := tx.QueryRow(query, username, email).Scan(&id); err != nil {
+		return fmt.Errorf("failed to insert user: %w", err)
+	}
+
+	user.ID = strconv.Itoa(id)
+	user.Email = email
+	user.Username = username
+	return nil
+}
@@ -0,0 +1,111 @@
+package db
+
+import (
+	"database/sql"
+	"regexp"
+	"strings"
+)
+
+// Search snippets wrap matching words in these markers.
+const (
+	HighlightStart = "<mark>"
+	HighlightStop  = "</mark>"
+)
+
+type SearchResult struct {
+	Entry
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+// SearchEntries runs a full-text search over a user's entries, best match
+// first. The query uses web search syntax: quoted phrases, "or" and -negation.
+func SearchEntries(db *sql.DB, userID, q string, limit int) ([]SearchResult, error) {
+	query := `SELECT ` + entryColumns + `,
+			ts_rank(e.search, q) AS rank,
+			ts_headline('english', e.body, q, 'StartSel=` + HighlightStart + `, StopSel=` + HighlightStop + `, MaxFragments=2, MaxWords=20, MinWords=8')
+		FROM entries e, websearch_to_tsquery('english', $2) q
+		WHERE e.user_id = $1 AND e.search @@ q
+		ORDER BY rank DESC, e.created_at DESC
+		LIMIT $3`
+	rows, err := db.Query(query, userID, q, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []SearchResult{}
+	for rows.Next() {
+		var result SearchResult
+		if err := scanEntry(rows, &result.Entry, &result.Rank, &result.Snippet); err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+// WebSearch is a query in web search syntax broken down for stores without
+// websearch_to_tsquery. An entry matches when it contains a phrase of every
+// group and none of the excluded phrases. Phrases are lowercase words.
+type WebSearch struct {
+	Groups   [][][]string
+	Excluded [][]string
+}
+
+var searchWordPattern = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// SearchWords splits text into lowercase words the way WebSearch phrases are.
+func SearchWords(text string) []string {
+	return searchWordPattern.FindAllString(strings.ToLower(text), -1)
+}
+
+// ParseWebSearch parses quoted phrases, "or" between terms and -negation.
+// Negation always excludes, even next to "or".
+func ParseWebSearch(q string) WebSearch {
+	var search WebSearch
+	pendingOr := false
+
+	for q = strings.TrimSpace(q); q != ""; q = strings.TrimSpace(q) {
+		negated := strings.HasPrefix(q, "-")
+		if negated {
+			q = q[1:]
+		}
+
+		var term string
+		quoted := strings.HasPrefix(q, `"`)
+		if quoted {
+			var found bool
+			term, q, found = strings.Cut(q[1:], `"`)
+			if !found {
+				q = ""
+			}
+		} else {
+			end := strings.IndexAny(q, " \t\n\"")
+			if end < 0 {
+				end = len(q)
+			}
+			term, q = q[:end], q[end:]
+		}
+
+		if !quoted && !negated && strings.EqualFold(term, "or") {
+			pendingOr = len(search.Groups) > 0
+			continue
+		}
+
+		phrase := SearchWords(term)
+		switch {
+		case len(phrase) == 0:
+			continue
+		case negated:
+			search.Excluded = append(search.Excluded, phrase)
+		case pendingOr:
+			last := len(search.Groups) - 1
+			search.Groups[last] = append(search.Groups[last], phrase)
+		default:
+			search.Groups = append(search.Groups, [][]string{phrase})
+		}
+		pendingOr = false
+	}
+	return search
+}
@@ -0,0 +1,87 @@
+package db
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// SessionTTL is how long a session token stays valid after it is issued.
+const SessionTTL = 30 * 24 * time.Hour
+
+type Session struct {
+	Token     string    `json:"token"`
+	UserID    string    `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// HashSessionToken is what gets stored, so a leaked sessions table can't be replayed.
+func HashSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewSessionToken generates a random session token.
+func NewSessionToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func CreateSession(db *sql.DB, userID string) (*Session, error) {
+	token, err := NewSessionToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate session token: %w", err)
+	}
+
+	expiresAt := time.Now().Add(SessionTTL)
+	query := `INSERT INTO sessions (user_id, token_hash, expires_at) VALUES ($1, $2, $3)`
+	if _, err := db.Exec(query, userID, HashSessionToken(token), expiresAt); err != nil {
+		return nil, fmt.Errorf("failed to insert session: %w", err)
+	}
+
+	return &Session{
+		Token:     token,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// GetUserBySessionToken resolves a token to its user, ignoring expired sessions.
+func GetUserBySessionToken(db *sql.DB, token string) (*User, error) {
+	var user User
+	var id int
+	query := `SELECT u.id, u.email, u.username FROM sessions s JOIN users u ON u.id = s.user_id WHERE s.token_hash = $1 AND s.expires_at > NOW()`
+	err := db.QueryRow(query, HashSessionToken(token)).Scan(&id, &user.Email, &user.Username)
+	if err != nil {
+		return nil, err
+	}
+	user.ID = strconv.Itoa(id)
+	return &user, nil
+}
+
+func DeleteSession(db *sql.DB, token string) error {
+	query := `DELETE FROM sessions WHERE token_hash = $1`
+	_, err := db.Exec(query, HashSessionToken(token))
+	return err
+}
+
+// DeleteUserSessions revokes every session of a user, e.g. "log out everywhere".
+func DeleteUserSessions(db *sql.DB, userID string) error {
+	query := `DELETE FROM sessions WHERE user_id = $1`
+	_, err := db.Exec(query, userID)
+	return err
+}
+
+func DeleteExpiredSessions(db *sql.DB) error {
+	query := `DELETE FROM sessions WHERE expires_at <= NOW()`
+	_, err := db.Exec(query)
+	return err
+}
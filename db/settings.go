@@ -0,0 +1,68 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Editor modes of UserSettings.EditorMode.
+const (
+	EditorStandard = "standard"
+	EditorVim      = "vim"
+)
+
+// UserSettings are a user's preferences, kept on the server so every client
+// they log in from picks them up.
+type UserSettings struct {
+	// EditorMode is how the journal editor takes keys, EditorStandard or
+	// EditorVim.
+	EditorMode string `json:"editor_mode"`
+	// TimeZone is an IANA time zone name, the device's own when empty.
+	TimeZone string `json:"time_zone"`
+	// DateFormat is the Go layout dates are shown with.
+	DateFormat string `json:"date_format"`
+	// ReminderTime is when to be reminded to write, as 15:04, or empty to
+	// use the client's config.
+	ReminderTime string    `json:"reminder_time"`
+	Theme        string    `json:"theme"`
+	LineNumbers  bool      `json:"line_numbers"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// DefaultUserSettings are the settings of a user who never saved any.
+func DefaultUserSettings() UserSettings {
+	return UserSettings{
+		EditorMode:  EditorStandard,
+		DateFormat:  "Mon, 02 Jan 2006",
+		Theme:       "default",
+		LineNumbers: true,
+	}
+}
+
+func GetUserSettings(db *sql.DB, userID string) (*UserSettings, error) {
+	var settings UserSettings
+	query := `SELECT editor_mode, time_zone, date_format, reminder_time, theme, line_numbers, updated_at FROM user_settings WHERE user_id = $1`
+	err := db.QueryRow(query, userID).Scan(&settings.EditorMode, &settings.TimeZone, &settings.DateFormat,
+		&settings.ReminderTime, &settings.Theme, &settings.LineNumbers, &settings.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// PutUserSettings stores the settings of a user, replacing the previous ones.
+func PutUserSettings(db *sql.DB, userID string, settings UserSettings) (*UserSettings, error) {
+	query := `INSERT INTO user_settings (user_id, editor_mode, time_zone, date_format, reminder_time, theme, line_numbers)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id) DO UPDATE SET editor_mode = EXCLUDED.editor_mode, time_zone = EXCLUDED.time_zone,
+			date_format = EXCLUDED.date_format, reminder_time = EXCLUDED.reminder_time, theme = EXCLUDED.theme,
+			line_numbers = EXCLUDED.line_numbers, updated_at = NOW()
+		RETURNING updated_at`
+	err := db.QueryRow(query, userID, settings.EditorMode, settings.TimeZone, settings.DateFormat,
+		settings.ReminderTime, settings.Theme, settings.LineNumbers).Scan(&settings.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store user settings: %w", err)
+	}
+	return &settings, nil
+}
@@ -0,0 +1,317 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"journalCli/db"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// entryColumns selects an entry aliased as e, in the order scanEntry expects.
+// Tags come newline-separated since SQLite has no arrays; they can't contain
+// whitespace.
+const entryColumns = `e.id, e.user_id, e.body, COALESCE(e.nonce, ''), COALESCE(e.key_check, ''), e.mood, e.version, e.created_at, e.updated_at,
+	COALESCE((SELECT group_concat(name, char(10)) FROM
+		(SELECT t.name FROM entry_tags et JOIN tags t ON t.id = et.tag_id WHERE et.entry_id = e.id ORDER BY t.name)), '')`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanEntry scans entryColumns into entry, followed by any extra columns the
+// query selected after them.
+func scanEntry(row rowScanner, entry *db.Entry, extra ...any) error {
+	var mood sql.NullInt64
+	var createdAt, updatedAt int64
+	var tags string
+	dest := []any{&entry.ID, &entry.UserID, &entry.Body, &entry.Nonce, &entry.KeyCheck, &mood, &entry.Version,
+		&createdAt, &updatedAt, &tags}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+	entry.CreatedAt = fromNanos(createdAt)
+	entry.UpdatedAt = fromNanos(updatedAt)
+	entry.Mood = nil
+	if mood.Valid {
+		value := int(mood.Int64)
+		entry.Mood = &value
+	}
+	entry.Tags = []string{}
+	if tags != "" {
+		entry.Tags = strings.Split(tags, "\n")
+	}
+	return nil
+}
+
+func scanEntries(rows *sql.Rows) ([]db.Entry, error) {
+	defer rows.Close()
+
+	entries := []db.Entry{}
+	for rows.Next() {
+		var entry db.Entry
+		if err := scanEntry(rows, &entry); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// setEntryTags replaces the tags of an entry, creating missing tags for the user.
+func setEntryTags(tx *sql.Tx, entryID, userID string, tags []string) error {
+	if _, err := tx.Exec(`DELETE FROM entry_tags WHERE entry_id = ?`, entryID); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		var tagID int
+		query := `INSERT INTO tags (user_id, name) VALUES (?, ?) ON CONFLICT (user_id, name) DO UPDATE SET name = excluded.name RETURNING id`
+		if err := tx.QueryRow(query, userID, tag).Scan(&tagID); err != nil {
+			return fmt.Errorf("failed to insert tag: %w", err)
+		}
+		query = `INSERT INTO entry_tags (entry_id, tag_id) VALUES (?, ?)`
+		if _, err := tx.Exec(query, entryID, tagID); err != nil {
+			return fmt.Errorf("failed to tag entry: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *Store) CreateEntry(userID, body string, enc db.Encryption, mood *int, createdAt time.Time) (*db.Entry, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := fromNanos(nanos(time.Now()))
+	if createdAt.IsZero() {
+		createdAt = now
+	}
+	entry := db.Entry{UserID: userID, Body: body, Encryption: enc, Mood: mood, Tags: db.BodyTags(body, enc),
+		Version: 1, CreatedAt: fromNanos(nanos(createdAt)), UpdatedAt: now}
+	query := `INSERT INTO entries (user_id, body, nonce, key_check, mood, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	res, err := tx.Exec(query, userID, body, nullable(enc.Nonce), nullable(enc.KeyCheck), mood, nanos(createdAt), nanos(now))
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert entry: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	entry.ID = strconv.FormatInt(id, 10)
+
+	if err := setEntryTags(tx, entry.ID, userID, entry.Tags); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (s *Store) GetEntryByID(id string) (*db.Entry, error) {
+	var entry db.Entry
+	query := `SELECT ` + entryColumns + ` FROM entries e WHERE e.id = ?`
+	if err := scanEntry(s.db.QueryRow(query, id), &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (s *Store) ListEntriesPage(userID string, filter db.EntryFilter, limit int, cursor string) ([]db.Entry, string, error) {
+	conditions := []string{"e.user_id = ?"}
+	args := []any{userID}
+
+	if cursor != "" {
+		createdAt, id, err := db.DecodeEntryCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		conditions = append(conditions, "(e.created_at, e.id) < (?, ?)")
+		args = append(args, nanos(createdAt), id)
+	}
+	if filter.Tag != "" {
+		conditions = append(conditions,
+			"EXISTS (SELECT 1 FROM entry_tags et JOIN tags t ON t.id = et.tag_id WHERE et.entry_id = e.id AND t.name = ?)")
+		args = append(args, strings.ToLower(filter.Tag))
+	}
+	if filter.MoodMin != nil {
+		conditions = append(conditions, "e.mood >= ?")
+		args = append(args, *filter.MoodMin)
+	}
+	if filter.MoodMax != nil {
+		conditions = append(conditions, "e.mood <= ?")
+		args = append(args, *filter.MoodMax)
+	}
+
+	query := `SELECT ` + entryColumns + ` FROM entries e WHERE ` + strings.Join(conditions, " AND ") +
+		` ORDER BY e.created_at DESC, e.id DESC LIMIT ?`
+	rows, err := s.db.Query(query, append(args, limit+1)...)
+	if err != nil {
+		return nil, "", err
+	}
+	entries, err := scanEntries(rows)
+	if err != nil {
+		return nil, "", err
+	}
+
+	nextCursor := ""
+	if len(entries) > limit {
+		entries = entries[:limit]
+		nextCursor = db.EncodeEntryCursor(entries[len(entries)-1])
+	}
+	return entries, nextCursor, nil
+}
+
+func (s *Store) UpdateEntry(id, userID, body string, enc db.Encryption, mood *int, baseVersion *int) (*db.Entry, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var oldBody string
+	var oldNonce, oldKeyCheck sql.NullString
+	var oldVersion int
+	var oldUpdatedAt int64
+	query := `SELECT body, nonce, key_check, version, updated_at FROM entries WHERE id = ? AND user_id = ?`
+	err = tx.QueryRow(query, id, userID).Scan(&oldBody, &oldNonce, &oldKeyCheck, &oldVersion, &oldUpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if baseVersion != nil && *baseVersion != oldVersion {
+		return nil, db.ErrVersionConflict
+	}
+
+	query = `INSERT INTO entry_revisions (entry_id, body, nonce, key_check, created_at) VALUES (?, ?, ?, ?, ?)`
+	if _, err := tx.Exec(query, id, oldBody, oldNonce, oldKeyCheck, oldUpdatedAt); err != nil {
+		return nil, fmt.Errorf("failed to insert entry revision: %w", err)
+	}
+
+	query = `UPDATE entries SET body = ?, nonce = ?, key_check = ?, mood = COALESCE(?, mood), version = version + 1, updated_at = ? WHERE id = ?`
+	if _, err := tx.Exec(query, body, nullable(enc.Nonce), nullable(enc.KeyCheck), mood, nanos(time.Now()), id); err != nil {
+		return nil, fmt.Errorf("failed to update entry: %w", err)
+	}
+
+	if err := setEntryTags(tx, id, userID, db.BodyTags(body, enc)); err != nil {
+		return nil, err
+	}
+
+	var entry db.Entry
+	query = `SELECT ` + entryColumns + ` FROM entries e WHERE e.id = ?`
+	if err := scanEntry(tx.QueryRow(query, id), &entry); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (s *Store) DeleteEntry(id, userID string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`DELETE FROM entries WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return db.ErrNotFound
+	}
+
+	query := `INSERT INTO entry_deletions (entry_id, user_id, deleted_at) VALUES (?, ?, ?)`
+	if _, err := tx.Exec(query, id, userID, nanos(time.Now())); err != nil {
+		return fmt.Errorf("failed to record entry deletion: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+func (s *Store) ListEntryRevisions(entryID string) ([]db.EntryRevision, error) {
+	query := `SELECT id, entry_id, body, COALESCE(nonce, ''), COALESCE(key_check, ''), created_at FROM entry_revisions WHERE entry_id = ? ORDER BY created_at DESC, id DESC`
+	rows, err := s.db.Query(query, entryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []db.EntryRevision{}
+	for rows.Next() {
+		var revision db.EntryRevision
+		var createdAt int64
+		err := rows.Scan(&revision.ID, &revision.EntryID, &revision.Body, &revision.Nonce, &revision.KeyCheck, &createdAt)
+		if err != nil {
+			return nil, err
+		}
+		revision.CreatedAt = fromNanos(createdAt)
+		revisions = append(revisions, revision)
+	}
+	return revisions, rows.Err()
+}
+
+func (s *Store) ListEntryChanges(userID string, since time.Time) ([]db.Entry, []string, time.Time, error) {
+	now := time.Now()
+
+	query := `SELECT ` + entryColumns + ` FROM entries e WHERE e.user_id = ? AND e.updated_at >= ? ORDER BY e.updated_at, e.id`
+	rows, err := s.db.Query(query, userID, nanos(since))
+	if err != nil {
+		return nil, nil, time.Time{}, err
+	}
+	entries, err := scanEntries(rows)
+	if err != nil {
+		return nil, nil, time.Time{}, err
+	}
+
+	query = `SELECT entry_id FROM entry_deletions WHERE user_id = ? AND deleted_at >= ? ORDER BY deleted_at`
+	deletedRows, err := s.db.Query(query, userID, nanos(since))
+	if err != nil {
+		return nil, nil, time.Time{}, err
+	}
+	defer deletedRows.Close()
+
+	deleted := []string{}
+	for deletedRows.Next() {
+		var id string
+		if err := deletedRows.Scan(&id); err != nil {
+			return nil, nil, time.Time{}, err
+		}
+		deleted = append(deleted, id)
+	}
+	return entries, deleted, now.Add(-db.ChangesOverlap), deletedRows.Err()
+}
+
+func (s *Store) ListTags(userID string) ([]db.TagCount, error) {
+	query := `SELECT t.name, COUNT(et.entry_id) FROM tags t
+		JOIN entry_tags et ON et.tag_id = t.id
+		WHERE t.user_id = ?
+		GROUP BY t.name
+		ORDER BY COUNT(et.entry_id) DESC, t.name`
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []db.TagCount{}
+	for rows.Next() {
+		var tag db.TagCount
+		if err := rows.Scan(&tag.Name, &tag.Count); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
@@ -0,0 +1,160 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// migrations upgrade the schema one version at a time; the version a database
+// is at is kept in PRAGMA user_version. Like the Postgres ones, a released
+// migration must never be edited, only followed by a new one.
+//
+// Times are stored as Unix nanoseconds so they sort and compare as integers.
+var migrations = []string{
+	`CREATE TABLE users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		username TEXT NOT NULL UNIQUE,
+		email TEXT NOT NULL UNIQUE,
+		password_hash TEXT NOT NULL,
+		created_at INTEGER NOT NULL
+	);
+
+	CREATE TABLE sessions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		token_hash TEXT NOT NULL UNIQUE,
+		created_at INTEGER NOT NULL,
+		expires_at INTEGER NOT NULL
+	);
+
+	CREATE TABLE oauth_identities (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		provider TEXT NOT NULL,
+		subject TEXT NOT NULL,
+		email TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		UNIQUE (provider, subject)
+	);
+
+	-- AUTOINCREMENT keeps the id of a deleted entry from being reused, which
+	-- would clash with its tombstone in entry_deletions.
+	CREATE TABLE entries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		body TEXT NOT NULL,
+		nonce TEXT,
+		key_check TEXT,
+		mood INTEGER CHECK (mood BETWEEN 1 AND 5),
+		version INTEGER NOT NULL DEFAULT 1,
+		created_at INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL
+	);
+
+	CREATE INDEX entries_user_id_created_at_idx ON entries (user_id, created_at DESC, id DESC);
+	CREATE INDEX entries_user_id_updated_at_idx ON entries (user_id, updated_at);
+
+	CREATE TABLE entry_revisions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		entry_id INTEGER NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+		body TEXT NOT NULL,
+		nonce TEXT,
+		key_check TEXT,
+		created_at INTEGER NOT NULL
+	);
+
+	CREATE INDEX entry_revisions_entry_id_idx ON entry_revisions (entry_id, created_at DESC);
+
+	CREATE TABLE entry_deletions (
+		entry_id INTEGER PRIMARY KEY,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		deleted_at INTEGER NOT NULL
+	);
+
+	CREATE INDEX entry_deletions_user_id_deleted_at_idx ON entry_deletions (user_id, deleted_at);
+
+	CREATE TABLE tags (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		name TEXT NOT NULL,
+		UNIQUE (user_id, name)
+	);
+
+	CREATE TABLE entry_tags (
+		entry_id INTEGER NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+		tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+		PRIMARY KEY (entry_id, tag_id)
+	);
+
+	CREATE INDEX entry_tags_tag_id_idx ON entry_tags (tag_id);
+
+	CREATE TABLE user_keys (
+		user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+		kdf TEXT NOT NULL,
+		kdf_params TEXT NOT NULL,
+		salt TEXT NOT NULL,
+		wrapped_key TEXT NOT NULL,
+		wrap_nonce TEXT NOT NULL,
+		key_check TEXT NOT NULL,
+		updated_at INTEGER NOT NULL
+	);
+
+	-- Full-text index of plaintext entries; ciphertext must not end up in it.
+	CREATE VIRTUAL TABLE entries_fts USING fts5(body, tokenize = 'porter unicode61');
+
+	CREATE TRIGGER entries_fts_insert AFTER INSERT ON entries WHEN new.nonce IS NULL BEGIN
+		INSERT INTO entries_fts (rowid, body) VALUES (new.id, new.body);
+	END;
+
+	CREATE TRIGGER entries_fts_update AFTER UPDATE OF body, nonce ON entries BEGIN
+		DELETE FROM entries_fts WHERE rowid = old.id;
+		INSERT INTO entries_fts (rowid, body) SELECT new.id, new.body WHERE new.nonce IS NULL;
+	END;
+
+	CREATE TRIGGER entries_fts_delete AFTER DELETE ON entries BEGIN
+		DELETE FROM entries_fts WHERE rowid = old.id;
+	END;`,
+
+	`CREATE TABLE user_settings (
+		user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+		editor_mode TEXT NOT NULL,
+		time_zone TEXT NOT NULL,
+		date_format TEXT NOT NULL,
+		reminder_time TEXT NOT NULL,
+		theme TEXT NOT NULL,
+		line_numbers INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL
+	);`,
+}
+
+// migrate brings the schema up to date, refusing databases written by a newer
+// version of the server.
+func migrate(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("database schema is at version %d but this server only knows %d, is it outdated?", version, len(migrations))
+	}
+
+	for ; version < len(migrations); version++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(migrations[version]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d failed: %w", version+1, err)
+		}
+		// PRAGMA doesn't take parameters.
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, version+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package sqlite
+
+import (
+	"journalCli/db"
+	"strings"
+)
+
+// ftsPhrase quotes a phrase for an FTS5 query. Its words are letters and
+// digits only, so they need no escaping.
+func ftsPhrase(words []string) string {
+	return `"` + strings.Join(words, " ") + `"`
+}
+
+// ftsQuery translates web search syntax into an FTS5 query, or returns "" when
+// nothing but excluded phrases was asked for, which FTS5 can't express.
+func ftsQuery(search db.WebSearch) string {
+	groups := make([]string, len(search.Groups))
+	for i, group := range search.Groups {
+		phrases := make([]string, len(group))
+		for j, phrase := range group {
+			phrases[j] = ftsPhrase(phrase)
+		}
+		groups[i] = "(" + strings.Join(phrases, " OR ") + ")"
+	}
+	if len(groups) == 0 {
+		return ""
+	}
+
+	query := strings.Join(groups, " AND ")
+	for _, phrase := range search.Excluded {
+		query = "(" + query + ") NOT " + ftsPhrase(phrase)
+	}
+	return query
+}
+
+func (s *Store) SearchEntries(userID, q string, limit int) ([]db.SearchResult, error) {
+	results := []db.SearchResult{}
+	match := ftsQuery(db.ParseWebSearch(q))
+	if match == "" {
+		return results, nil
+	}
+
+	query := `SELECT ` + entryColumns + `,
+			-bm25(entries_fts) AS rank,
+			snippet(entries_fts, 0, '` + db.HighlightStart + `', '` + db.HighlightStop + `', ' ... ', 20)
+		FROM entries_fts JOIN entries e ON e.id = entries_fts.rowid
+		WHERE entries_fts MATCH ? AND e.user_id = ?
+		ORDER BY rank DESC, e.created_at DESC
+		LIMIT ?`
+	rows, err := s.db.Query(query, match, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var result db.SearchResult
+		if err := scanEntry(rows, &result.Entry, &result.Rank, &result.Snippet); err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
@@ -0,0 +1,272 @@
+// Package sqlite is a db.Store in a single SQLite file, for running the server
+// without Postgres. It creates and migrates its schema itself when opened.
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"journalCli/db"
+	"strconv"
+	"strings"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+// Open opens the database at path, creating it if needed. ":memory:" opens a
+// throwaway in-memory database.
+func Open(path string) (*Store, error) {
+	database, err := sql.Open("sqlite", path+"?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows one writer at a time; a single connection turns that into
+	// queueing instead of SQLITE_BUSY errors, and keeps :memory: databases
+	// from being one per connection.
+	database.SetMaxOpenConns(1)
+
+	if err := migrate(database); err != nil {
+		database.Close()
+		return nil, err
+	}
+	return &Store{db: database}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func nanos(t time.Time) int64 {
+	return t.UnixNano()
+}
+
+func fromNanos(n int64) time.Time {
+	return time.Unix(0, n)
+}
+
+// nullable stores empty strings as NULL.
+func nullable(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+// uniqueViolation returns the table.column of the UNIQUE constraint err
+// violates, or "" if it isn't a UNIQUE violation.
+func uniqueViolation(err error) string {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) || sqliteErr.Code() != sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+		return ""
+	}
+	// The message reads "... UNIQUE constraint failed: users.email (2067)".
+	_, columns, _ := strings.Cut(sqliteErr.Error(), "UNIQUE constraint failed: ")
+	column, _, _ := strings.Cut(columns, " ")
+	return column
+}
+
+func (s *Store) CreateUser(username, email, passwordHash string) (*db.User, error) {
+	query := `INSERT INTO users (username, email, password_hash, created_at) VALUES (?, ?, ?, ?)`
+	res, err := s.db.Exec(query, username, email, passwordHash, nanos(time.Now()))
+	switch uniqueViolation(err) {
+	case "":
+	case "users.email":
+		return nil, db.ErrEmailTaken
+	default:
+		return nil, db.ErrUsernameTaken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert user: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return &db.User{ID: strconv.FormatInt(id, 10), Email: email, Username: username}, nil
+}
+
+func (s *Store) GetUserByEmail(email string) (*db.User, error) {
+	var user db.User
+	query := `SELECT id, email, username, password_hash FROM users WHERE email = ?`
+	err := s.db.QueryRow(query, email).Scan(&user.ID, &user.Email, &user.Username, &user.Password_hash)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (s *Store) GetUserByID(id string) (*db.User, error) {
+	var user db.User
+	query := `SELECT id, username, email FROM users WHERE id = ?`
+	err := s.db.QueryRow(query, id).Scan(&user.ID, &user.Username, &user.Email)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (s *Store) FindOrCreateOAuthUser(provider, subject, email string) (*db.User, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var user db.User
+	query := `SELECT u.id, u.email, u.username FROM oauth_identities oi JOIN users u ON u.id = oi.user_id
+		WHERE oi.provider = ? AND oi.subject = ?`
+	err = tx.QueryRow(query, provider, subject).Scan(&user.ID, &user.Email, &user.Username)
+	if err == nil {
+		return &user, tx.Commit()
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	query = `SELECT id, email, username FROM users WHERE email = ?`
+	err = tx.QueryRow(query, email).Scan(&user.ID, &user.Email, &user.Username)
+	if errors.Is(err, sql.ErrNoRows) {
+		if err := createOAuthUser(tx, email, &user); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	query = `INSERT INTO oauth_identities (user_id, provider, subject, email, created_at) VALUES (?, ?, ?, ?, ?)`
+	if _, err := tx.Exec(query, user.ID, provider, subject, email, nanos(time.Now())); err != nil {
+		return nil, fmt.Errorf("failed to link %s identity: %w", provider, err)
+	}
+
+	return &user, tx.Commit()
+}
+
+// createOAuthUser inserts a user without a password, numbering the username
+// when it is taken.
+func createOAuthUser(tx *sql.Tx, email string, user *db.User) error {
+	base := db.UsernameFromEmail(email)
+	username := base
+
+	for i := 2; ; i++ {
+		var taken bool
+		if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM users WHERE username = ?)`, username).Scan(&taken); err != nil {
+			return err
+		}
+		if !taken {
+			break
+		}
+		username = base + strconv.Itoa(i)
+	}
+
+	query := `INSERT INTO users (username, email, password_hash, created_at) VALUES (?, ?, '', ?)`
+	res, err := tx.Exec(query, username, email, nanos(time.Now()))
+	if err != nil {
+		return fmt.Errorf("failed to insert user: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	user.ID = strconv.FormatInt(id, 10)
+	user.Email = email
+	user.Username = username
+	return nil
+}
+
+func (s *Store) CreateSession(userID string) (*db.Session, error) {
+	token, err := db.NewSessionToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate session token: %w", err)
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(db.SessionTTL)
+	query := `INSERT INTO sessions (user_id, token_hash, created_at, expires_at) VALUES (?, ?, ?, ?)`
+	if _, err := s.db.Exec(query, userID, db.HashSessionToken(token), nanos(now), nanos(expiresAt)); err != nil {
+		return nil, fmt.Errorf("failed to insert session: %w", err)
+	}
+
+	return &db.Session{Token: token, UserID: userID, ExpiresAt: expiresAt}, nil
+}
+
+func (s *Store) GetUserBySessionToken(token string) (*db.User, error) {
+	var user db.User
+	query := `SELECT u.id, u.email, u.username FROM sessions s JOIN users u ON u.id = s.user_id WHERE s.token_hash = ? AND s.expires_at > ?`
+	err := s.db.QueryRow(query, db.HashSessionToken(token), nanos(time.Now())).Scan(&user.ID, &user.Email, &user.Username)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (s *Store) DeleteSession(token string) error {
+	_, err := s.db.Exec(`DELETE FROM sessions WHERE token_hash = ?`, db.HashSessionToken(token))
+	return err
+}
+
+func (s *Store) DeleteUserSessions(userID string) error {
+	_, err := s.db.Exec(`DELETE FROM sessions WHERE user_id = ?`, userID)
+	return err
+}
+
+func (s *Store) DeleteExpiredSessions() error {
+	_, err := s.db.Exec(`DELETE FROM sessions WHERE expires_at <= ?`, nanos(time.Now()))
+	return err
+}
+
+func (s *Store) GetUserKey(userID string) (*db.UserKey, error) {
+	var key db.UserKey
+	var updatedAt int64
+	query := `SELECT kdf, kdf_params, salt, wrapped_key, wrap_nonce, key_check, updated_at FROM user_keys WHERE user_id = ?`
+	err := s.db.QueryRow(query, userID).Scan(&key.KDF, &key.KDFParams, &key.Salt, &key.WrappedKey, &key.WrapNonce, &key.KeyCheck, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	key.UpdatedAt = fromNanos(updatedAt)
+	return &key, nil
+}
+
+func (s *Store) PutUserKey(userID string, key db.UserKey) (*db.UserKey, error) {
+	key.UpdatedAt = fromNanos(nanos(time.Now()))
+	query := `INSERT INTO user_keys (user_id, kdf, kdf_params, salt, wrapped_key, wrap_nonce, key_check, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET kdf = excluded.kdf, kdf_params = excluded.kdf_params, salt = excluded.salt,
+			wrapped_key = excluded.wrapped_key, wrap_nonce = excluded.wrap_nonce, key_check = excluded.key_check, updated_at = excluded.updated_at`
+	_, err := s.db.Exec(query, userID, key.KDF, key.KDFParams, key.Salt, key.WrappedKey, key.WrapNonce, key.KeyCheck, nanos(key.UpdatedAt))
+	if err != nil {
+		return nil, fmt.Errorf("failed to store user key: %w", err)
+	}
+	return &key, nil
+}
+
+func (s *Store) GetUserSettings(userID string) (*db.UserSettings, error) {
+	var settings db.UserSettings
+	var updatedAt int64
+	query := `SELECT editor_mode, time_zone, date_format, reminder_time, theme, line_numbers, updated_at FROM user_settings WHERE user_id = ?`
+	err := s.db.QueryRow(query, userID).Scan(&settings.EditorMode, &settings.TimeZone, &settings.DateFormat,
+		&settings.ReminderTime, &settings.Theme, &settings.LineNumbers, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	settings.UpdatedAt = fromNanos(updatedAt)
+	return &settings, nil
+}
+
+func (s *Store) PutUserSettings(userID string, settings db.UserSettings) (*db.UserSettings, error) {
+	settings.UpdatedAt = fromNanos(nanos(time.Now()))
+	query := `INSERT INTO user_settings (user_id, editor_mode, time_zone, date_format, reminder_time, theme, line_numbers, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET editor_mode = excluded.editor_mode, time_zone = excluded.time_zone,
+			date_format = excluded.date_format, reminder_time = excluded.reminder_time, theme = excluded.theme,
+			line_numbers = excluded.line_numbers, updated_at = excluded.updated_at`
+	_, err := s.db.Exec(query, userID, settings.EditorMode, settings.TimeZone, settings.DateFormat,
+		settings.ReminderTime, settings.Theme, settings.LineNumbers, nanos(settings.UpdatedAt))
+	if err != nil {
+		return nil, fmt.Errorf("failed to store user settings: %w", err)
+	}
+	return &settings, nil
+}
@@ -0,0 +1,20 @@
+package sqlite_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"journalCli/db"
+	"journalCli/db/sqlite"
+	"journalCli/db/storetest"
+)
+
+func TestStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) db.Store {
+		s, err := sqlite.Open(filepath.Join(t.TempDir(), "journal.db"))
+		if err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		return s
+	})
+}
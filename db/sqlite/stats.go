@@ -0,0 +1,150 @@
+package sqlite
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"journalCli/db"
+	"sync"
+	"time"
+
+	"modernc.org/sqlite"
+)
+
+// SQLite has no time zones, so the stats queries call these Go functions to
+// put entries on days and hours in the user's time zone:
+//
+//	local_time(created_at, 'Europe/Berlin', '2006-01-02')
+//	word_count(body)
+func init() {
+	sqlite.MustRegisterFunction("local_time", &sqlite.FunctionImpl{NArgs: 3, Deterministic: true, Scalar: localTime})
+	sqlite.MustRegisterFunction("word_count", &sqlite.FunctionImpl{NArgs: 1, Deterministic: true, Scalar: wordCount})
+}
+
+// locations caches the time zones local_time loads, since it runs once per
+// row.
+var locations sync.Map
+
+func loadLocation(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+func localTime(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+	createdAt, ok := args[0].(int64)
+	if !ok {
+		return nil, fmt.Errorf("local_time: created_at is %T, not an integer", args[0])
+	}
+	name, _ := args[1].(string)
+	layout, _ := args[2].(string)
+	loc, err := loadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("local_time: %w", err)
+	}
+	return fromNanos(createdAt).In(loc).Format(layout), nil
+}
+
+func wordCount(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+	body, _ := args[0].(string)
+	return int64(db.WordCount(body)), nil
+}
+
+func (s *Store) ListEntryDays(userID string, loc *time.Location) ([]time.Time, error) {
+	query := `SELECT DISTINCT local_time(created_at, ?, '2006-01-02') AS day FROM entries
+		WHERE user_id = ?
+		ORDER BY day DESC`
+	rows, err := s.db.Query(query, loc.String(), userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := []time.Time{}
+	for rows.Next() {
+		var day string
+		if err := rows.Scan(&day); err != nil {
+			return nil, err
+		}
+		t, err := time.ParseInLocation(time.DateOnly, day, loc)
+		if err != nil {
+			return nil, err
+		}
+		days = append(days, t)
+	}
+	return days, rows.Err()
+}
+
+func (s *Store) GetStats(userID string, loc *time.Location, since time.Time) (*db.Stats, error) {
+	stats := &db.Stats{Days: []db.DayStats{}}
+
+	query := `SELECT local_time(created_at, ?, '2006-01-02') AS day, COUNT(*),
+		SUM(CASE WHEN nonce IS NULL THEN word_count(body) ELSE 0 END),
+		AVG(mood)
+		FROM entries
+		WHERE user_id = ? AND created_at >= ?
+		GROUP BY day
+		ORDER BY day`
+	rows, err := s.db.Query(query, loc.String(), userID, nanos(since))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var day db.DayStats
+		var mood sql.NullFloat64
+		if err := rows.Scan(&day.Date, &day.Entries, &day.Words, &mood); err != nil {
+			return nil, err
+		}
+		if mood.Valid {
+			day.Mood = &mood.Float64
+		}
+		stats.Days = append(stats.Days, day)
+		stats.Entries += day.Entries
+		stats.Words += day.Words
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	query = `SELECT CAST(local_time(created_at, ?, '15') AS INTEGER) AS hour, COUNT(*)
+		FROM entries
+		WHERE user_id = ? AND created_at >= ?
+		GROUP BY hour`
+	hours, err := s.db.Query(query, loc.String(), userID, nanos(since))
+	if err != nil {
+		return nil, err
+	}
+	defer hours.Close()
+	for hours.Next() {
+		var hour, count int
+		if err := hours.Scan(&hour, &count); err != nil {
+			return nil, err
+		}
+		stats.Hours[hour] = count
+	}
+	if err := hours.Err(); err != nil {
+		return nil, err
+	}
+
+	var mood sql.NullFloat64
+	query = `SELECT AVG(mood) FROM entries WHERE user_id = ? AND created_at >= ?`
+	if err := s.db.QueryRow(query, userID, nanos(since)).Scan(&mood); err != nil {
+		return nil, err
+	}
+	if mood.Valid {
+		stats.MoodAverage = &mood.Float64
+	}
+
+	if stats.Tags, err = s.ListTags(userID); err != nil {
+		return nil, err
+	}
+	stats.Tags = stats.Tags[:min(len(stats.Tags), db.TopTags)]
+	return stats, nil
+}
@@ -0,0 +1,203 @@
+package db
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// Streak is how regularly a user writes: Current is the days in a row up to
+// today, or up to yesterday while today's entry isn't written yet, Longest
+// the most days in a row ever, and DaysThisMonth the days written on in the
+// current month.
+type Streak struct {
+	Current       int  `json:"current"`
+	Longest       int  `json:"longest"`
+	DaysThisMonth int  `json:"days_this_month"`
+	WroteToday    bool `json:"wrote_today"`
+}
+
+// ListEntryDays returns the days a user wrote entries on, newest first, as
+// midnight in loc.
+func ListEntryDays(db *sql.DB, userID string, loc *time.Location) ([]time.Time, error) {
+	query := `SELECT DISTINCT (created_at AT TIME ZONE $2)::date AS day FROM entries
+		WHERE user_id = $1
+		ORDER BY day DESC`
+	rows, err := db.Query(query, userID, loc.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := []time.Time{}
+	for rows.Next() {
+		var day time.Time
+		if err := rows.Scan(&day); err != nil {
+			return nil, err
+		}
+		days = append(days, time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc))
+	}
+	return days, rows.Err()
+}
+
+// EntryDays returns the distinct days of times, which must be sorted newest
+// first, as midnight in loc. It is ListEntryDays for stores without SQL.
+func EntryDays(times []time.Time, loc *time.Location) []time.Time {
+	days := []time.Time{}
+	for _, t := range times {
+		t = t.In(loc)
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		if len(days) == 0 || !days[len(days)-1].Equal(day) {
+			days = append(days, day)
+		}
+	}
+	return days
+}
+
+// dayNumber counts days from the Unix epoch to the date of t, which unlike
+// subtracting times doesn't trip over daylight saving time.
+func dayNumber(t time.Time) int {
+	year, month, day := t.Date()
+	return int(time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Unix() / (24 * 60 * 60))
+}
+
+// ComputeStreak returns the streak of a user who wrote on days, newest first
+// as ListEntryDays returns them, as of now in the same location. Days after
+// today, from entries dated in the future, don't count.
+func ComputeStreak(days []time.Time, now time.Time) Streak {
+	var streak Streak
+	today := dayNumber(now)
+	run, prev, first := 0, 0, true
+
+	for _, day := range days {
+		n := dayNumber(day)
+		if n > today {
+			continue
+		}
+		if n == today {
+			streak.WroteToday = true
+		}
+		if day.Year() == now.Year() && day.Month() == now.Month() {
+			streak.DaysThisMonth++
+		}
+
+		if run > 0 && n == prev-1 {
+			run++
+		} else {
+			// The current streak is the first run, if it is still going.
+			first = run == 0 && n >= today-1
+			run = 1
+		}
+		prev = n
+		streak.Longest = max(streak.Longest, run)
+		if first {
+			streak.Current = run
+		}
+	}
+	return streak
+}
+
+// TopTags is how many of the most used tags Stats lists.
+const TopTags = 10
+
+// Stats sums up a user's entries since a day, for the Insights page. Words
+// of encrypted entries aren't counted since the server can't read them.
+type Stats struct {
+	Entries int `json:"entries"`
+	Words   int `json:"words"`
+	// Days lists the days with entries, oldest first.
+	Days []DayStats `json:"days"`
+	// Hours counts entries by the hour of the day they were written at.
+	Hours [24]int `json:"hours"`
+	// MoodAverage is the average mood of the entries that have one, nil
+	// when none do.
+	MoodAverage *float64 `json:"mood_average"`
+	// Tags are the most used tags of all time.
+	Tags []TagCount `json:"tags"`
+}
+
+type DayStats struct {
+	Date    string   `json:"date"`
+	Entries int      `json:"entries"`
+	Words   int      `json:"words"`
+	Mood    *float64 `json:"mood"`
+}
+
+// WordCount counts the words of a body the way the stores do in SQL.
+func WordCount(body string) int {
+	return len(strings.Fields(body))
+}
+
+// nullFloat returns the value of an average, which is NULL over no rows.
+func nullFloat(f sql.NullFloat64) *float64 {
+	if !f.Valid {
+		return nil
+	}
+	return &f.Float64
+}
+
+// GetStats sums up the entries a user wrote since a time, counting days and
+// hours in loc.
+func GetStats(db *sql.DB, userID string, loc *time.Location, since time.Time) (*Stats, error) {
+	stats := &Stats{Days: []DayStats{}}
+
+	query := `SELECT to_char(created_at AT TIME ZONE $2, 'YYYY-MM-DD') AS day, COUNT(*),
+		SUM(CASE WHEN nonce IS NULL THEN (SELECT COUNT(*) FROM regexp_matches(body, '\S+', 'g')) ELSE 0 END),
+		AVG(mood)
+		FROM entries
+		WHERE user_id = $1 AND created_at >= $3
+		GROUP BY day
+		ORDER BY day`
+	rows, err := db.Query(query, userID, loc.String(), since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var day DayStats
+		var mood sql.NullFloat64
+		if err := rows.Scan(&day.Date, &day.Entries, &day.Words, &mood); err != nil {
+			return nil, err
+		}
+		day.Mood = nullFloat(mood)
+		stats.Days = append(stats.Days, day)
+		stats.Entries += day.Entries
+		stats.Words += day.Words
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	query = `SELECT EXTRACT(HOUR FROM created_at AT TIME ZONE $2)::int AS hour, COUNT(*)
+		FROM entries
+		WHERE user_id = $1 AND created_at >= $3
+		GROUP BY hour`
+	hours, err := db.Query(query, userID, loc.String(), since)
+	if err != nil {
+		return nil, err
+	}
+	defer hours.Close()
+	for hours.Next() {
+		var hour, count int
+		if err := hours.Scan(&hour, &count); err != nil {
+			return nil, err
+		}
+		stats.Hours[hour] = count
+	}
+	if err := hours.Err(); err != nil {
+		return nil, err
+	}
+
+	var mood sql.NullFloat64
+	query = `SELECT AVG(mood) FROM entries WHERE user_id = $1 AND created_at >= $2`
+	if err := db.QueryRow(query, userID, since).Scan(&mood); err != nil {
+		return nil, err
+	}
+	stats.MoodAverage = nullFloat(mood)
+
+	if stats.Tags, err = ListTags(db, userID); err != nil {
+		return nil, err
+	}
+	stats.Tags = stats.Tags[:min(len(stats.Tags), TopTags)]
+	return stats, nil
+}
@@ -0,0 +1,91 @@
+package db_test
+
+import (
+	"testing"
+	"time"
+
+	"journalCli/db"
+)
+
+func TestComputeStreak(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("LoadLocation: %v", err)
+	}
+	// Clocks in New York went forward on 2026-03-08, a day of 23 hours.
+	now := time.Date(2026, time.March, 10, 9, 0, 0, 0, newYork)
+
+	// days returns the days that many days before now, as ListEntryDays
+	// returns them.
+	days := func(ago ...int) []time.Time {
+		list := []time.Time{}
+		for _, n := range ago {
+			list = append(list, time.Date(now.Year(), now.Month(), now.Day()-n, 0, 0, 0, 0, newYork))
+		}
+		return list
+	}
+
+	tests := []struct {
+		name string
+		days []time.Time
+		want db.Streak
+	}{
+		{
+			name: "no entries",
+			days: days(),
+			want: db.Streak{},
+		},
+		{
+			name: "today",
+			days: days(0),
+			want: db.Streak{Current: 1, Longest: 1, DaysThisMonth: 1, WroteToday: true},
+		},
+		{
+			name: "yesterday but not today",
+			days: days(1, 2),
+			want: db.Streak{Current: 2, Longest: 2, DaysThisMonth: 2},
+		},
+		{
+			name: "broken before yesterday",
+			days: days(2, 3),
+			want: db.Streak{Current: 0, Longest: 2, DaysThisMonth: 2},
+		},
+		{
+			name: "across the change to daylight saving time",
+			days: days(0, 1, 2, 3),
+			want: db.Streak{Current: 4, Longest: 4, DaysThisMonth: 4, WroteToday: true},
+		},
+		{
+			name: "only the first run is current",
+			days: days(0, 1, 3, 4, 5),
+			want: db.Streak{Current: 2, Longest: 3, DaysThisMonth: 5, WroteToday: true},
+		},
+		{
+			name: "longest run in the past",
+			days: days(0, 5, 6, 7),
+			want: db.Streak{Current: 1, Longest: 3, DaysThisMonth: 4, WroteToday: true},
+		},
+		{
+			name: "future days don't count",
+			days: days(-2, -1, 1),
+			want: db.Streak{Current: 1, Longest: 1, DaysThisMonth: 1},
+		},
+		{
+			name: "days of last month",
+			days: days(8, 9, 10, 11),
+			want: db.Streak{Current: 0, Longest: 4, DaysThisMonth: 2},
+		},
+		{
+			name: "run into last month",
+			days: days(0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10),
+			want: db.Streak{Current: 11, Longest: 11, DaysThisMonth: 10, WroteToday: true},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := db.ComputeStreak(test.days, now); got != test.want {
+				t.Errorf("ComputeStreak = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ErrNotFound is returned by Store methods when the user, entry, session or
+// key asked for doesn't exist. It is sql.ErrNoRows so the Postgres store can
+// pass on errors from Scan as they are.
+var ErrNotFound = sql.ErrNoRows
+
+// Store is the storage the server runs on. Postgres is the production one;
+// the sqlite and memory packages implement it too, and all of them are held to
+// the same behaviour by the storetest conformance suite.
+type Store interface {
+	CreateUser(username, email, passwordHash string) (*User, error)
+	GetUserByEmail(email string) (*User, error)
+	GetUserByID(id string) (*User, error)
+	// FindOrCreateOAuthUser returns the user an OAuth identity belongs to,
+	// linking it to the user with the same email or to a new user the first
+	// time it is seen.
+	FindOrCreateOAuthUser(provider, subject, email string) (*User, error)
+
+	CreateSession(userID string) (*Session, error)
+	// GetUserBySessionToken resolves a token to its user, ignoring expired
+	// sessions.
+	GetUserBySessionToken(token string) (*User, error)
+	DeleteSession(token string) error
+	DeleteUserSessions(userID string) error
+	DeleteExpiredSessions() error
+
+	// CreateEntry saves a new entry, tagging it with the hashtags of its body.
+	// A zero createdAt means now.
+	CreateEntry(userID, body string, enc Encryption, mood *int, createdAt time.Time) (*Entry, error)
+	GetEntryByID(id string) (*Entry, error)
+	// ListEntriesPage returns up to limit entries of a user matching filter,
+	// newest first, starting after cursor (empty for the first page). The
+	// returned cursor is empty when there are no more entries.
+	ListEntriesPage(userID string, filter EntryFilter, limit int, cursor string) ([]Entry, string, error)
+	// UpdateEntry replaces the body of an entry owned by userID, keeping the
+	// previous body as a revision. A nil mood leaves the mood unchanged. When
+	// baseVersion is set and the entry has moved past it, it returns
+	// ErrVersionConflict.
+	UpdateEntry(id, userID, body string, enc Encryption, mood *int, baseVersion *int) (*Entry, error)
+	// DeleteEntry deletes an entry owned by userID with its revisions, leaving
+	// a tombstone for ListEntryChanges.
+	DeleteEntry(id, userID string) error
+	// ListEntryRevisions returns the previous bodies of an entry, newest first.
+	ListEntryRevisions(entryID string) ([]EntryRevision, error)
+	// ListEntryChanges returns the entries of a user created or updated at or
+	// after since, the ids of those deleted at or after it, and the since to
+	// pass next time.
+	ListEntryChanges(userID string, since time.Time) ([]Entry, []string, time.Time, error)
+	// SearchEntries runs a full-text search over a user's plaintext entries,
+	// best match first, using web search syntax.
+	SearchEntries(userID, q string, limit int) ([]SearchResult, error)
+	// ListTags returns the tags a user has used, most used first.
+	ListTags(userID string) ([]TagCount, error)
+	// ListEntryDays returns the days a user wrote entries on, newest first,
+	// as midnight in loc.
+	ListEntryDays(userID string, loc *time.Location) ([]time.Time, error)
+	// GetStats sums up the entries a user wrote since a time, counting days
+	// and hours in loc.
+	GetStats(userID string, loc *time.Location, since time.Time) (*Stats, error)
+
+	GetUserKey(userID string) (*UserKey, error)
+	// PutUserKey stores the key envelope of a user, replacing the previous one.
+	PutUserKey(userID string, key UserKey) (*UserKey, error)
+	// GetUserSettings returns ErrNotFound for a user who never saved any.
+	GetUserSettings(userID string) (*UserSettings, error)
+	// PutUserSettings stores the settings of a user, replacing the previous
+	// ones.
+	PutUserSettings(userID string, settings UserSettings) (*UserSettings, error)
+
+	Close() error
+}
+
+// Postgres is the Store backed by the functions of this package.
+type Postgres struct {
+	db *sql.DB
+}
+
+// NewPostgres wraps a database opened with InitDB.
+func NewPostgres(db *sql.DB) *Postgres {
+	return &Postgres{db: db}
+}
+
+func (p *Postgres) CreateUser(username, email, passwordHash string) (*User, error) {
+	return CreateUser(p.db, username, email, passwordHash)
+}
+
+func (p *Postgres) GetUserByEmail(email string) (*User, error) {
+	return GetUserByEmail(p.db, email)
+}
+
+func (p *Postgres) GetUserByID(id string) (*User, error) {
+	return GetUserByID(p.db, id)
+}
+
+func (p *Postgres) FindOrCreateOAuthUser(provider, subject, email string) (*User, error) {
+	return FindOrCreateOAuthUser(p.db, provider, subject, email)
+}
+
+func (p *Postgres) CreateSession(userID string) (*Session, error) {
+	return CreateSession(p.db, userID)
+}
+
+func (p *Postgres) GetUserBySessionToken(token string) (*User, error) {
+	return GetUserBySessionToken(p.db, token)
+}
+
+func (p *Postgres) DeleteSession(token string) error {
+	return DeleteSession(p.db, token)
+}
+
+func (p *Postgres) DeleteUserSessions(userID string) error {
+	return DeleteUserSessions(p.db, userID)
+}
+
+func (p *Postgres) DeleteExpiredSessions() error {
+	return DeleteExpiredSessions(p.db)
+}
+
+func (p *Postgres) CreateEntry(userID, body string, enc Encryption, mood *int, createdAt time.Time) (*Entry, error) {
+	return CreateEntry(p.db, userID, body, enc, mood, createdAt)
+}
+
+func (p *Postgres) GetEntryByID(id string) (*Entry, error) {
+	return GetEntryByID(p.db, id)
+}
+
+func (p *Postgres) ListEntriesPage(userID string, filter EntryFilter, limit int, cursor string) ([]Entry, string, error) {
+	return ListEntriesPage(p.db, userID, filter, limit, cursor)
+}
+
+func (p *Postgres) UpdateEntry(id, userID, body string, enc Encryption, mood *int, baseVersion *int) (*Entry, error) {
+	return UpdateEntry(p.db, id, userID, body, enc, mood, baseVersion)
+}
+
+func (p *Postgres) DeleteEntry(id, userID string) error {
+	return DeleteEntry(p.db, id, userID)
+}
+
+func (p *Postgres) ListEntryRevisions(entryID string) ([]EntryRevision, error) {
+	return ListEntryRevisions(p.db, entryID)
+}
+
+func (p *Postgres) ListEntryChanges(userID string, since time.Time) ([]Entry, []string, time.Time, error) {
+	return ListEntryChanges(p.db, userID, since)
+}
+
+func (p *Postgres) SearchEntries(userID, q string, limit int) ([]SearchResult, error) {
+	return SearchEntries(p.db, userID, q, limit)
+}
+
+func (p *Postgres) ListTags(userID string) ([]TagCount, error) {
+	return ListTags(p.db, userID)
+}
+
+func (p *Postgres) ListEntryDays(userID string, loc *time.Location) ([]time.Time, error) {
+	return ListEntryDays(p.db, userID, loc)
+}
+
+func (p *Postgres) GetStats(userID string, loc *time.Location, since time.Time) (*Stats, error) {
+	return GetStats(p.db, userID, loc, since)
+}
+
+func (p *Postgres) GetUserKey(userID string) (*UserKey, error) {
+	return GetUserKey(p.db, userID)
+}
+
+func (p *Postgres) PutUserKey(userID string, key UserKey) (*UserKey, error) {
+	return PutUserKey(p.db, userID, key)
+}
+
+func (p *Postgres) GetUserSettings(userID string) (*UserSettings, error) {
+	return GetUserSettings(p.db, userID)
+}
+
+func (p *Postgres) PutUserSettings(userID string, settings UserSettings) (*UserSettings, error) {
+	return PutUserSettings(p.db, userID, settings)
+}
+
+func (p *Postgres) Close() error {
+	return p.db.Close()
+}
@@ -0,0 +1,733 @@
+// Package storetest is the conformance suite every db.Store has to pass, so
+// the handlers behave the same whichever one the server runs on. A backend
+// runs it from a test of its own:
+//
+//	func TestStore(t *testing.T) {
+//		storetest.Run(t, func(t *testing.T) db.Store {
+//			return memory.New()
+//		})
+//	}
+//
+// open must return an empty store; Run closes it when each subtest ends.
+package storetest
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"journalCli/db"
+	"journalCli/utils"
+)
+
+// Run runs the suite against the stores returned by open.
+func Run(t *testing.T, open func(t *testing.T) db.Store) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, s db.Store)
+	}{
+		{"Users", testUsers},
+		{"Sessions", testSessions},
+		{"OAuthUsers", testOAuthUsers},
+		{"CreateEntry", testCreateEntry},
+		{"ListEntriesPage", testListEntriesPage},
+		{"UpdateEntry", testUpdateEntry},
+		{"DeleteEntry", testDeleteEntry},
+		{"EntryChanges", testEntryChanges},
+		{"Search", testSearch},
+		{"Tags", testTags},
+		{"LongTags", testLongTags},
+		{"EntryDays", testEntryDays},
+		{"Stats", testStats},
+		{"Keys", testKeys},
+		{"Settings", testSettings},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := open(t)
+			t.Cleanup(func() {
+				if err := s.Close(); err != nil {
+					t.Errorf("Close: %v", err)
+				}
+			})
+			tt.test(t, s)
+		})
+	}
+}
+
+func createUser(t *testing.T, s db.Store, name string) *db.User {
+	t.Helper()
+	user, err := s.CreateUser(name, name+"@example.com", "hash-"+name)
+	if err != nil {
+		t.Fatalf("CreateUser(%q): %v", name, err)
+	}
+	return user
+}
+
+func createEntry(t *testing.T, s db.Store, userID, body string, mood *int, createdAt time.Time) *db.Entry {
+	t.Helper()
+	entry, err := s.CreateEntry(userID, body, db.Encryption{}, mood, createdAt)
+	if err != nil {
+		t.Fatalf("CreateEntry(%q): %v", body, err)
+	}
+	return entry
+}
+
+func intPtr(n int) *int {
+	return &n
+}
+
+func sorted(values []string) []string {
+	values = slices.Clone(values)
+	slices.Sort(values)
+	return values
+}
+
+func entryIDs(entries []db.Entry) []string {
+	ids := make([]string, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.ID
+	}
+	return ids
+}
+
+func testUsers(t *testing.T, s db.Store) {
+	user, err := s.CreateUser("alice", "alice@example.com", "secret-hash")
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if user.ID == "" || user.Username != "alice" || user.Email != "alice@example.com" || user.Password_hash != "" {
+		t.Errorf("CreateUser = %+v, want an id, the username and email and no hash", user)
+	}
+
+	if _, err := s.CreateUser("alice2", "alice@example.com", "x"); !errors.Is(err, db.ErrEmailTaken) {
+		t.Errorf("CreateUser with a taken email: err = %v, want ErrEmailTaken", err)
+	}
+	if _, err := s.CreateUser("alice", "other@example.com", "x"); !errors.Is(err, db.ErrUsernameTaken) {
+		t.Errorf("CreateUser with a taken username: err = %v, want ErrUsernameTaken", err)
+	}
+
+	byEmail, err := s.GetUserByEmail("alice@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail: %v", err)
+	}
+	if byEmail.ID != user.ID || byEmail.Password_hash != "secret-hash" {
+		t.Errorf("GetUserByEmail = %+v, want id %s with its password hash", byEmail, user.ID)
+	}
+
+	byID, err := s.GetUserByID(user.ID)
+	if err != nil {
+		t.Fatalf("GetUserByID: %v", err)
+	}
+	if byID.ID != user.ID || byID.Username != "alice" || byID.Password_hash != "" {
+		t.Errorf("GetUserByID = %+v, want alice without her hash", byID)
+	}
+
+	if _, err := s.GetUserByEmail("nobody@example.com"); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("GetUserByEmail of an unknown email: err = %v, want ErrNotFound", err)
+	}
+	if _, err := s.GetUserByID("999999"); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("GetUserByID of an unknown id: err = %v, want ErrNotFound", err)
+	}
+}
+
+func testSessions(t *testing.T, s db.Store) {
+	alice := createUser(t, s, "alice")
+
+	first, err := s.CreateSession(alice.ID)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	second, err := s.CreateSession(alice.ID)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	if first.Token == "" || first.Token == second.Token {
+		t.Errorf("CreateSession tokens %q and %q, want two different ones", first.Token, second.Token)
+	}
+	if !first.ExpiresAt.After(time.Now()) {
+		t.Errorf("CreateSession expires at %v, want in the future", first.ExpiresAt)
+	}
+
+	user, err := s.GetUserBySessionToken(first.Token)
+	if err != nil {
+		t.Fatalf("GetUserBySessionToken: %v", err)
+	}
+	if user.ID != alice.ID || user.Email != alice.Email {
+		t.Errorf("GetUserBySessionToken = %+v, want alice", user)
+	}
+	if _, err := s.GetUserBySessionToken("not-a-token"); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("GetUserBySessionToken of an unknown token: err = %v, want ErrNotFound", err)
+	}
+
+	if err := s.DeleteSession(first.Token); err != nil {
+		t.Fatalf("DeleteSession: %v", err)
+	}
+	if _, err := s.GetUserBySessionToken(first.Token); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("token still valid after DeleteSession: err = %v", err)
+	}
+	if _, err := s.GetUserBySessionToken(second.Token); err != nil {
+		t.Errorf("DeleteSession revoked another session: %v", err)
+	}
+
+	if err := s.DeleteUserSessions(alice.ID); err != nil {
+		t.Fatalf("DeleteUserSessions: %v", err)
+	}
+	if _, err := s.GetUserBySessionToken(second.Token); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("token still valid after DeleteUserSessions: err = %v", err)
+	}
+
+	if err := s.DeleteExpiredSessions(); err != nil {
+		t.Errorf("DeleteExpiredSessions: %v", err)
+	}
+}
+
+func testOAuthUsers(t *testing.T, s db.Store) {
+	alice := createUser(t, s, "alice")
+
+	linked, err := s.FindOrCreateOAuthUser("google", "sub-alice", "alice@example.com")
+	if err != nil {
+		t.Fatalf("FindOrCreateOAuthUser: %v", err)
+	}
+	if linked.ID != alice.ID {
+		t.Errorf("identity with alice's email linked to user %s, want %s", linked.ID, alice.ID)
+	}
+
+	created, err := s.FindOrCreateOAuthUser("google", "sub-bob", "Bob.Smith@example.com")
+	if err != nil {
+		t.Fatalf("FindOrCreateOAuthUser: %v", err)
+	}
+	if created.ID == alice.ID || created.Username != "bob.smith" || created.Email != "Bob.Smith@example.com" {
+		t.Errorf("FindOrCreateOAuthUser for a new email = %+v, want a new user bob.smith", created)
+	}
+
+	again, err := s.FindOrCreateOAuthUser("google", "sub-bob", "bob@elsewhere.example")
+	if err != nil {
+		t.Fatalf("FindOrCreateOAuthUser: %v", err)
+	}
+	if again.ID != created.ID {
+		t.Errorf("known identity resolved to user %s, want %s", again.ID, created.ID)
+	}
+
+	clash, err := s.FindOrCreateOAuthUser("google", "sub-other-alice", "alice@other.example")
+	if err != nil {
+		t.Fatalf("FindOrCreateOAuthUser: %v", err)
+	}
+	if clash.Username != "alice2" {
+		t.Errorf("username for a taken local part = %q, want alice2", clash.Username)
+	}
+}
+
+func testCreateEntry(t *testing.T, s db.Store) {
+	alice := createUser(t, s, "alice")
+
+	entry := createEntry(t, s, alice.ID, "Walked the #dog, then #Work", intPtr(4), time.Time{})
+	if entry.ID == "" || entry.UserID != alice.ID || entry.Version != 1 {
+		t.Errorf("CreateEntry = %+v, want an id, alice's user id and version 1", entry)
+	}
+	if entry.Mood == nil || *entry.Mood != 4 {
+		t.Errorf("CreateEntry mood = %v, want 4", entry.Mood)
+	}
+	if got := sorted(entry.Tags); !slices.Equal(got, []string{"dog", "work"}) {
+		t.Errorf("CreateEntry tags = %v, want dog and work", got)
+	}
+	if time.Since(entry.CreatedAt) > time.Minute || entry.CreatedAt.After(time.Now().Add(time.Second)) {
+		t.Errorf("CreateEntry created at %v, want now", entry.CreatedAt)
+	}
+
+	got, err := s.GetEntryByID(entry.ID)
+	if err != nil {
+		t.Fatalf("GetEntryByID: %v", err)
+	}
+	if got.Body != entry.Body || got.UserID != alice.ID || !slices.Equal(got.Tags, []string{"dog", "work"}) {
+		t.Errorf("GetEntryByID = %+v, want the entry with sorted tags", got)
+	}
+
+	written := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	offline := createEntry(t, s, alice.ID, "written offline", nil, written)
+	if !offline.CreatedAt.Equal(written) || offline.Mood != nil {
+		t.Errorf("CreateEntry with createdAt = %+v, want created at %v without mood", offline, written)
+	}
+
+	enc := db.Encryption{Nonce: "bm9uY2U", KeyCheck: "Y2hlY2s"}
+	sealed, err := s.CreateEntry(alice.ID, "Y2lwaGVydGV4dCAjbm90YXRhZw", enc, nil, time.Time{})
+	if err != nil {
+		t.Fatalf("CreateEntry encrypted: %v", err)
+	}
+	if sealed.Encryption != enc || len(sealed.Tags) != 0 {
+		t.Errorf("encrypted CreateEntry = %+v, want its encryption and no tags", sealed)
+	}
+	if got, err := s.GetEntryByID(sealed.ID); err != nil || got.Encryption != enc {
+		t.Errorf("GetEntryByID encrypted = %+v, %v, want its encryption back", got, err)
+	}
+
+	if _, err := s.GetEntryByID("999999"); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("GetEntryByID of an unknown id: err = %v, want ErrNotFound", err)
+	}
+}
+
+func testListEntriesPage(t *testing.T, s db.Store) {
+	alice := createUser(t, s, "alice")
+	bob := createUser(t, s, "bob")
+
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	var ids []string
+	for i, mood := range []int{1, 2, 3, 4, 5} {
+		body := "day"
+		if i%2 == 0 {
+			body += " #even"
+		}
+		ids = append(ids, createEntry(t, s, alice.ID, body, intPtr(mood), base.Add(time.Duration(i)*time.Hour)).ID)
+	}
+	// Same time as the newest one, so only the id breaks the tie.
+	ids = append(ids, createEntry(t, s, alice.ID, "tie", nil, base.Add(4*time.Hour)).ID)
+	createEntry(t, s, bob.ID, "not alice's #even", intPtr(3), base)
+
+	newestFirst := []string{ids[5], ids[4], ids[3], ids[2], ids[1], ids[0]}
+
+	var pages [][]string
+	cursor := ""
+	for {
+		entries, next, err := s.ListEntriesPage(alice.ID, db.EntryFilter{}, 4, cursor)
+		if err != nil {
+			t.Fatalf("ListEntriesPage: %v", err)
+		}
+		pages = append(pages, entryIDs(entries))
+		if next == "" {
+			break
+		}
+		if len(pages) > 3 {
+			t.Fatalf("ListEntriesPage keeps returning cursors")
+		}
+		cursor = next
+	}
+	if len(pages) != 2 || !slices.Equal(slices.Concat(pages...), newestFirst) {
+		t.Errorf("pages = %v, want %v split in pages of 4", pages, newestFirst)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		filter db.EntryFilter
+		want   []string
+	}{
+		{"tag", db.EntryFilter{Tag: "Even"}, []string{ids[4], ids[2], ids[0]}},
+		{"mood min", db.EntryFilter{MoodMin: intPtr(4)}, []string{ids[4], ids[3]}},
+		{"mood range", db.EntryFilter{MoodMin: intPtr(2), MoodMax: intPtr(3)}, []string{ids[2], ids[1]}},
+		{"no match", db.EntryFilter{Tag: "missing"}, []string{}},
+	} {
+		entries, next, err := s.ListEntriesPage(alice.ID, tc.filter, 10, "")
+		if err != nil {
+			t.Fatalf("ListEntriesPage %s: %v", tc.name, err)
+		}
+		if got := entryIDs(entries); !slices.Equal(got, tc.want) || next != "" {
+			t.Errorf("ListEntriesPage %s = %v, %q, want %v and no cursor", tc.name, got, next, tc.want)
+		}
+	}
+
+	if _, _, err := s.ListEntriesPage(alice.ID, db.EntryFilter{}, 10, "not a cursor"); !errors.Is(err, db.ErrInvalidCursor) {
+		t.Errorf("ListEntriesPage with a bad cursor: err = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func testUpdateEntry(t *testing.T, s db.Store) {
+	alice := createUser(t, s, "alice")
+	bob := createUser(t, s, "bob")
+	entry := createEntry(t, s, alice.ID, "first draft #draft", intPtr(2), time.Time{})
+
+	updated, err := s.UpdateEntry(entry.ID, alice.ID, "second draft #final", db.Encryption{}, nil, intPtr(1))
+	if err != nil {
+		t.Fatalf("UpdateEntry: %v", err)
+	}
+	if updated.Body != "second draft #final" || updated.Version != 2 || !slices.Equal(updated.Tags, []string{"final"}) {
+		t.Errorf("UpdateEntry = %+v, want the new body, version 2 and tag final", updated)
+	}
+	if updated.Mood == nil || *updated.Mood != 2 {
+		t.Errorf("UpdateEntry without a mood changed it to %v, want 2", updated.Mood)
+	}
+	if updated.UpdatedAt.Before(entry.UpdatedAt) || !updated.CreatedAt.Equal(entry.CreatedAt) {
+		t.Errorf("UpdateEntry times %v/%v, want created kept and updated moved on", updated.CreatedAt, updated.UpdatedAt)
+	}
+
+	updated, err = s.UpdateEntry(entry.ID, alice.ID, "third draft", db.Encryption{}, intPtr(5), nil)
+	if err != nil {
+		t.Fatalf("UpdateEntry: %v", err)
+	}
+	if updated.Version != 3 || updated.Mood == nil || *updated.Mood != 5 {
+		t.Errorf("UpdateEntry = %+v, want version 3 and mood 5", updated)
+	}
+
+	if _, err := s.UpdateEntry(entry.ID, alice.ID, "stale", db.Encryption{}, nil, intPtr(2)); !errors.Is(err, db.ErrVersionConflict) {
+		t.Errorf("UpdateEntry from an old version: err = %v, want ErrVersionConflict", err)
+	}
+	if _, err := s.UpdateEntry(entry.ID, bob.ID, "hijack", db.Encryption{}, nil, nil); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("UpdateEntry by another user: err = %v, want ErrNotFound", err)
+	}
+	if _, err := s.UpdateEntry("999999", alice.ID, "nothing", db.Encryption{}, nil, nil); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("UpdateEntry of an unknown id: err = %v, want ErrNotFound", err)
+	}
+
+	revisions, err := s.ListEntryRevisions(entry.ID)
+	if err != nil {
+		t.Fatalf("ListEntryRevisions: %v", err)
+	}
+	var bodies []string
+	for _, revision := range revisions {
+		bodies = append(bodies, revision.Body)
+		if revision.EntryID != entry.ID {
+			t.Errorf("revision of entry %s, want %s", revision.EntryID, entry.ID)
+		}
+	}
+	if !slices.Equal(bodies, []string{"second draft #final", "first draft #draft"}) {
+		t.Errorf("revisions = %q, want the two previous bodies newest first", bodies)
+	}
+	if !revisions[1].CreatedAt.Equal(entry.UpdatedAt) {
+		t.Errorf("first revision created at %v, want %v", revisions[1].CreatedAt, entry.UpdatedAt)
+	}
+
+	got, err := s.GetEntryByID(entry.ID)
+	if err != nil || got.Body != "third draft" {
+		t.Errorf("GetEntryByID after the failed updates = %+v, %v, want the third draft", got, err)
+	}
+}
+
+func testDeleteEntry(t *testing.T, s db.Store) {
+	alice := createUser(t, s, "alice")
+	bob := createUser(t, s, "bob")
+	entry := createEntry(t, s, alice.ID, "to be deleted", nil, time.Time{})
+	if _, err := s.UpdateEntry(entry.ID, alice.ID, "edited", db.Encryption{}, nil, nil); err != nil {
+		t.Fatalf("UpdateEntry: %v", err)
+	}
+
+	if err := s.DeleteEntry(entry.ID, bob.ID); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("DeleteEntry by another user: err = %v, want ErrNotFound", err)
+	}
+	if err := s.DeleteEntry(entry.ID, alice.ID); err != nil {
+		t.Fatalf("DeleteEntry: %v", err)
+	}
+	if _, err := s.GetEntryByID(entry.ID); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("GetEntryByID after DeleteEntry: err = %v, want ErrNotFound", err)
+	}
+	if revisions, err := s.ListEntryRevisions(entry.ID); err != nil || len(revisions) != 0 {
+		t.Errorf("ListEntryRevisions after DeleteEntry = %v, %v, want none", revisions, err)
+	}
+	if err := s.DeleteEntry(entry.ID, alice.ID); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("DeleteEntry twice: err = %v, want ErrNotFound", err)
+	}
+
+	next := createEntry(t, s, alice.ID, "after", nil, time.Time{})
+	if next.ID == entry.ID {
+		t.Errorf("id %s of a deleted entry was reused", entry.ID)
+	}
+}
+
+func testEntryChanges(t *testing.T, s db.Store) {
+	alice := createUser(t, s, "alice")
+	bob := createUser(t, s, "bob")
+
+	kept := createEntry(t, s, alice.ID, "kept", nil, time.Time{})
+	gone := createEntry(t, s, alice.ID, "gone", nil, time.Time{})
+	createEntry(t, s, bob.ID, "bob's", nil, time.Time{})
+
+	entries, deleted, since, err := s.ListEntryChanges(alice.ID, time.Time{})
+	if err != nil {
+		t.Fatalf("ListEntryChanges: %v", err)
+	}
+	if got := entryIDs(entries); !slices.Equal(got, []string{kept.ID, gone.ID}) || len(deleted) != 0 {
+		t.Errorf("ListEntryChanges from the start = %v, %v, want %s and %s oldest first", got, deleted, kept.ID, gone.ID)
+	}
+	if since.After(time.Now()) {
+		t.Errorf("ListEntryChanges next since %v is in the future", since)
+	}
+
+	// Changes from before since - ChangesOverlap are not returned again.
+	time.Sleep(10 * time.Millisecond)
+	later := time.Now()
+	time.Sleep(10 * time.Millisecond)
+	if _, err := s.UpdateEntry(kept.ID, alice.ID, "kept, edited", db.Encryption{}, nil, nil); err != nil {
+		t.Fatalf("UpdateEntry: %v", err)
+	}
+	if err := s.DeleteEntry(gone.ID, alice.ID); err != nil {
+		t.Fatalf("DeleteEntry: %v", err)
+	}
+
+	entries, deleted, _, err = s.ListEntryChanges(alice.ID, later)
+	if err != nil {
+		t.Fatalf("ListEntryChanges: %v", err)
+	}
+	if got := entryIDs(entries); !slices.Equal(got, []string{kept.ID}) || entries[0].Body != "kept, edited" {
+		t.Errorf("ListEntryChanges since the edit = %+v, want only the edited entry", entries)
+	}
+	if !slices.Equal(deleted, []string{gone.ID}) {
+		t.Errorf("ListEntryChanges deleted = %v, want %s", deleted, gone.ID)
+	}
+}
+
+func testSearch(t *testing.T, s db.Store) {
+	alice := createUser(t, s, "alice")
+	bob := createUser(t, s, "bob")
+
+	garden := createEntry(t, s, alice.ID, "Spent the morning in the garden planting tomatoes", nil, time.Time{})
+	twice := createEntry(t, s, alice.ID, "garden garden garden, and more garden work", nil, time.Time{})
+	office := createEntry(t, s, alice.ID, "Long meeting at the office about budgets", nil, time.Time{})
+	createEntry(t, s, bob.ID, "bob's garden is bigger", nil, time.Time{})
+	sealed := db.Encryption{Nonce: "bm9uY2U", KeyCheck: "Y2hlY2s"}
+	if _, err := s.CreateEntry(alice.ID, "garden", sealed, nil, time.Time{}); err != nil {
+		t.Fatalf("CreateEntry encrypted: %v", err)
+	}
+
+	results, err := s.SearchEntries(alice.ID, "garden", 10)
+	if err != nil {
+		t.Fatalf("SearchEntries: %v", err)
+	}
+	var ids []string
+	for _, result := range results {
+		ids = append(ids, result.ID)
+		if !strings.Contains(result.Snippet, db.HighlightStart+"garden"+db.HighlightStop) {
+			t.Errorf("snippet %q doesn't highlight garden", result.Snippet)
+		}
+	}
+	if !slices.Equal(ids, []string{twice.ID, garden.ID}) {
+		t.Errorf("SearchEntries(garden) = %v, want alice's plaintext entries %s then %s", ids, twice.ID, garden.ID)
+	}
+	if len(results) == 2 && results[0].Rank <= results[1].Rank {
+		t.Errorf("ranks %v and %v, want the entry with more matches first", results[0].Rank, results[1].Rank)
+	}
+
+	for _, tc := range []struct {
+		q    string
+		want []string
+	}{
+		{`"planting tomatoes"`, []string{garden.ID}},
+		{`"tomatoes planting"`, []string{}},
+		{`garden -tomatoes`, []string{twice.ID}},
+		{`tomatoes or budgets`, []string{garden.ID, office.ID}},
+		{`meeting garden`, []string{}},
+	} {
+		results, err := s.SearchEntries(alice.ID, tc.q, 10)
+		if err != nil {
+			t.Fatalf("SearchEntries(%s): %v", tc.q, err)
+		}
+		got := []string{}
+		for _, result := range results {
+			got = append(got, result.ID)
+		}
+		if !slices.Equal(sorted(got), sorted(tc.want)) {
+			t.Errorf("SearchEntries(%s) = %v, want %v", tc.q, got, tc.want)
+		}
+	}
+
+	if results, err := s.SearchEntries(alice.ID, "garden", 1); err != nil || len(results) != 1 {
+		t.Errorf("SearchEntries with limit 1 = %d results, %v", len(results), err)
+	}
+}
+
+func testTags(t *testing.T, s db.Store) {
+	alice := createUser(t, s, "alice")
+	bob := createUser(t, s, "bob")
+
+	createEntry(t, s, alice.ID, "#work #gym", nil, time.Time{})
+	createEntry(t, s, alice.ID, "#work again", nil, time.Time{})
+	edited := createEntry(t, s, alice.ID, "#travel", nil, time.Time{})
+	createEntry(t, s, bob.ID, "#secret", nil, time.Time{})
+	if _, err := s.UpdateEntry(edited.ID, alice.ID, "#gym instead", db.Encryption{}, nil, nil); err != nil {
+		t.Fatalf("UpdateEntry: %v", err)
+	}
+
+	tags, err := s.ListTags(alice.ID)
+	if err != nil {
+		t.Fatalf("ListTags: %v", err)
+	}
+	want := []db.TagCount{{Name: "gym", Count: 2}, {Name: "work", Count: 2}}
+	if !slices.Equal(tags, want) {
+		t.Errorf("ListTags = %v, want %v", tags, want)
+	}
+}
+
+func testLongTags(t *testing.T, s db.Store) {
+	alice := createUser(t, s, "alice")
+
+	// Postgres keeps tags in a VARCHAR(64), so every store cuts them there.
+	long := strings.Repeat("ü", utils.MaxTagLength+6)
+	cut := strings.Repeat("ü", utils.MaxTagLength)
+	entry := createEntry(t, s, alice.ID, "#"+long, nil, time.Time{})
+	if !slices.Equal(entry.Tags, []string{cut}) {
+		t.Errorf("CreateEntry tags = %q, want %q", entry.Tags, []string{cut})
+	}
+
+	tags, err := s.ListTags(alice.ID)
+	if err != nil {
+		t.Fatalf("ListTags: %v", err)
+	}
+	want := []db.TagCount{{Name: cut, Count: 1}}
+	if !slices.Equal(tags, want) {
+		t.Errorf("ListTags = %v, want %v", tags, want)
+	}
+}
+
+func testEntryDays(t *testing.T, s db.Store) {
+	alice := createUser(t, s, "alice")
+	bob := createUser(t, s, "bob")
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("no time zone database: %v", err)
+	}
+
+	// 03:30 UTC is still the evening before in New York.
+	createEntry(t, s, alice.ID, "late", nil, time.Date(2024, 3, 10, 3, 30, 0, 0, time.UTC))
+	createEntry(t, s, alice.ID, "morning", nil, time.Date(2024, 3, 10, 15, 0, 0, 0, time.UTC))
+	createEntry(t, s, alice.ID, "afternoon", nil, time.Date(2024, 3, 10, 19, 0, 0, 0, time.UTC))
+	createEntry(t, s, alice.ID, "later", nil, time.Date(2024, 3, 12, 12, 0, 0, 0, time.UTC))
+	createEntry(t, s, bob.ID, "other user", nil, time.Date(2024, 3, 11, 12, 0, 0, 0, time.UTC))
+
+	days, err := s.ListEntryDays(alice.ID, loc)
+	if err != nil {
+		t.Fatalf("ListEntryDays: %v", err)
+	}
+	want := []time.Time{
+		time.Date(2024, 3, 12, 0, 0, 0, 0, loc),
+		time.Date(2024, 3, 10, 0, 0, 0, 0, loc),
+		time.Date(2024, 3, 9, 0, 0, 0, 0, loc),
+	}
+	if !slices.EqualFunc(days, want, time.Time.Equal) {
+		t.Errorf("ListEntryDays = %v, want %v", days, want)
+	}
+
+	if days, err := s.ListEntryDays(createUser(t, s, "carol").ID, loc); err != nil || len(days) != 0 {
+		t.Errorf("ListEntryDays without entries = %v, %v", days, err)
+	}
+}
+
+func testStats(t *testing.T, s db.Store) {
+	alice := createUser(t, s, "alice")
+	bob := createUser(t, s, "bob")
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("no time zone database: %v", err)
+	}
+
+	createEntry(t, s, alice.ID, "too old to count", intPtr(1), time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC))
+	// 03:30 UTC is 22:30 the evening before in New York.
+	createEntry(t, s, alice.ID, "late night #work", intPtr(2), time.Date(2024, 3, 10, 3, 30, 0, 0, time.UTC))
+	createEntry(t, s, alice.ID, "a  quiet\nmorning", intPtr(4), time.Date(2024, 3, 10, 13, 0, 0, 0, time.UTC))
+	createEntry(t, s, alice.ID, "no mood #work #gym", nil, time.Date(2024, 3, 10, 14, 0, 0, 0, time.UTC))
+	if _, err := s.CreateEntry(alice.ID, "c2VhbGVk", db.Encryption{Nonce: "bm9uY2U=", KeyCheck: "Y2hlY2s="}, intPtr(5), time.Date(2024, 3, 12, 13, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("CreateEntry(encrypted): %v", err)
+	}
+	createEntry(t, s, bob.ID, "other user", intPtr(3), time.Date(2024, 3, 11, 12, 0, 0, 0, time.UTC))
+
+	stats, err := s.GetStats(alice.ID, loc, time.Date(2024, 3, 1, 0, 0, 0, 0, loc))
+	if err != nil {
+		t.Fatalf("GetStats: %v", err)
+	}
+	if stats.Entries != 4 || stats.Words != 10 {
+		t.Errorf("GetStats entries, words = %d, %d, want 4, 10", stats.Entries, stats.Words)
+	}
+	if stats.MoodAverage == nil || math.Abs(*stats.MoodAverage-11.0/3) > 1e-9 {
+		t.Errorf("GetStats mood average = %v, want 3.67", stats.MoodAverage)
+	}
+
+	type day struct {
+		date           string
+		entries, words int
+		mood           float64
+	}
+	want := []day{{"2024-03-09", 1, 3, 2}, {"2024-03-10", 2, 7, 4}, {"2024-03-12", 1, 0, 5}}
+	var got []day
+	for _, d := range stats.Days {
+		if d.Mood == nil {
+			t.Errorf("GetStats day %s has no mood", d.Date)
+			continue
+		}
+		got = append(got, day{d.Date, d.Entries, d.Words, *d.Mood})
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("GetStats days = %v, want %v", got, want)
+	}
+
+	// New York is on daylight saving time from March 10.
+	wantHours := map[int]int{22: 1, 9: 2, 10: 1}
+	for hour, count := range stats.Hours {
+		if count != wantHours[hour] {
+			t.Errorf("GetStats hour %d = %d, want %d", hour, count, wantHours[hour])
+		}
+	}
+
+	wantTags := []db.TagCount{{Name: "work", Count: 2}, {Name: "gym", Count: 1}}
+	if !slices.Equal(stats.Tags, wantTags) {
+		t.Errorf("GetStats tags = %v, want %v", stats.Tags, wantTags)
+	}
+
+	stats, err = s.GetStats(createUser(t, s, "carol").ID, loc, time.Time{})
+	if err != nil || stats.Entries != 0 || len(stats.Days) != 0 || stats.MoodAverage != nil || len(stats.Tags) != 0 {
+		t.Errorf("GetStats without entries = %+v, %v", stats, err)
+	}
+}
+
+func testKeys(t *testing.T, s db.Store) {
+	alice := createUser(t, s, "alice")
+
+	if _, err := s.GetUserKey(alice.ID); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("GetUserKey before PutUserKey: err = %v, want ErrNotFound", err)
+	}
+
+	key := db.UserKey{KDF: "argon2id", KDFParams: "t=3", Salt: "c2FsdA", WrappedKey: "a2V5", WrapNonce: "bm9uY2U", KeyCheck: "Y2hlY2s"}
+	stored, err := s.PutUserKey(alice.ID, key)
+	if err != nil {
+		t.Fatalf("PutUserKey: %v", err)
+	}
+	if stored.UpdatedAt.IsZero() {
+		t.Errorf("PutUserKey updated at is zero")
+	}
+
+	key.Salt, key.WrappedKey = "bmV3LXNhbHQ", "bmV3LWtleQ"
+	if _, err := s.PutUserKey(alice.ID, key); err != nil {
+		t.Fatalf("PutUserKey again: %v", err)
+	}
+
+	got, err := s.GetUserKey(alice.ID)
+	if err != nil {
+		t.Fatalf("GetUserKey: %v", err)
+	}
+	got.UpdatedAt = time.Time{}
+	if *got != key {
+		t.Errorf("GetUserKey = %+v, want %+v", got, key)
+	}
+}
+
+func testSettings(t *testing.T, s db.Store) {
+	alice := createUser(t, s, "alice")
+	bob := createUser(t, s, "bob")
+
+	if _, err := s.GetUserSettings(alice.ID); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("GetUserSettings before PutUserSettings: err = %v, want ErrNotFound", err)
+	}
+
+	settings := db.DefaultUserSettings()
+	stored, err := s.PutUserSettings(alice.ID, settings)
+	if err != nil {
+		t.Fatalf("PutUserSettings: %v", err)
+	}
+	if stored.UpdatedAt.IsZero() {
+		t.Errorf("PutUserSettings updated at is zero")
+	}
+
+	settings.EditorMode, settings.TimeZone, settings.ReminderTime, settings.LineNumbers = db.EditorVim, "Europe/Berlin", "21:30", false
+	if _, err := s.PutUserSettings(alice.ID, settings); err != nil {
+		t.Fatalf("PutUserSettings again: %v", err)
+	}
+	if _, err := s.PutUserSettings(bob.ID, db.DefaultUserSettings()); err != nil {
+		t.Fatalf("PutUserSettings(bob): %v", err)
+	}
+
+	got, err := s.GetUserSettings(alice.ID)
+	if err != nil {
+		t.Fatalf("GetUserSettings: %v", err)
+	}
+	got.UpdatedAt = time.Time{}
+	if *got != settings {
+		t.Errorf("GetUserSettings = %+v, want %+v", got, settings)
+	}
+}
@@ -0,0 +1,34 @@
+package db
+
+import (
+	"database/sql"
+)
+
+type TagCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// ListTags returns the tags a user has used, most used first.
+func ListTags(db *sql.DB, userID string) ([]TagCount, error) {
+	query := `SELECT t.name, COUNT(et.entry_id) FROM tags t
+		JOIN entry_tags et ON et.tag_id = t.id
+		WHERE t.user_id = $1
+		GROUP BY t.name
+		ORDER BY COUNT(et.entry_id) DESC, t.name`
+	rows, err := db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []TagCount{}
+	for rows.Next() {
+		var tag TagCount
+		if err := rows.Scan(&tag.Name, &tag.Count); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
@@ -2,21 +2,38 @@ package db
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"strconv"
+
+	"github.com/lib/pq"
+)
+
+// Errors returned by CreateUser when the email or username belongs to another
+// user.
+var (
+	ErrEmailTaken    = errors.New("email already registered")
+	ErrUsernameTaken = errors.New("username already taken")
 )
 
 type User struct {
 	ID            string `json:"id"`
 	Email         string `json:"email"`
 	Username      string `json:"username"`
-	Password_hash string `json:"password_hash"`
+	Password_hash string `json:"-"`
 }
 
 func CreateUser(db *sql.DB, username, email, password_hash string) (*User, error) {
 	var id int
 	query := `INSERT INTO users (username, email, password_hash) VALUES ($1, $2, $3) RETURNING id`
 	err := db.QueryRow(query, username, email, password_hash).Scan(&id)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		if pqErr.Constraint == "users_email_key" {
+			return nil, ErrEmailTaken
+		}
+		return nil, ErrUsernameTaken
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to insert user: %w", err)
 	}
@@ -41,8 +58,8 @@ func GetUserByEmail(db *sql.DB, email string) (*User, error) {
 
 func GetUserByID(db *sql.DB, id string) (*User, error) {
 	var user User
-	query := `SELECT username, email FROM users WHERE id = $1`
-	err := db.QueryRow(query, id).Scan(&user.Username, &user.Email)
+	query := `SELECT id, username, email FROM users WHERE id = $1`
+	err := db.QueryRow(query, id).Scan(&user.ID, &user.Username, &user.Email)
 	if err != nil {
 		return nil, err
 	}
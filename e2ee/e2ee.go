@@ -0,0 +1,226 @@
+// Package e2ee implements the client side of end-to-end encrypted entries.
+//
+// Entries are sealed with a random 256-bit data key using XChaCha20-Poly1305.
+// The data key never leaves the client unwrapped: it is stored on the server
+// in a KeyEnvelope, sealed with a key derived from the user's passphrase with
+// Argon2id. Changing the passphrase only re-wraps the data key, so existing
+// entries don't have to be re-encrypted.
+package e2ee
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+const kdfArgon2id = "argon2id"
+
+// Argon2id parameters for new envelopes, following the RFC 9106 second
+// recommended option.
+const (
+	argonTime    = 3
+	argonMemory  = 64 * 1024
+	argonThreads = 4
+	saltSize     = 16
+
+	// maxArgonMemory bounds the memory, in KiB, an envelope can make Unwrap
+	// spend, so a hostile server can't exhaust the client's: 1 GiB.
+	maxArgonMemory = 1024 * 1024
+)
+
+var (
+	ErrWrongPassphrase = errors.New("wrong passphrase")
+	ErrWrongKey        = errors.New("entry was sealed with a different key")
+	ErrUnsupportedKDF  = errors.New("unsupported key derivation function")
+	ErrInvalidNonce    = errors.New("invalid nonce")
+)
+
+// KeyEnvelope is what the server stores for a user. Binary fields are base64.
+type KeyEnvelope struct {
+	KDF        string `json:"kdf"`
+	KDFParams  string `json:"kdf_params"`
+	Salt       string `json:"salt"`
+	WrappedKey string `json:"wrapped_key"`
+	WrapNonce  string `json:"wrap_nonce"`
+	KeyCheck   string `json:"key_check"`
+}
+
+// Key is an unwrapped data key.
+type Key struct {
+	raw []byte
+}
+
+// Check identifies the key without revealing it, so clients and the server
+// can tell which key sealed an entry.
+func (k Key) Check() string {
+	mac := hmac.New(sha256.New, k.raw)
+	mac.Write([]byte("journalcli key check"))
+	return hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+func encode(b []byte) string {
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func decode(s string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(s)
+}
+
+// CheckNonce reports whether nonce is a base64 nonce of the size Seal and
+// Wrap make, returning it decoded.
+func CheckNonce(nonce string) ([]byte, error) {
+	n, err := decode(nonce)
+	if err != nil || len(n) != chacha20poly1305.NonceSizeX {
+		return nil, ErrInvalidNonce
+	}
+	return n, nil
+}
+
+func random(n int) []byte {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		panic(fmt.Sprintf("e2ee: reading random bytes: %v", err))
+	}
+	return buf
+}
+
+type argonParams struct {
+	time    uint32
+	memory  uint32
+	threads uint8
+}
+
+func (p argonParams) String() string {
+	return fmt.Sprintf("t=%d,m=%d,p=%d", p.time, p.memory, p.threads)
+}
+
+func parseArgonParams(s string) (argonParams, error) {
+	var p argonParams
+	if _, err := fmt.Sscanf(s, "t=%d,m=%d,p=%d", &p.time, &p.memory, &p.threads); err != nil {
+		return p, fmt.Errorf("invalid kdf params %q: %w", s, err)
+	}
+	if p.time < 1 || p.threads < 1 || p.memory > maxArgonMemory {
+		return p, fmt.Errorf("invalid kdf params %q: out of range", s)
+	}
+	return p, nil
+}
+
+func deriveKEK(passphrase string, salt []byte, p argonParams) []byte {
+	return argon2.IDKey([]byte(passphrase), salt, p.time, p.memory, p.threads, chacha20poly1305.KeySize)
+}
+
+// NewKey generates a data key and wraps it with passphrase.
+func NewKey(passphrase string) (Key, KeyEnvelope, error) {
+	key := Key{raw: random(chacha20poly1305.KeySize)}
+	envelope, err := Wrap(key, passphrase)
+	return key, envelope, err
+}
+
+// Wrap seals the data key with a fresh key derived from passphrase.
+func Wrap(key Key, passphrase string) (KeyEnvelope, error) {
+	params := argonParams{time: argonTime, memory: argonMemory, threads: argonThreads}
+	salt := random(saltSize)
+
+	aead, err := chacha20poly1305.NewX(deriveKEK(passphrase, salt, params))
+	if err != nil {
+		return KeyEnvelope{}, err
+	}
+	nonce := random(aead.NonceSize())
+
+	return KeyEnvelope{
+		KDF:        kdfArgon2id,
+		KDFParams:  params.String(),
+		Salt:       encode(salt),
+		WrappedKey: encode(aead.Seal(nil, nonce, key.raw, []byte(kdfArgon2id))),
+		WrapNonce:  encode(nonce),
+		KeyCheck:   key.Check(),
+	}, nil
+}
+
+// Unwrap recovers the data key of an envelope.
+func Unwrap(envelope KeyEnvelope, passphrase string) (Key, error) {
+	if envelope.KDF != kdfArgon2id {
+		return Key{}, ErrUnsupportedKDF
+	}
+	params, err := parseArgonParams(envelope.KDFParams)
+	if err != nil {
+		return Key{}, err
+	}
+	salt, err := decode(envelope.Salt)
+	if err != nil {
+		return Key{}, err
+	}
+	wrapped, err := decode(envelope.WrappedKey)
+	if err != nil {
+		return Key{}, err
+	}
+	nonce, err := CheckNonce(envelope.WrapNonce)
+	if err != nil {
+		return Key{}, err
+	}
+
+	aead, err := chacha20poly1305.NewX(deriveKEK(passphrase, salt, params))
+	if err != nil {
+		return Key{}, err
+	}
+	raw, err := aead.Open(nil, nonce, wrapped, []byte(kdfArgon2id))
+	if err != nil {
+		return Key{}, ErrWrongPassphrase
+	}
+
+	key := Key{raw: raw}
+	if key.Check() != envelope.KeyCheck {
+		return Key{}, ErrWrongPassphrase
+	}
+	return key, nil
+}
+
+// Rewrap changes the passphrase of an envelope, keeping its data key.
+func Rewrap(envelope KeyEnvelope, oldPassphrase, newPassphrase string) (KeyEnvelope, error) {
+	key, err := Unwrap(envelope, oldPassphrase)
+	if err != nil {
+		return KeyEnvelope{}, err
+	}
+	return Wrap(key, newPassphrase)
+}
+
+// Seal encrypts an entry body, returning the base64 ciphertext and nonce.
+func (k Key) Seal(plaintext string) (ciphertext, nonce string, err error) {
+	aead, err := chacha20poly1305.NewX(k.raw)
+	if err != nil {
+		return "", "", err
+	}
+	n := random(aead.NonceSize())
+	return encode(aead.Seal(nil, n, []byte(plaintext), nil)), encode(n), nil
+}
+
+// Open decrypts an entry body sealed with Seal.
+func (k Key) Open(ciphertext, nonce, keyCheck string) (string, error) {
+	if keyCheck != k.Check() {
+		return "", ErrWrongKey
+	}
+	sealed, err := decode(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	n, err := CheckNonce(nonce)
+	if err != nil {
+		return "", err
+	}
+	aead, err := chacha20poly1305.NewX(k.raw)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := aead.Open(nil, n, sealed, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt entry: %w", err)
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,144 @@
+package e2ee
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSealOpen(t *testing.T) {
+	key, envelope, err := NewKey("correct horse")
+	if err != nil {
+		t.Fatalf("NewKey: %v", err)
+	}
+	if envelope.KeyCheck != key.Check() {
+		t.Errorf("envelope key check = %q, want %q", envelope.KeyCheck, key.Check())
+	}
+
+	ciphertext, nonce, err := key.Seal("Dear diary")
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if strings.Contains(ciphertext, "diary") {
+		t.Errorf("ciphertext %q holds the plaintext", ciphertext)
+	}
+	plaintext, err := key.Open(ciphertext, nonce, key.Check())
+	if err != nil || plaintext != "Dear diary" {
+		t.Errorf("Open = %q, %v, want %q", plaintext, err, "Dear diary")
+	}
+
+	other, _, err := NewKey("other")
+	if err != nil {
+		t.Fatalf("NewKey: %v", err)
+	}
+	if _, err := other.Open(ciphertext, nonce, key.Check()); !errors.Is(err, ErrWrongKey) {
+		t.Errorf("Open with another key: err = %v, want ErrWrongKey", err)
+	}
+}
+
+func TestUnwrap(t *testing.T) {
+	key, envelope, err := NewKey("correct horse")
+	if err != nil {
+		t.Fatalf("NewKey: %v", err)
+	}
+
+	unwrapped, err := Unwrap(envelope, "correct horse")
+	if err != nil || unwrapped.Check() != key.Check() {
+		t.Errorf("Unwrap = %v, %v, want the data key", unwrapped.Check(), err)
+	}
+	if _, err := Unwrap(envelope, "battery staple"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Unwrap with the wrong passphrase: err = %v, want ErrWrongPassphrase", err)
+	}
+
+	wrongCheck := envelope
+	wrongCheck.KeyCheck = "0123456789abcdef"
+	if _, err := Unwrap(wrongCheck, "correct horse"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Unwrap with the wrong key check: err = %v, want ErrWrongPassphrase", err)
+	}
+}
+
+func TestRewrap(t *testing.T) {
+	key, envelope, err := NewKey("old")
+	if err != nil {
+		t.Fatalf("NewKey: %v", err)
+	}
+	ciphertext, nonce, err := key.Seal("written before")
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+
+	rewrapped, err := Rewrap(envelope, "old", "new")
+	if err != nil {
+		t.Fatalf("Rewrap: %v", err)
+	}
+	if rewrapped.Salt == envelope.Salt || rewrapped.WrappedKey == envelope.WrappedKey {
+		t.Error("Rewrap reused the salt or wrapping of the old envelope")
+	}
+	if _, err := Unwrap(rewrapped, "old"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Unwrap with the old passphrase: err = %v, want ErrWrongPassphrase", err)
+	}
+
+	// Entries sealed before still open with the data key of the new envelope.
+	unwrapped, err := Unwrap(rewrapped, "new")
+	if err != nil {
+		t.Fatalf("Unwrap with the new passphrase: %v", err)
+	}
+	if plaintext, err := unwrapped.Open(ciphertext, nonce, rewrapped.KeyCheck); err != nil || plaintext != "written before" {
+		t.Errorf("Open after Rewrap = %q, %v, want %q", plaintext, err, "written before")
+	}
+
+	if _, err := Rewrap(envelope, "wrong", "new"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Rewrap with the wrong passphrase: err = %v, want ErrWrongPassphrase", err)
+	}
+}
+
+func TestMalformed(t *testing.T) {
+	key, envelope, err := NewKey("correct horse")
+	if err != nil {
+		t.Fatalf("NewKey: %v", err)
+	}
+	ciphertext, nonce, err := key.Seal("Dear diary")
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+
+	nonces := map[string]string{
+		"short":      encode([]byte("12345")),
+		"not base64": "!!!",
+		"empty":      "",
+	}
+	for name, bad := range nonces {
+		if _, err := key.Open(ciphertext, bad, key.Check()); !errors.Is(err, ErrInvalidNonce) {
+			t.Errorf("Open with a %s nonce: err = %v, want ErrInvalidNonce", name, err)
+		}
+		badEnvelope := envelope
+		badEnvelope.WrapNonce = bad
+		if _, err := Unwrap(badEnvelope, "correct horse"); !errors.Is(err, ErrInvalidNonce) {
+			t.Errorf("Unwrap with a %s nonce: err = %v, want ErrInvalidNonce", name, err)
+		}
+	}
+	if _, err := key.Open("!!!", nonce, key.Check()); err == nil {
+		t.Error("Open of ciphertext that isn't base64: err = nil")
+	}
+	if _, err := key.Open(encode([]byte("tampered")), nonce, key.Check()); err == nil {
+		t.Error("Open of tampered ciphertext: err = nil")
+	}
+
+	envelopes := map[string]func(*KeyEnvelope){
+		"kdf":           func(e *KeyEnvelope) { e.KDF = "scrypt" },
+		"no rounds":     func(e *KeyEnvelope) { e.KDFParams = "t=0,m=65536,p=0" },
+		"no threads":    func(e *KeyEnvelope) { e.KDFParams = "t=3,m=65536,p=0" },
+		"huge memory":   func(e *KeyEnvelope) { e.KDFParams = "t=3,m=4294967295,p=4" },
+		"params":        func(e *KeyEnvelope) { e.KDFParams = "fast please" },
+		"salt":          func(e *KeyEnvelope) { e.Salt = "!!!" },
+		"wrapped key":   func(e *KeyEnvelope) { e.WrappedKey = "!!!" },
+		"truncated key": func(e *KeyEnvelope) { e.WrappedKey = e.WrappedKey[:8] },
+	}
+	for name, spoil := range envelopes {
+		bad := envelope
+		spoil(&bad)
+		if _, err := Unwrap(bad, "correct horse"); err == nil {
+			t.Errorf("Unwrap with a bad %s: err = nil", name)
+		}
+	}
+}
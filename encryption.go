@@ -0,0 +1,321 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"journalCli/client"
+	"journalCli/e2ee"
+	"sync"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var errVaultLocked = errors.New("journal is encrypted, unlock it in Settings first")
+
+// lockedEntryBody replaces the body of encrypted entries that can't be opened.
+const lockedEntryBody = "🔒 Encrypted entry, unlock your journal in Settings to read it"
+
+// vault holds the end-to-end encryption state of the logged-in user. Like
+// the API client, it is shared by pointer so commands see the latest state.
+type vault struct {
+	mu       sync.RWMutex
+	envelope *e2ee.KeyEnvelope
+	key      *e2ee.Key
+}
+
+func (v *vault) SetEnvelope(envelope *e2ee.KeyEnvelope) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.envelope = envelope
+	if envelope == nil || (v.key != nil && v.key.Check() != envelope.KeyCheck) {
+		v.key = nil
+	}
+}
+
+func (v *vault) Envelope() *e2ee.KeyEnvelope {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.envelope
+}
+
+func (v *vault) SetKey(key *e2ee.Key) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.key = key
+}
+
+func (v *vault) Enabled() bool {
+	return v.Envelope() != nil
+}
+
+func (v *vault) Unlocked() bool {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.key != nil
+}
+
+// seal encrypts a body when encryption is enabled, and leaves it as is
+// otherwise.
+func (v *vault) seal(body string) (string, client.Encryption, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if v.envelope == nil {
+		return body, client.Encryption{}, nil
+	}
+	if v.key == nil {
+		return "", client.Encryption{}, errVaultLocked
+	}
+	ciphertext, nonce, err := v.key.Seal(body)
+	if err != nil {
+		return "", client.Encryption{}, err
+	}
+	return ciphertext, client.Encryption{Nonce: nonce, KeyCheck: v.key.Check()}, nil
+}
+
+// open decrypts an encrypted body, reporting whether it could.
+func (v *vault) open(body string, enc client.Encryption) (string, bool) {
+	if enc.Nonce == "" {
+		return body, true
+	}
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if v.key == nil {
+		return lockedEntryBody, false
+	}
+	plaintext, err := v.key.Open(body, enc.Nonce, enc.KeyCheck)
+	if err != nil {
+		return lockedEntryBody, false
+	}
+	return plaintext, true
+}
+
+func (v *vault) openEntry(entry *client.Entry) {
+	var ok bool
+	entry.Body, ok = v.open(entry.Body, entry.Encryption)
+	entry.Locked = !ok
+}
+
+type cryptoPrompt int
+
+const (
+	cryptoNone cryptoPrompt = iota
+	cryptoEnable
+	cryptoUnlock
+	cryptoChange
+)
+
+type KeyLoadedMsg struct {
+	Envelope *e2ee.KeyEnvelope
+}
+
+type EncryptionEnabledMsg struct{}
+
+type VaultUnlockedMsg struct{}
+
+type PassphraseChangedMsg struct{}
+
+func newPassphraseInput(placeholder string) textinput.Model {
+	input := textinput.New()
+	input.Placeholder = placeholder
+	input.EchoMode = textinput.EchoPassword
+	input.EchoCharacter = '•'
+	input.CharLimit = 128
+	input.Width = 30
+	return input
+}
+
+func fetchKey(api *client.Client) tea.Msg {
+	envelope, err := api.GetKey(context.Background())
+	if errors.Is(err, client.ErrEncryptionDisabled) {
+		return KeyLoadedMsg{}
+	}
+	if err != nil {
+		return ErrMsg{err}
+	}
+	return KeyLoadedMsg{Envelope: envelope}
+}
+
+func enableEncryption(passphrase string, v *vault, api *client.Client) tea.Msg {
+	key, envelope, err := e2ee.NewKey(passphrase)
+	if err != nil {
+		return ErrMsg{err}
+	}
+	if err := api.PutKey(context.Background(), envelope); err != nil {
+		return ErrMsg{err}
+	}
+	v.SetEnvelope(&envelope)
+	v.SetKey(&key)
+	return EncryptionEnabledMsg{}
+}
+
+func unlockVault(passphrase string, v *vault) tea.Msg {
+	envelope := v.Envelope()
+	if envelope == nil {
+		return ErrMsg{fmt.Errorf("Encryption is not set up")}
+	}
+	key, err := e2ee.Unwrap(*envelope, passphrase)
+	if err != nil {
+		return ErrMsg{err}
+	}
+	v.SetKey(&key)
+	return VaultUnlockedMsg{}
+}
+
+// changePassphrase re-wraps the data key with a new passphrase. Entries keep
+// being sealed with the same data key, so none of them are re-encrypted.
+func changePassphrase(oldPassphrase, newPassphrase string, v *vault, api *client.Client) tea.Msg {
+	envelope := v.Envelope()
+	if envelope == nil {
+		return ErrMsg{fmt.Errorf("Encryption is not set up")}
+	}
+	rewrapped, err := e2ee.Rewrap(*envelope, oldPassphrase, newPassphrase)
+	if err != nil {
+		return ErrMsg{err}
+	}
+	if err := api.PutKey(context.Background(), rewrapped); err != nil {
+		return ErrMsg{err}
+	}
+	v.SetEnvelope(&rewrapped)
+	return PassphraseChangedMsg{}
+}
+
+// cryptoInputs returns the passphrase inputs used by the current prompt.
+func (m *Model) cryptoInputs() []*textinput.Model {
+	switch m.cryptoPrompt {
+	case cryptoEnable:
+		return []*textinput.Model{&m.newPassphrase, &m.confirmPassphrase}
+	case cryptoUnlock:
+		return []*textinput.Model{&m.passphrase}
+	case cryptoChange:
+		return []*textinput.Model{&m.passphrase, &m.newPassphrase, &m.confirmPassphrase}
+	}
+	return nil
+}
+
+func (m *Model) focusCryptoInput() tea.Cmd {
+	var cmd tea.Cmd
+	for i, input := range m.cryptoInputs() {
+		if i == m.cryptoFocus {
+			cmd = input.Focus()
+		} else {
+			input.Blur()
+		}
+	}
+	return cmd
+}
+
+func (m *Model) openCryptoPrompt(prompt cryptoPrompt) tea.Cmd {
+	m.cryptoPrompt = prompt
+	m.cryptoFocus = 0
+	m.err = nil
+	m.msg = ""
+	m.passphrase.SetValue("")
+	m.newPassphrase.SetValue("")
+	m.confirmPassphrase.SetValue("")
+	return m.focusCryptoInput()
+}
+
+func (m Model) submitCryptoPrompt() (Model, tea.Cmd) {
+	passphrase := m.passphrase.Value()
+	newPassphrase := m.newPassphrase.Value()
+
+	if m.cryptoPrompt != cryptoUnlock {
+		if len(newPassphrase) < 8 {
+			m.err = fmt.Errorf("Passphrase too short, please use at least 8 characters")
+			return m, nil
+		}
+		if newPassphrase != m.confirmPassphrase.Value() {
+			m.err = fmt.Errorf("Passphrases do not match")
+			return m, nil
+		}
+	}
+
+	prompt := m.cryptoPrompt
+	m.cryptoPrompt = cryptoNone
+	m.msg = "Working..."
+	v, api := m.vault, m.api
+
+	switch prompt {
+	case cryptoEnable:
+		return m, func() tea.Msg { return enableEncryption(newPassphrase, v, api) }
+	case cryptoUnlock:
+		return m, func() tea.Msg { return unlockVault(passphrase, v) }
+	default:
+		return m, func() tea.Msg { return changePassphrase(passphrase, newPassphrase, v, api) }
+	}
+}
+
+func (m Model) updateCryptoPrompt(msg tea.KeyMsg) (Model, tea.Cmd) {
+	inputs := m.cryptoInputs()
+
+	switch {
+	case key.Matches(msg, cryptoKeys.Cancel):
+		m.cryptoPrompt = cryptoNone
+		m.err = nil
+		return m, nil
+	case key.Matches(msg, cryptoKeys.NextField):
+		m.cryptoFocus = (m.cryptoFocus + 1) % len(inputs)
+		return m, m.focusCryptoInput()
+	case key.Matches(msg, cryptoKeys.PrevField):
+		m.cryptoFocus = (m.cryptoFocus + len(inputs) - 1) % len(inputs)
+		return m, m.focusCryptoInput()
+	case key.Matches(msg, cryptoKeys.Submit):
+		if m.cryptoFocus < len(inputs)-1 {
+			m.cryptoFocus++
+			return m, m.focusCryptoInput()
+		}
+		return m.submitCryptoPrompt()
+	case key.Matches(msg, quitKey):
+		return m, tea.Quit
+	}
+
+	var cmd tea.Cmd
+	input := inputs[m.cryptoFocus]
+	*input, cmd = input.Update(msg)
+	return m, cmd
+}
+
+// encryptionStatus is one line describing the encryption state for Settings.
+func encryptionStatus(m Model) string {
+	switch {
+	case !m.vault.Enabled():
+		return "🔓 End-to-end encryption: off"
+	case m.vault.Unlocked():
+		return "🔐 End-to-end encryption: on, unlocked"
+	default:
+		return "🔒 End-to-end encryption: on, locked"
+	}
+}
+
+func renderCryptoPrompt(m Model) string {
+	titles := map[cryptoPrompt]string{
+		cryptoEnable: "🔐 Enable End-to-End Encryption",
+		cryptoUnlock: "🔒 Unlock Your Journal",
+		cryptoChange: "🔑 Change Passphrase",
+	}
+
+	rows := []string{titleStyle.Render(titles[m.cryptoPrompt])}
+	for i, input := range m.cryptoInputs() {
+		style := inputBoxStyle
+		if i == m.cryptoFocus {
+			style = focusedInputStyle
+		}
+		rows = append(rows, style.Render(input.View()))
+	}
+	if m.cryptoPrompt == cryptoEnable {
+		rows = append(rows, lipgloss.NewStyle().Italic(true).Width(50).Align(lipgloss.Center).Render(
+			"New entries will be encrypted before they leave this device. If you forget the passphrase they can't be recovered."))
+	}
+	rows = append(rows, "", keyHints(cryptoKeyMap, m.width))
+
+	if m.err != nil {
+		rows = append(rows, errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
+	}
+
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center,
+		lipgloss.JoinVertical(lipgloss.Center, rows...))
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"journalCli/client"
+	"journalCli/export"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type ExportDoneMsg struct {
+	Count int
+	Path  string
+}
+
+var exportFormatNames = map[export.Format]string{
+	export.Markdown: "Markdown",
+	export.JSON:     "JSON",
+	export.JSONL:    "JSON Lines",
+	export.HTML:     "HTML site",
+}
+
+// exportJournal pages through every entry, newest first, and adds it to exp
+// after open decrypted the page. It returns how many entries were exported;
+// exp still has to be closed.
+func exportJournal(ctx context.Context, api *client.Client, exp export.Exporter, open func([]client.Entry) error) (int, error) {
+	count := 0
+	opts := client.ListEntriesOptions{Limit: 100}
+	for {
+		page, err := api.ListEntries(ctx, opts)
+		if err != nil {
+			return count, err
+		}
+		if err := open(page.Entries); err != nil {
+			return count, err
+		}
+		for _, entry := range page.Entries {
+			if err := exp.Add(entry); err != nil {
+				return count, err
+			}
+			count++
+		}
+		if page.NextCursor == "" {
+			return count, nil
+		}
+		opts.Cursor = page.NextCursor
+	}
+}
+
+// defaultExportPath is where exports go unless the user picks another path,
+// e.g. ~/journalcli-export-2024-03-01.json.
+func defaultExportPath(format export.Format, now time.Time) string {
+	name := "journalcli-export-" + now.Format(time.DateOnly)
+	if !format.Dir() {
+		name += "." + string(format)
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, name)
+	}
+	return name
+}
+
+// expandHome replaces a leading ~ in path with the home directory.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+func runExport(format export.Format, path string, loc *time.Location, v *vault, api *client.Client) tea.Msg {
+	exp, err := export.Create(format, path, loc)
+	if err != nil {
+		return ErrMsg{err}
+	}
+	count, err := exportJournal(context.Background(), api, exp, func(entries []client.Entry) error {
+		for i := range entries {
+			v.openEntry(&entries[i])
+		}
+		return nil
+	})
+	err = errors.Join(err, exp.Close())
+	if errors.Is(err, export.ErrLocked) {
+		return ErrMsg{fmt.Errorf("Unlock the journal to export encrypted entries")}
+	}
+	if err != nil {
+		return ErrMsg{err}
+	}
+	return ExportDoneMsg{Count: count, Path: path}
+}
+
+func newExportPathInput() textinput.Model {
+	input := textinput.New()
+	input.Placeholder = "Export to"
+	input.CharLimit = 256
+	input.Width = 50
+	return input
+}
+
+func (m *Model) openExportPrompt() tea.Cmd {
+	m.exportOpen = true
+	m.err = nil
+	m.msg = ""
+	m.exportPath.SetValue(defaultExportPath(export.Formats[m.exportFormat], m.currentTime.In(m.location)))
+	m.exportPath.CursorEnd()
+	return m.exportPath.Focus()
+}
+
+func (m Model) updateExportPrompt(msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, exportKeys.Cancel):
+		m.exportOpen = false
+		m.err = nil
+		return m, nil
+	case key.Matches(msg, exportKeys.NextFormat, exportKeys.PrevFormat):
+		// Keep a path the user typed, but follow the format with the default.
+		wasDefault := m.exportPath.Value() == defaultExportPath(export.Formats[m.exportFormat], m.currentTime.In(m.location))
+		step := 1
+		if key.Matches(msg, exportKeys.PrevFormat) {
+			step = len(export.Formats) - 1
+		}
+		m.exportFormat = (m.exportFormat + step) % len(export.Formats)
+		if wasDefault {
+			m.exportPath.SetValue(defaultExportPath(export.Formats[m.exportFormat], m.currentTime.In(m.location)))
+			m.exportPath.CursorEnd()
+		}
+		return m, nil
+	case key.Matches(msg, exportKeys.Export):
+		path := expandHome(strings.TrimSpace(m.exportPath.Value()))
+		if path == "" {
+			m.err = fmt.Errorf("Please enter where to export to")
+			return m, nil
+		}
+		m.exportOpen = false
+		m.err = nil
+		m.msg = "Exporting..."
+		format, loc, v, api := export.Formats[m.exportFormat], m.location, m.vault, m.api
+		return m, func() tea.Msg { return runExport(format, path, loc, v, api) }
+	case key.Matches(msg, quitKey):
+		return m, tea.Quit
+	}
+
+	var cmd tea.Cmd
+	m.exportPath, cmd = m.exportPath.Update(msg)
+	return m, cmd
+}
+
+func renderExportPrompt(m Model) string {
+	formats := make([]string, len(export.Formats))
+	for i, format := range export.Formats {
+		if i == m.exportFormat {
+			formats[i] = selectedStyle.Render("[" + exportFormatNames[format] + "]")
+		} else {
+			formats[i] = " " + exportFormatNames[format] + " "
+		}
+	}
+
+	destination := "File"
+	if export.Formats[m.exportFormat].Dir() {
+		destination = "Directory"
+	}
+
+	rows := []string{
+		titleStyle.Render("📦 Export Journal"),
+		"Format: " + strings.Join(formats, " "),
+		destination + ":",
+		focusedInputStyle.Width(54).Render(m.exportPath.View()),
+		keyHints(exportKeyMap, m.width),
+	}
+	if m.err != nil {
+		rows = append(rows, errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
+	}
+
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center,
+		lipgloss.JoinVertical(lipgloss.Center, rows...))
+}
@@ -0,0 +1,138 @@
+// Package export writes a journal out in formats that don't need journalCli
+// to be read: a Markdown file per day, a JSON or JSON Lines dump, or a static
+// HTML site.
+//
+// Exporters are given entries one at a time, newest first as the server lists
+// them, and hold on to a day of entries at most, so a journal doesn't have to
+// fit in memory to be exported.
+package export
+
+import (
+	"errors"
+	"fmt"
+	"journalCli/client"
+	"os"
+	"strings"
+	"time"
+)
+
+type Format string
+
+const (
+	Markdown Format = "markdown"
+	JSON     Format = "json"
+	JSONL    Format = "jsonl"
+	HTML     Format = "html"
+)
+
+var Formats = []Format{Markdown, JSON, JSONL, HTML}
+
+func ParseFormat(value string) (Format, error) {
+	for _, format := range Formats {
+		if strings.EqualFold(value, string(format)) {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unknown export format %q, expected one of markdown, json, jsonl or html", value)
+}
+
+// Dir reports whether the format is written to a directory rather than to a
+// single file.
+func (f Format) Dir() bool {
+	return f == Markdown || f == HTML
+}
+
+var (
+	ErrLocked   = errors.New("entry is encrypted and the journal is locked")
+	ErrUnsorted = errors.New("entries must be exported newest first")
+)
+
+// Exporter writes entries added newest first. Close finishes the export and
+// must be called even when Add fails.
+type Exporter interface {
+	Add(entry client.Entry) error
+	Close() error
+}
+
+// Create starts an export to path, a directory for Markdown and HTML and a
+// file otherwise. Days and months are those of the entries in loc.
+func Create(format Format, path string, loc *time.Location) (Exporter, error) {
+	switch format {
+	case Markdown:
+		return NewMarkdown(path, loc)
+	case HTML:
+		return NewHTML(path, loc)
+	case JSON, JSONL:
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, err
+		}
+		if format == JSON {
+			return closer{NewJSON(f), f}, nil
+		}
+		return closer{NewJSONL(f), f}, nil
+	}
+	return nil, fmt.Errorf("unknown export format %q", format)
+}
+
+// closer closes the file an exporter writes to once it is done.
+type closer struct {
+	Exporter
+	f *os.File
+}
+
+func (c closer) Close() error {
+	return errors.Join(c.Exporter.Close(), c.f.Close())
+}
+
+// Entry is an entry as it is exported, without what only matters to the
+// server such as its version or how it was encrypted.
+type Entry struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Mood      *int      `json:"mood,omitempty"`
+	Tags      []string  `json:"tags"`
+	Body      string    `json:"body"`
+}
+
+func newEntry(entry client.Entry) (Entry, error) {
+	if entry.Locked {
+		return Entry{}, fmt.Errorf("entry %s: %w", entry.ID, ErrLocked)
+	}
+	tags := entry.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return Entry{
+		ID:        entry.ID,
+		CreatedAt: entry.CreatedAt,
+		UpdatedAt: entry.UpdatedAt,
+		Mood:      entry.Mood,
+		Tags:      tags,
+		Body:      entry.Body,
+	}, nil
+}
+
+// period groups entries by a layout of their creation time, e.g. by day with
+// time.DateOnly, and checks that they come newest first.
+type period struct {
+	layout  string
+	loc     *time.Location
+	current string
+	last    time.Time
+}
+
+// next returns the period of t and whether it starts a new one.
+func (p *period) next(t time.Time) (string, bool, error) {
+	if !p.last.IsZero() && t.After(p.last) {
+		return "", false, ErrUnsorted
+	}
+	p.last = t
+	key := t.In(p.loc).Format(p.layout)
+	if key == p.current {
+		return key, false, nil
+	}
+	p.current = key
+	return key, true, nil
+}
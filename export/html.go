@@ -0,0 +1,191 @@
+package export
+
+import (
+	"bufio"
+	"errors"
+	"html/template"
+	"journalCli/client"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var siteTemplates = template.Must(template.New("site").Parse(`
+{{define "head"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.}}</title>
+<style>
+body { font-family: system-ui, sans-serif; line-height: 1.6; margin: 0; color: #222; background: #fafafa; }
+main { max-width: 42rem; margin: 0 auto; padding: 2rem 1rem; }
+a { color: #7d56f4; }
+h2 { margin-top: 2.5rem; border-bottom: 1px solid #ddd; }
+article { margin: 1.5rem 0; }
+.meta { color: #777; font-size: 0.9rem; }
+.body { white-space: pre-wrap; overflow-wrap: break-word; }
+ul { padding-left: 1.2rem; }
+@media (prefers-color-scheme: dark) {
+  body { color: #ddd; background: #1e1e2e; }
+  h2 { border-color: #444; }
+  .meta { color: #999; }
+}
+</style>
+</head>
+<body>
+<main>
+{{end}}
+
+{{define "month"}}{{template "head" .}}<p><a href="index.html">← All months</a></p>
+<h1>{{.}}</h1>
+{{end}}
+
+{{define "day"}}<h2>{{.}}</h2>
+{{end}}
+
+{{define "entry"}}<article>
+<p class="meta">{{.Time}}{{if .Details}} · {{.Details}}{{end}}</p>
+<div class="body">{{.Body}}</div>
+</article>
+{{end}}
+
+{{define "foot"}}</main>
+</body>
+</html>
+{{end}}
+
+{{define "index"}}{{template "head" "Journal"}}<h1>Journal</h1>
+{{range .}}<h2>{{.Year}}</h2>
+<ul>
+{{range .Months}}<li><a href="{{.File}}">{{.Name}}</a> <span class="meta">{{.Entries}} {{if eq .Entries 1}}entry{{else}}entries{{end}}</span></li>
+{{end}}</ul>
+{{else}}<p>No entries.</p>
+{{end}}{{template "foot"}}{{end}}
+`))
+
+type siteMonth struct {
+	Name    string
+	File    string
+	Entries int
+}
+
+type siteYear struct {
+	Year   string
+	Months []siteMonth
+}
+
+type htmlExporter struct {
+	dir    string
+	months period
+	days   period
+	f      *os.File
+	w      *bufio.Writer
+	// index lists the months exported so far, newest first.
+	index []siteYear
+}
+
+// NewHTML exports entries to dir as a static site: a page per month, and an
+// index.html linking to them by year. The files are only readable by the
+// user, like the journal.
+func NewHTML(dir string, loc *time.Location) (Exporter, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &htmlExporter{
+		dir:    dir,
+		months: period{layout: "2006-01", loc: loc},
+		days:   period{layout: time.DateOnly, loc: loc},
+	}, nil
+}
+
+func (e *htmlExporter) Add(entry client.Entry) error {
+	exported, err := newEntry(entry)
+	if err != nil {
+		return err
+	}
+	created := entry.CreatedAt.In(e.months.loc)
+
+	month, newMonth, err := e.months.next(created)
+	if err != nil {
+		return err
+	}
+	if newMonth {
+		if err := e.startMonth(month, created); err != nil {
+			return err
+		}
+	}
+	if _, newDay, _ := e.days.next(created); newDay {
+		if err := siteTemplates.ExecuteTemplate(e.w, "day", created.Format("Monday, 2 January")); err != nil {
+			return err
+		}
+	}
+
+	e.month().Entries++
+	return siteTemplates.ExecuteTemplate(e.w, "entry", map[string]string{
+		"Time":    created.Format("15:04"),
+		"Details": entryDetails(exported),
+		"Body":    strings.TrimRight(exported.Body, "\n"),
+	})
+}
+
+// startMonth finishes the page of the previous month and starts the one of
+// month.
+func (e *htmlExporter) startMonth(month string, created time.Time) error {
+	if err := e.finishMonth(); err != nil {
+		return err
+	}
+
+	file := month + ".html"
+	f, err := os.OpenFile(filepath.Join(e.dir, file), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	e.f, e.w = f, bufio.NewWriter(f)
+
+	year := created.Format("2006")
+	if len(e.index) == 0 || e.index[len(e.index)-1].Year != year {
+		e.index = append(e.index, siteYear{Year: year})
+	}
+	last := &e.index[len(e.index)-1]
+	last.Months = append(last.Months, siteMonth{Name: created.Format("January"), File: file})
+
+	return siteTemplates.ExecuteTemplate(e.w, "month", created.Format("January 2006"))
+}
+
+// month returns the index entry of the month being exported.
+func (e *htmlExporter) month() *siteMonth {
+	year := &e.index[len(e.index)-1]
+	return &year.Months[len(year.Months)-1]
+}
+
+func (e *htmlExporter) finishMonth() error {
+	if e.f == nil {
+		return nil
+	}
+	f := e.f
+	e.f = nil
+	err := siteTemplates.ExecuteTemplate(e.w, "foot", nil)
+	if err == nil {
+		err = e.w.Flush()
+	}
+	return errors.Join(err, f.Close())
+}
+
+func (e *htmlExporter) Close() error {
+	if err := e.finishMonth(); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(filepath.Join(e.dir, "index.html"), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	err = siteTemplates.ExecuteTemplate(w, "index", e.index)
+	if err == nil {
+		err = w.Flush()
+	}
+	return errors.Join(err, f.Close())
+}
@@ -0,0 +1,75 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"journalCli/client"
+)
+
+type jsonExporter struct {
+	w     *bufio.Writer
+	buf   bytes.Buffer
+	enc   *json.Encoder
+	lines bool
+	count int
+}
+
+// NewJSON exports entries to w as a JSON array, written as entries are added.
+func NewJSON(w io.Writer) Exporter {
+	e := newJSONExporter(w, false)
+	e.enc.SetIndent("  ", "  ")
+	return e
+}
+
+// NewJSONL exports entries to w as JSON Lines, one entry per line.
+func NewJSONL(w io.Writer) Exporter {
+	return newJSONExporter(w, true)
+}
+
+func newJSONExporter(w io.Writer, lines bool) *jsonExporter {
+	e := &jsonExporter{w: bufio.NewWriter(w), lines: lines}
+	e.enc = json.NewEncoder(&e.buf)
+	e.enc.SetEscapeHTML(false)
+	return e
+}
+
+func (e *jsonExporter) Add(entry client.Entry) error {
+	exported, err := newEntry(entry)
+	if err != nil {
+		return err
+	}
+	e.buf.Reset()
+	if err := e.enc.Encode(exported); err != nil {
+		return err
+	}
+	e.count++
+
+	if e.lines {
+		_, err = e.w.Write(e.buf.Bytes())
+		return err
+	}
+	sep := ",\n  "
+	if e.count == 1 {
+		sep = "[\n  "
+	}
+	if _, err := e.w.WriteString(sep); err != nil {
+		return err
+	}
+	_, err = e.w.Write(bytes.TrimSuffix(e.buf.Bytes(), []byte("\n")))
+	return err
+}
+
+func (e *jsonExporter) Close() error {
+	if !e.lines {
+		end := "\n]\n"
+		if e.count == 0 {
+			end = "[]\n"
+		}
+		if _, err := e.w.WriteString(end); err != nil {
+			return err
+		}
+	}
+	return e.w.Flush()
+}
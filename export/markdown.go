@@ -0,0 +1,124 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"journalCli/client"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type markdownExporter struct {
+	dir  string
+	days period
+	// day holds the entries of the day being exported, newest first.
+	day []Entry
+}
+
+// NewMarkdown exports entries to dir as one Markdown file per day, e.g.
+// 2024-03-01.md, with the day's mood and tags in YAML front matter. The
+// files are only readable by the user, like the journal.
+func NewMarkdown(dir string, loc *time.Location) (Exporter, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &markdownExporter{dir: dir, days: period{layout: time.DateOnly, loc: loc}}, nil
+}
+
+func (e *markdownExporter) Add(entry client.Entry) error {
+	exported, err := newEntry(entry)
+	if err != nil {
+		return err
+	}
+	_, newDay, err := e.days.next(entry.CreatedAt)
+	if err != nil {
+		return err
+	}
+	if newDay {
+		if err := e.flush(); err != nil {
+			return err
+		}
+	}
+	e.day = append(e.day, exported)
+	return nil
+}
+
+func (e *markdownExporter) Close() error {
+	return e.flush()
+}
+
+// flush writes the file of the day being exported.
+func (e *markdownExporter) flush() error {
+	if len(e.day) == 0 {
+		return nil
+	}
+	entries := e.day
+	e.day = nil
+	slices.Reverse(entries)
+	date := entries[0].CreatedAt.In(e.days.loc)
+
+	f, err := os.OpenFile(filepath.Join(e.dir, date.Format(time.DateOnly)+".md"), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	writeDay(w, date, entries)
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writeDay(w *bufio.Writer, date time.Time, entries []Entry) {
+	var tags []string
+	moods, moodSum := 0, 0
+	for _, entry := range entries {
+		for _, tag := range entry.Tags {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+		if entry.Mood != nil {
+			moods++
+			moodSum += *entry.Mood
+		}
+	}
+	slices.Sort(tags)
+
+	// JSON strings and arrays are valid YAML, and spare quoting tags by hand.
+	quotedTags, _ := json.Marshal(append([]string{}, tags...))
+	fmt.Fprintf(w, "---\ndate: %s\nentries: %d\n", date.Format(time.DateOnly), len(entries))
+	if moods > 0 {
+		mood := math.Round(float64(moodSum)/float64(moods)*10) / 10
+		fmt.Fprintf(w, "mood: %s\n", strconv.FormatFloat(mood, 'f', -1, 64))
+	}
+	fmt.Fprintf(w, "tags: %s\n---\n\n", quotedTags)
+	fmt.Fprintf(w, "# %s\n", date.Format("Monday, 2 January 2006"))
+
+	for _, entry := range entries {
+		fmt.Fprintf(w, "\n## %s\n\n", entry.CreatedAt.In(date.Location()).Format("15:04"))
+		if details := entryDetails(entry); details != "" {
+			fmt.Fprintf(w, "*%s*\n\n", details)
+		}
+		w.WriteString(strings.TrimRight(entry.Body, "\n") + "\n")
+	}
+}
+
+// entryDetails returns the mood and tags of an entry as a line of text.
+func entryDetails(entry Entry) string {
+	var details []string
+	if entry.Mood != nil {
+		details = append(details, fmt.Sprintf("Mood %d/5", *entry.Mood))
+	}
+	if len(entry.Tags) > 0 {
+		details = append(details, "#"+strings.Join(entry.Tags, " #"))
+	}
+	return strings.Join(details, " · ")
+}
@@ -0,0 +1,214 @@
+// Command fakeidp is a stand-in OpenID Connect provider for trying the Google
+// login without Google. It implements just enough of the device authorization
+// grant: codes are approved by entering them, with any email, on its /device
+// page.
+//
+//	go run ./fakeidp -listen localhost:9090
+//	go run ./server -google-client-id fake \
+//	    -google-device-auth-url http://localhost:9090/device/code \
+//	    -google-token-url http://localhost:9090/token \
+//	    -google-jwks-url http://localhost:9090/jwks \
+//	    -google-issuer http://localhost:9090
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+const codeTTL = 10 * time.Minute
+
+type deviceCode struct {
+	userCode  string
+	clientID  string
+	expiresAt time.Time
+	email     string
+	denied    bool
+}
+
+type idp struct {
+	issuer   string
+	interval int
+	key      *rsa.PrivateKey
+	signer   jose.Signer
+
+	mu    sync.Mutex
+	codes map[string]*deviceCode
+}
+
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func oauthError(w http.ResponseWriter, code string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{"error": code})
+}
+
+func (p *idp) deviceCodeHandler(w http.ResponseWriter, r *http.Request) {
+	clientID := r.PostFormValue("client_id")
+	if clientID == "" {
+		oauthError(w, "invalid_client")
+		return
+	}
+
+	code := randomHex(16)
+	userCode := strings.ToUpper(randomHex(2) + "-" + randomHex(2))
+
+	p.mu.Lock()
+	p.codes[code] = &deviceCode{userCode: userCode, clientID: clientID, expiresAt: time.Now().Add(codeTTL)}
+	p.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"device_code":               code,
+		"user_code":                 userCode,
+		"verification_uri":          p.issuer + "/device",
+		"verification_uri_complete": p.issuer + "/device?user_code=" + userCode,
+		"expires_in":                int(codeTTL.Seconds()),
+		"interval":                  p.interval,
+	})
+}
+
+var devicePage = template.Must(template.New("device").Parse(`<!doctype html>
+<title>Fake IdP</title>
+<h1>Fake IdP</h1>
+{{if .Message}}<p>{{.Message}}</p>{{end}}
+<form method="post">
+  <p><label>Code <input name="user_code" value="{{.UserCode}}"></label></p>
+  <p><label>Email <input name="email" type="email" value="alice@example.com"></label></p>
+  <button name="action" value="approve">Approve</button>
+  <button name="action" value="deny">Deny</button>
+</form>`))
+
+func (p *idp) devicePageHandler(w http.ResponseWriter, r *http.Request) {
+	data := struct{ UserCode, Message string }{UserCode: r.FormValue("user_code")}
+
+	if r.Method == http.MethodPost {
+		data.Message = "Unknown or expired code."
+		p.mu.Lock()
+		for _, code := range p.codes {
+			if code.userCode == strings.ToUpper(strings.TrimSpace(data.UserCode)) && time.Now().Before(code.expiresAt) {
+				code.email = r.PostFormValue("email")
+				code.denied = r.PostFormValue("action") == "deny"
+				data.Message = "Done, you can go back to your terminal."
+			}
+		}
+		p.mu.Unlock()
+	}
+
+	devicePage.Execute(w, data)
+}
+
+func (p *idp) tokenHandler(w http.ResponseWriter, r *http.Request) {
+	if r.PostFormValue("grant_type") != "urn:ietf:params:oauth:grant-type:device_code" {
+		oauthError(w, "unsupported_grant_type")
+		return
+	}
+
+	p.mu.Lock()
+	code, ok := p.codes[r.PostFormValue("device_code")]
+	if ok && (code.denied || code.email != "" || time.Now().After(code.expiresAt)) {
+		delete(p.codes, r.PostFormValue("device_code"))
+	}
+	p.mu.Unlock()
+
+	switch {
+	case !ok:
+		oauthError(w, "invalid_grant")
+		return
+	case time.Now().After(code.expiresAt):
+		oauthError(w, "expired_token")
+		return
+	case code.denied:
+		oauthError(w, "access_denied")
+		return
+	case code.email == "":
+		oauthError(w, "authorization_pending")
+		return
+	}
+
+	sub := sha256.Sum256([]byte(code.email))
+	now := time.Now()
+	claims := map[string]any{
+		"iss":            p.issuer,
+		"aud":            code.clientID,
+		"sub":            hex.EncodeToString(sub[:8]),
+		"email":          code.email,
+		"email_verified": true,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+	}
+	idToken, err := jwt.Signed(p.signer).Claims(claims).Serialize()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": randomHex(16),
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func (p *idp) jwksHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{Key: &p.key.PublicKey, KeyID: "fake", Algorithm: string(jose.RS256), Use: "sig"},
+	}})
+}
+
+func main() {
+	listen := flag.String("listen", "localhost:9090", "address to listen on")
+	interval := flag.Int("interval", 2, "polling interval to ask clients for, in seconds")
+	flag.Parse()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		log.Fatal(err)
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", "fake"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	p := &idp{
+		issuer:   "http://" + *listen,
+		interval: *interval,
+		key:      key,
+		signer:   signer,
+		codes:    map[string]*deviceCode{},
+	}
+
+	http.HandleFunc("/device/code", p.deviceCodeHandler)
+	http.HandleFunc("/device", p.devicePageHandler)
+	http.HandleFunc("/token", p.tokenHandler)
+	http.HandleFunc("/jwks", p.jwksHandler)
+
+	fmt.Printf("Fake IdP running on %s\n", p.issuer)
+	if err := http.ListenAndServe(*listen, nil); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"journalCli/client"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type GoogleDeviceMsg struct {
+	Auth client.DeviceAuthorization
+}
+
+type googlePollMsg struct {
+	DeviceCode string
+}
+
+// GooglePendingMsg means the login hasn't been approved yet. SlowDown asks to
+// poll less often.
+type GooglePendingMsg struct {
+	DeviceCode string
+	SlowDown   bool
+}
+
+type GoogleLoginFailedMsg struct {
+	err error
+}
+
+func startGoogleLogin(api *client.Client) tea.Msg {
+	auth, err := api.StartGoogleLogin(context.Background())
+	if errors.Is(err, client.ErrNotConfigured) {
+		return ErrMsg{fmt.Errorf("Google login is not available on this server")}
+	}
+	if err != nil {
+		return ErrMsg{err}
+	}
+	return GoogleDeviceMsg{Auth: *auth}
+}
+
+func pollGoogleLogin(deviceCode string, api *client.Client) tea.Msg {
+	auth, err := api.PollGoogleLogin(context.Background(), deviceCode)
+	switch {
+	case errors.Is(err, client.ErrAuthorizationPending):
+		return GooglePendingMsg{DeviceCode: deviceCode}
+	case errors.Is(err, client.ErrSlowDown):
+		return GooglePendingMsg{DeviceCode: deviceCode, SlowDown: true}
+	case errors.Is(err, client.ErrAccessDenied):
+		return GoogleLoginFailedMsg{fmt.Errorf("Google login was denied")}
+	case errors.Is(err, client.ErrExpired):
+		return GoogleLoginFailedMsg{fmt.Errorf("The login code expired, press Ctrl+g to try again")}
+	case err != nil:
+		return GoogleLoginFailedMsg{err}
+	}
+	rememberSession(api, auth)
+	return LoginSuccessMsg{User: auth.User, Token: auth.Token}
+}
+
+func scheduleGooglePoll(deviceCode string, interval int) tea.Cmd {
+	return tea.Tick(time.Duration(interval)*time.Second, func(time.Time) tea.Msg {
+		return googlePollMsg{DeviceCode: deviceCode}
+	})
+}
+
+// updateGoogleLogin handles the messages of a login with Google in progress.
+// Messages about a login that was cancelled in the meantime are dropped.
+func (m Model) updateGoogleLogin(msg tea.Msg) (Model, tea.Cmd) {
+	active := m.googleLogin != nil
+
+	switch msg := msg.(type) {
+	case GoogleDeviceMsg:
+		m.err = nil
+		m.googleLogin = &msg.Auth
+		return m, scheduleGooglePoll(msg.Auth.DeviceCode, msg.Auth.Interval)
+	case googlePollMsg:
+		if !active || m.googleLogin.DeviceCode != msg.DeviceCode {
+			return m, nil
+		}
+		api := m.api
+		return m, func() tea.Msg { return pollGoogleLogin(msg.DeviceCode, api) }
+	case GooglePendingMsg:
+		if !active || m.googleLogin.DeviceCode != msg.DeviceCode {
+			return m, nil
+		}
+		if msg.SlowDown {
+			m.googleLogin.Interval += 5
+		}
+		return m, scheduleGooglePoll(msg.DeviceCode, m.googleLogin.Interval)
+	case GoogleLoginFailedMsg:
+		if active {
+			m.googleLogin = nil
+			m.err = msg.err
+		}
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, googleKeys.Cancel):
+			m.googleLogin = nil
+		case key.Matches(msg, quitKey):
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+func renderGoogleLogin(m Model) string {
+	auth := m.googleLogin
+	codeStyle := lipgloss.NewStyle().
+		Bold(true).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(currentTheme.Primary.Terminal()).
+		Padding(0, 2)
+
+	rows := []string{
+		titleStyle.Render("🔐 Login with " + renderGoogleLogo()),
+		"1. Open " + lipgloss.NewStyle().Underline(true).Render(auth.VerificationURI),
+		"2. Enter this code:",
+		codeStyle.Render(auth.UserCode),
+	}
+	if auth.VerificationURIComplete != "" {
+		rows = append(rows, lipgloss.NewStyle().Italic(true).Render("or open "+auth.VerificationURIComplete))
+	}
+	rows = append(rows,
+		" ",
+		lipgloss.NewStyle().Italic(true).Render("Waiting for you to approve the login..."),
+		keyHints(googleKeyMap, m.width),
+	)
+
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center,
+		lipgloss.JoinVertical(lipgloss.Center, rows...))
+}
@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"journalCli/db"
+	"journalCli/oauth"
+)
+
+// API holds what the handlers need to serve requests. The store decides where
+// users and entries live, so the handlers run the same against Postgres,
+// SQLite or memory.
+type API struct {
+	store  db.Store
+	google *oauth.Provider
+}
+
+// New returns the handlers for a store. Login with Google stays disabled while
+// google is nil.
+func New(store db.Store, google *oauth.Provider) *API {
+	return &API{store: store, google: google}
+}
@@ -2,100 +2,168 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"journalCli/client"
 	"journalCli/db"
 	"journalCli/utils"
 	"net/http"
+	"net/mail"
+	"strings"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
-type LoginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
-}
-
-type SignupRequest struct {
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
-}
+// minPasswordLength matches the minimum length of an encryption passphrase.
+const minPasswordLength = 8
+
+// signupFieldErrors returns what is wrong with each field of a signup, if
+// anything.
+func signupFieldErrors(req client.SignupRequest) map[string]string {
+	fields := map[string]string{}
+
+	username := strings.TrimSpace(req.Username)
+	switch {
+	case username == "":
+		fields["username"] = "is required"
+	case len(username) > 50:
+		fields["username"] = "must be at most 50 characters"
+	case strings.ContainsAny(username, " \t@"):
+		fields["username"] = "must not contain spaces or @"
+	}
 
-func LoginHandler(w http.ResponseWriter, r *http.Request) {
-	database := db.GetDB()
+	email := strings.TrimSpace(req.Email)
+	if email == "" {
+		fields["email"] = "is required"
+	} else if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email || len(email) > 100 {
+		fields["email"] = "is not a valid email address"
+	}
 
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
+	if len(req.Password) < minPasswordLength {
+		fields["password"] = fmt.Sprintf("must be at least %d characters", minPasswordLength)
 	}
 
-	var loginReq LoginRequest
-	err := json.NewDecoder(r.Body).Decode(&loginReq)
+	return fields
+}
 
-	if err != nil {
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+// authResponse is what logging in or signing up returns. It copies the user
+// into a client.User, which has no field for the password hash to leak
+// through.
+func authResponse(user *db.User, session *db.Session) client.AuthResponse {
+	return client.AuthResponse{
+		User:      client.User{ID: user.ID, Email: user.Email, Username: user.Username},
+		Token:     session.Token,
+		ExpiresAt: session.ExpiresAt,
+	}
+}
+
+func (api *API) LoginHandler(w http.ResponseWriter, r *http.Request) {
+	var loginReq client.LoginRequest
+	if !decodeJSON(w, r, &loginReq) {
 		return
 	}
 
 	email := loginReq.Email
 	password := loginReq.Password
 
-	user, err := db.GetUserByEmail(database, email)
+	user, err := api.store.GetUserByEmail(email)
 
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
+	if err != nil && !errors.Is(err, db.ErrNotFound) {
+		internalError(w, r, err)
 		return
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password_hash), []byte(password)); err != nil {
-		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+	if err != nil || bcrypt.CompareHashAndPassword([]byte(user.Password_hash), []byte(password)) != nil {
+		writeError(w, r, http.StatusUnauthorized, client.CodeInvalidCredentials, "Wrong email or password")
 		return
 	}
 
 	user.Password_hash = ""
 
+	session, err := api.store.CreateSession(user.ID)
+
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(user)
+	json.NewEncoder(w).Encode(authResponse(user, session))
 }
 
-func SignUpHandler(w http.ResponseWriter, r *http.Request) {
-
-	database := db.GetDB()
+func (api *API) SignUpHandler(w http.ResponseWriter, r *http.Request) {
+	var signupReq client.SignupRequest
+	if !decodeJSON(w, r, &signupReq) {
+		return
+	}
 
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	if fields := signupFieldErrors(signupReq); len(fields) > 0 {
+		writeFieldErrors(w, r, "Can't sign up", fields)
 		return
 	}
 
-	var signupReq SignupRequest
+	username := strings.TrimSpace(signupReq.Username)
+	email := strings.TrimSpace(signupReq.Email)
+	password := signupReq.Password
 
-	err := json.NewDecoder(r.Body).Decode(&signupReq)
+	hashPassword, err := utils.HashPassword(password)
 
 	if err != nil {
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		internalError(w, r, err)
 		return
 	}
-	username := signupReq.Username
-	email := signupReq.Email
-	password := signupReq.Password
 
-	hashPassword, err := utils.HashPassword(password)
+	user, err := api.store.CreateUser(username, email, hashPassword)
 
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if errors.Is(err, db.ErrEmailTaken) {
+		writeError(w, r, http.StatusConflict, client.CodeEmailTaken, "Email already registered")
 		return
 	}
 
-	user, err := db.CreateUser(database, username, email, hashPassword)
+	if errors.Is(err, db.ErrUsernameTaken) {
+		writeError(w, r, http.StatusConflict, client.CodeUsernameTaken, "Username already taken")
+		return
+	}
 
-	user.Password_hash = ""
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	session, err := api.store.CreateSession(user.ID)
 
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		internalError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(user)
+	json.NewEncoder(w).Encode(authResponse(user, session))
+}
+
+// LogoutHandler revokes the session used for the request, or every session of
+// the user when the body asks for {"all": true}. Must be wrapped in RequireAuth.
+func (api *API) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	var logoutReq client.LogoutRequest
+	if r.ContentLength != 0 {
+		if !decodeJSON(w, r, &logoutReq) {
+			return
+		}
+	}
+
+	var err error
+	if logoutReq.All {
+		err = api.store.DeleteUserSessions(UserFromContext(r.Context()).ID)
+	} else {
+		err = api.store.DeleteSession(tokenFromContext(r.Context()))
+	}
+
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
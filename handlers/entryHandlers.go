@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"journalCli/client"
+	"journalCli/db"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultEntriesLimit = 20
+	maxEntriesLimit     = 100
+)
+
+type CreateEntryRequest struct {
+	Body string `json:"body"`
+	db.Encryption
+	Mood      *int      `json:"mood"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type UpdateEntryRequest struct {
+	Body string `json:"body"`
+	db.Encryption
+	Mood        *int `json:"mood"`
+	BaseVersion *int `json:"base_version"`
+}
+
+type EntryChangesResponse struct {
+	Entries []db.Entry `json:"entries"`
+	Deleted []string   `json:"deleted"`
+	Cursor  time.Time  `json:"cursor"`
+}
+
+type ListEntriesResponse struct {
+	Entries    []db.Entry `json:"entries"`
+	NextCursor string     `json:"next_cursor"`
+}
+
+// parseLimit reads the limit query parameter, defaulting to
+// defaultEntriesLimit and capping it at maxEntriesLimit.
+func parseLimit(raw string) (int, error) {
+	if raw == "" {
+		return defaultEntriesLimit, nil
+	}
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit <= 0 {
+		return 0, errors.New("Invalid limit")
+	}
+	return min(limit, maxEntriesLimit), nil
+}
+
+func validMood(mood *int) bool {
+	return mood == nil || (*mood >= db.MinMood && *mood <= db.MaxMood)
+}
+
+// entryFieldErrors returns what is wrong with the body and mood of an entry
+// being saved, if anything.
+func entryFieldErrors(body string, mood *int) map[string]string {
+	fields := map[string]string{}
+	if strings.TrimSpace(body) == "" {
+		fields["body"] = "cannot be empty"
+	}
+	if !validMood(mood) {
+		fields["mood"] = fmt.Sprintf("must be between %d and %d", db.MinMood, db.MaxMood)
+	}
+	return fields
+}
+
+// parseMood reads an optional mood query parameter.
+func parseMood(raw string) (*int, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	mood, err := strconv.Atoi(raw)
+	if err != nil || !validMood(&mood) {
+		return nil, fmt.Errorf("Mood must be between %d and %d", db.MinMood, db.MaxMood)
+	}
+	return &mood, nil
+}
+
+func (api *API) CreateEntryHandler(w http.ResponseWriter, r *http.Request) {
+	var entryReq CreateEntryRequest
+	if !decodeJSON(w, r, &entryReq) {
+		return
+	}
+
+	if fields := entryFieldErrors(entryReq.Body, entryReq.Mood); len(fields) > 0 {
+		writeFieldErrors(w, r, "Entry is invalid", fields)
+		return
+	}
+
+	user := UserFromContext(r.Context())
+
+	if !api.checkEncryption(w, r, user.ID, entryReq.Encryption) {
+		return
+	}
+
+	entry, err := api.store.CreateEntry(user.ID, entryReq.Body, entryReq.Encryption, entryReq.Mood, entryReq.CreatedAt)
+
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(entry)
+}
+
+func (api *API) ListEntriesHandler(w http.ResponseWriter, r *http.Request) {
+	user := UserFromContext(r.Context())
+	query := r.URL.Query()
+
+	limit, err := parseLimit(query.Get("limit"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, client.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	filter := db.EntryFilter{Tag: strings.TrimPrefix(query.Get("tag"), "#")}
+
+	if filter.MoodMin, err = parseMood(query.Get("mood_min")); err != nil {
+		writeError(w, r, http.StatusBadRequest, client.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	if filter.MoodMax, err = parseMood(query.Get("mood_max")); err != nil {
+		writeError(w, r, http.StatusBadRequest, client.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	entries, nextCursor, err := api.store.ListEntriesPage(user.ID, filter, limit, query.Get("cursor"))
+
+	if errors.Is(err, db.ErrInvalidCursor) {
+		writeError(w, r, http.StatusBadRequest, client.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ListEntriesResponse{Entries: entries, NextCursor: nextCursor})
+}
+
+// ownedEntry loads an entry and checks that it belongs to the authenticated
+// user, writing the error response itself when it doesn't.
+func (api *API) ownedEntry(w http.ResponseWriter, r *http.Request, id string) (*db.Entry, bool) {
+	if _, err := strconv.Atoi(id); err != nil {
+		writeError(w, r, http.StatusNotFound, client.CodeNotFound, "Entry not found")
+		return nil, false
+	}
+
+	entry, err := api.store.GetEntryByID(id)
+
+	if errors.Is(err, db.ErrNotFound) {
+		writeError(w, r, http.StatusNotFound, client.CodeNotFound, "Entry not found")
+		return nil, false
+	}
+
+	if err != nil {
+		internalError(w, r, err)
+		return nil, false
+	}
+
+	if entry.UserID != UserFromContext(r.Context()).ID {
+		writeError(w, r, http.StatusForbidden, client.CodeForbidden, "Entry belongs to another user")
+		return nil, false
+	}
+
+	return entry, true
+}
+
+func (api *API) GetEntryHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	entry, ok := api.ownedEntry(w, r, id)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(entry)
+}
+
+func (api *API) UpdateEntryHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var entryReq UpdateEntryRequest
+	if !decodeJSON(w, r, &entryReq) {
+		return
+	}
+
+	if fields := entryFieldErrors(entryReq.Body, entryReq.Mood); len(fields) > 0 {
+		writeFieldErrors(w, r, "Entry is invalid", fields)
+		return
+	}
+
+	if _, ok := api.ownedEntry(w, r, id); !ok {
+		return
+	}
+
+	user := UserFromContext(r.Context())
+
+	if !api.checkEncryption(w, r, user.ID, entryReq.Encryption) {
+		return
+	}
+
+	entry, err := api.store.UpdateEntry(id, user.ID, entryReq.Body, entryReq.Encryption, entryReq.Mood, entryReq.BaseVersion)
+
+	if errors.Is(err, db.ErrNotFound) {
+		writeError(w, r, http.StatusNotFound, client.CodeNotFound, "Entry not found")
+		return
+	}
+
+	if errors.Is(err, db.ErrVersionConflict) {
+		writeError(w, r, http.StatusConflict, client.CodeVersionConflict, "Entry was changed since it was loaded")
+		return
+	}
+
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(entry)
+}
+
+func (api *API) DeleteEntryHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	if _, ok := api.ownedEntry(w, r, id); !ok {
+		return
+	}
+
+	err := api.store.DeleteEntry(id, UserFromContext(r.Context()).ID)
+
+	if errors.Is(err, db.ErrNotFound) {
+		writeError(w, r, http.StatusNotFound, client.CodeNotFound, "Entry not found")
+		return
+	}
+
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (api *API) ListEntryRevisionsHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	if _, ok := api.ownedEntry(w, r, id); !ok {
+		return
+	}
+
+	revisions, err := api.store.ListEntryRevisions(id)
+
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(revisions)
+}
+
+// SearchEntriesHandler serves /entries/search?q= for the authenticated user.
+// Must be wrapped in RequireAuth.
+func (api *API) SearchEntriesHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	q := strings.TrimSpace(query.Get("q"))
+	if q == "" {
+		writeError(w, r, http.StatusBadRequest, client.CodeInvalidRequest, "Missing search query")
+		return
+	}
+
+	limit, err := parseLimit(query.Get("limit"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, client.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	results, err := api.store.SearchEntries(UserFromContext(r.Context()).ID, q, limit)
+
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(results)
+}
+
+// TagsHandler serves /tags, the tags of the authenticated user with how many
+// entries use them. Must be wrapped in RequireAuth.
+func (api *API) TagsHandler(w http.ResponseWriter, r *http.Request) {
+	tags, err := api.store.ListTags(UserFromContext(r.Context()).ID)
+
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(tags)
+}
+
+// EntryChangesHandler serves /entries/changes?since=, what changed in the
+// authenticated user's journal for syncing clients. Must be wrapped in
+// RequireAuth.
+func (api *API) EntryChangesHandler(w http.ResponseWriter, r *http.Request) {
+	var since time.Time
+	if rawSince := r.URL.Query().Get("since"); rawSince != "" {
+		var err error
+		if since, err = time.Parse(time.RFC3339Nano, rawSince); err != nil {
+			writeError(w, r, http.StatusBadRequest, client.CodeInvalidRequest, "Invalid since, expected an RFC 3339 time")
+			return
+		}
+	}
+
+	entries, deleted, cursor, err := api.store.ListEntryChanges(UserFromContext(r.Context()).ID, since)
+
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(EntryChangesResponse{Entries: entries, Deleted: deleted, Cursor: cursor})
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"journalCli/client"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+func writeAPIError(w http.ResponseWriter, r *http.Request, status int, apiErr client.APIError) {
+	apiErr.RequestID = RequestIDFromContext(r.Context())
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(client.ErrorResponse{Error: apiErr})
+}
+
+// writeError answers with an error envelope.
+func writeError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	writeAPIError(w, r, status, client.APIError{Code: code, Message: message})
+}
+
+// writeFieldErrors answers 400 with what is wrong with each invalid field.
+func writeFieldErrors(w http.ResponseWriter, r *http.Request, message string, fields map[string]string) {
+	writeAPIError(w, r, http.StatusBadRequest, client.APIError{Code: client.CodeValidationFailed, Message: message, Fields: fields})
+}
+
+// internalError logs an unexpected error and answers 500 without the details,
+// which can include SQL; the request id in the response leads to the log line.
+func internalError(w http.ResponseWriter, r *http.Request, err error) {
+	slog.ErrorContext(r.Context(), "request failed",
+		slog.String("request_id", RequestIDFromContext(r.Context())),
+		slog.String("method", r.Method),
+		slog.String("path", r.URL.Path),
+		slog.Any("error", err),
+	)
+	writeError(w, r, http.StatusInternalServerError, client.CodeInternal, "Internal server error")
+}
+
+// decodeJSON reads the request body into v, answering 400 or 413 itself when
+// it can't.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		writeError(w, r, http.StatusRequestEntityTooLarge, client.CodePayloadTooLarge, "Request body is too large")
+		return false
+	case err != nil:
+		writeError(w, r, http.StatusBadRequest, client.CodeInvalidRequest, "Invalid request payload")
+		return false
+	}
+	return true
+}
+
+// routeErrors serves mux, replacing the plain-text 404 and 405 responses it
+// writes for requests that match no route with error envelopes.
+func routeErrors(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h, pattern := mux.Handler(r)
+		if pattern != "" {
+			mux.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &headerRecorder{header: http.Header{}}
+		h.ServeHTTP(rec, r)
+		switch rec.status {
+		case http.StatusNotFound:
+			writeError(w, r, http.StatusNotFound, client.CodeNotFound, "No such endpoint")
+		case http.StatusMethodNotAllowed:
+			w.Header().Set("Allow", rec.header.Get("Allow"))
+			writeError(w, r, http.StatusMethodNotAllowed, client.CodeMethodNotAllowed,
+				"Method not allowed, use "+strings.ReplaceAll(rec.header.Get("Allow"), ", ", " or "))
+		default:
+			// Redirects to the clean form of the path.
+			mux.ServeHTTP(w, r)
+		}
+	})
+}
+
+// headerRecorder keeps the status and headers a handler writes, dropping the
+// body.
+type headerRecorder struct {
+	header http.Header
+	status int
+}
+
+func (rec *headerRecorder) Header() http.Header {
+	return rec.header
+}
+
+func (rec *headerRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+}
+
+func (rec *headerRecorder) Write(b []byte) (int, error) {
+	rec.WriteHeader(http.StatusOK)
+	return len(b), nil
+}
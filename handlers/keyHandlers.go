@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"journalCli/client"
+	"journalCli/db"
+	"journalCli/e2ee"
+	"net/http"
+)
+
+// GetKeyHandler returns the end-to-end encryption key envelope of the
+// authenticated user. Must be wrapped in RequireAuth.
+func (api *API) GetKeyHandler(w http.ResponseWriter, r *http.Request) {
+	key, err := api.store.GetUserKey(UserFromContext(r.Context()).ID)
+
+	if errors.Is(err, db.ErrNotFound) {
+		writeError(w, r, http.StatusNotFound, client.CodeEncryptionDisabled, "Encryption is not set up")
+		return
+	}
+
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(key)
+}
+
+// PutKeyHandler stores a new key envelope. Once set up, the data key can only
+// be re-wrapped (e.g. after a passphrase change), never swapped for another
+// one, since that would make existing entries unreadable.
+func (api *API) PutKeyHandler(w http.ResponseWriter, r *http.Request) {
+	user := UserFromContext(r.Context())
+
+	var keyReq db.UserKey
+	if !decodeJSON(w, r, &keyReq) {
+		return
+	}
+
+	if keyReq.KDF == "" || keyReq.Salt == "" || keyReq.WrappedKey == "" || keyReq.WrapNonce == "" || keyReq.KeyCheck == "" {
+		writeError(w, r, http.StatusBadRequest, client.CodeInvalidRequest, "Incomplete key envelope")
+		return
+	}
+
+	if _, err := e2ee.CheckNonce(keyReq.WrapNonce); err != nil {
+		writeError(w, r, http.StatusBadRequest, client.CodeInvalidRequest, "Key envelope has an invalid nonce")
+		return
+	}
+
+	current, err := api.store.GetUserKey(user.ID)
+
+	if err != nil && !errors.Is(err, db.ErrNotFound) {
+		internalError(w, r, err)
+		return
+	}
+
+	if current != nil && current.KeyCheck != keyReq.KeyCheck {
+		writeError(w, r, http.StatusConflict, client.CodeKeyMismatch, "Key envelope wraps a different data key")
+		return
+	}
+
+	key, err := api.store.PutUserKey(user.ID, keyReq)
+
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(key)
+}
+
+// checkEncryption makes sure an encrypted body was sealed with the user's
+// current data key, writing the error response itself when it wasn't.
+func (api *API) checkEncryption(w http.ResponseWriter, r *http.Request, userID string, enc db.Encryption) bool {
+	if enc.Nonce == "" && enc.KeyCheck == "" {
+		return true
+	}
+
+	if enc.Nonce == "" || enc.KeyCheck == "" {
+		writeError(w, r, http.StatusBadRequest, client.CodeInvalidRequest, "Encrypted entries need both a nonce and a key check")
+		return false
+	}
+
+	if _, err := e2ee.CheckNonce(enc.Nonce); err != nil {
+		writeError(w, r, http.StatusBadRequest, client.CodeInvalidRequest, "Encrypted entries need a valid nonce")
+		return false
+	}
+
+	key, err := api.store.GetUserKey(userID)
+
+	if errors.Is(err, db.ErrNotFound) {
+		writeError(w, r, http.StatusBadRequest, client.CodeEncryptionDisabled, "Encryption is not set up")
+		return false
+	}
+
+	if err != nil {
+		internalError(w, r, err)
+		return false
+	}
+
+	if key.KeyCheck != enc.KeyCheck {
+		writeError(w, r, http.StatusBadRequest, client.CodeKeyMismatch, "Entry was sealed with an unknown key")
+		return false
+	}
+
+	return true
+}
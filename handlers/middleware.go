@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"journalCli/client"
+	"journalCli/db"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+type contextKey int
+
+const (
+	userContextKey contextKey = iota
+	tokenContextKey
+	requestIDContextKey
+)
+
+// Middleware wraps a handler with behaviour shared by every route.
+type Middleware func(http.Handler) http.Handler
+
+// Chain wraps h so that requests go through middleware in the order given.
+func Chain(h http.Handler, middleware ...Middleware) http.Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	return h
+}
+
+// RequestID tags each request with the X-Request-ID the client sent, or a new
+// one, and echoes it in the response so a report can be matched to the logs.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if id == "" || len(id) > 64 {
+			buf := make([]byte, 8)
+			rand.Read(buf)
+			id = hex.EncodeToString(buf)
+		}
+		w.Header().Set("X-Request-ID", id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDContextKey, id)))
+	})
+}
+
+// RequestIDFromContext returns the id set by RequestID.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey).(string)
+	return id
+}
+
+// statusRecorder remembers what was written for AccessLog and Recover.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += n
+	return n, err
+}
+
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// AccessLog logs one line per request once it has been served.
+func AccessLog(logger *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w}
+			next.ServeHTTP(rec, r)
+
+			logger.LogAttrs(r.Context(), slog.LevelInfo, "request",
+				slog.String("request_id", RequestIDFromContext(r.Context())),
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", rec.status),
+				slog.Int("bytes", rec.bytes),
+				slog.Duration("duration", time.Since(start)),
+				slog.String("remote", r.RemoteAddr),
+			)
+		})
+	}
+}
+
+// Recover turns a panicking handler into a 500 response and a logged stack
+// trace instead of a dropped connection.
+func Recover(logger *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := &statusRecorder{ResponseWriter: w}
+			defer func() {
+				err := recover()
+				if err == nil {
+					return
+				}
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
+				logger.Error("handler panicked",
+					slog.String("request_id", RequestIDFromContext(r.Context())),
+					slog.Any("panic", err),
+					slog.String("stack", string(debug.Stack())),
+				)
+				if rec.status == 0 {
+					writeError(rec, r, http.StatusInternalServerError, client.CodeInternal, "Internal server error")
+				}
+			}()
+			next.ServeHTTP(rec, r)
+		})
+	}
+}
+
+// LimitBody makes reading more than limit bytes of a request body fail, so
+// a handler decoding JSON answers 400 instead of buffering it all.
+func LimitBody(limit int64) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Timeout answers 503 Service Unavailable when a handler takes longer than
+// timeout. The error envelope carries the request id, so the message is built
+// per request.
+func Timeout(timeout time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := json.Marshal(client.ErrorResponse{Error: client.APIError{
+				Code:      client.CodeTimeout,
+				Message:   "Request timed out",
+				RequestID: RequestIDFromContext(r.Context()),
+			}})
+			// TimeoutHandler writes the message without a Content-Type.
+			http.TimeoutHandler(next, timeout, string(body)).ServeHTTP(&timeoutWriter{ResponseWriter: w}, r)
+		})
+	}
+}
+
+// timeoutWriter labels the message of a timed out request as JSON. Handlers
+// set their own Content-Type, which TimeoutHandler copies over before writing
+// the status, so only the timeout message arrives without one.
+type timeoutWriter struct {
+	http.ResponseWriter
+}
+
+func (w *timeoutWriter) WriteHeader(status int) {
+	if status == http.StatusServiceUnavailable && w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *timeoutWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	token, found := strings.CutPrefix(header, "Bearer ")
+	if !found {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// RequireAuth resolves the bearer token of the request to a user and makes it
+// available to next through UserFromContext.
+func (api *API) RequireAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := bearerToken(r)
+		if token == "" {
+			writeError(w, r, http.StatusUnauthorized, client.CodeUnauthorized, "Missing bearer token")
+			return
+		}
+
+		user, err := api.store.GetUserBySessionToken(token)
+		if err != nil {
+			writeError(w, r, http.StatusUnauthorized, client.CodeUnauthorized, "Invalid or expired session")
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), userContextKey, user)
+		ctx = context.WithValue(ctx, tokenContextKey, token)
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// UserFromContext returns the user set by RequireAuth.
+func UserFromContext(ctx context.Context) *db.User {
+	user, _ := ctx.Value(userContextKey).(*db.User)
+	return user
+}
+
+func tokenFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(tokenContextKey).(string)
+	return token
+}
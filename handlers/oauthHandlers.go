@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"journalCli/client"
+	"journalCli/oauth"
+	"log/slog"
+	"net/http"
+)
+
+// GoogleDeviceHandler starts a device authorization with Google and returns
+// the code the user has to enter, along with the device code to poll with.
+func (api *API) GoogleDeviceHandler(w http.ResponseWriter, r *http.Request) {
+	if api.google == nil {
+		writeError(w, r, http.StatusNotImplemented, client.CodeNotConfigured, "Google login is not configured on this server")
+		return
+	}
+
+	auth, err := api.google.StartDeviceAuthorization(r.Context())
+
+	if err != nil {
+		providerError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(auth)
+}
+
+// GoogleTokenHandler checks whether the user approved a device authorization.
+// Once they have, it logs them in like LoginHandler, creating their account on
+// the first login. Until then it answers 202 Accepted, or 429 when polled
+// faster than the interval.
+func (api *API) GoogleTokenHandler(w http.ResponseWriter, r *http.Request) {
+	if api.google == nil {
+		writeError(w, r, http.StatusNotImplemented, client.CodeNotConfigured, "Google login is not configured on this server")
+		return
+	}
+
+	var tokenReq client.DeviceTokenRequest
+	if !decodeJSON(w, r, &tokenReq) {
+		return
+	}
+
+	if tokenReq.DeviceCode == "" {
+		writeFieldErrors(w, r, "Missing device code", map[string]string{"device_code": "is required"})
+		return
+	}
+
+	identity, err := api.google.PollDeviceToken(r.Context(), tokenReq.DeviceCode)
+
+	switch {
+	case errors.Is(err, oauth.ErrAuthorizationPending):
+		writeError(w, r, http.StatusAccepted, client.CodeAuthPending, "Authorization pending")
+		return
+	case errors.Is(err, oauth.ErrSlowDown):
+		writeError(w, r, http.StatusTooManyRequests, client.CodeSlowDown, "Polling too fast")
+		return
+	case errors.Is(err, oauth.ErrAccessDenied), errors.Is(err, oauth.ErrUnverifiedEmail):
+		writeError(w, r, http.StatusForbidden, client.CodeAccessDenied, err.Error())
+		return
+	case errors.Is(err, oauth.ErrExpiredToken):
+		writeError(w, r, http.StatusGone, client.CodeExpired, "Login code expired")
+		return
+	case err != nil:
+		providerError(w, r, err)
+		return
+	}
+
+	user, err := api.store.FindOrCreateOAuthUser(api.google.Name(), identity.Subject, identity.Email)
+
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	session, err := api.store.CreateSession(user.ID)
+
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(authResponse(user, session))
+}
+
+// providerError logs a failed call to the identity provider and answers 502.
+func providerError(w http.ResponseWriter, r *http.Request, err error) {
+	slog.WarnContext(r.Context(), "identity provider request failed",
+		slog.String("request_id", RequestIDFromContext(r.Context())),
+		slog.Any("error", err),
+	)
+	writeError(w, r, http.StatusBadGateway, client.CodeProviderError, "Google login failed, try again later")
+}
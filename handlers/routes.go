@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"journalCli/client"
+	"net/http"
+)
+
+// Routes returns the API's router, serving every route under client.APIPath.
+// A change that breaks existing clients goes under a new version instead.
+// The method in each pattern means requests with another method get 405
+// Method Not Allowed without reaching a handler.
+func (api *API) Routes() http.Handler {
+	mux := http.NewServeMux()
+	handle := func(method, path string, handler http.HandlerFunc) {
+		mux.HandleFunc(method+" "+client.APIPath+path, handler)
+	}
+
+	handle("POST", "/signup", api.SignUpHandler)
+	handle("POST", "/login", api.LoginHandler)
+	handle("POST", "/auth/google/device", api.GoogleDeviceHandler)
+	handle("POST", "/auth/google/token", api.GoogleTokenHandler)
+	handle("POST", "/logout", api.RequireAuth(api.LogoutHandler))
+
+	handle("GET", "/entries", api.RequireAuth(api.ListEntriesHandler))
+	handle("POST", "/entries", api.RequireAuth(api.CreateEntryHandler))
+	handle("GET", "/entries/search", api.RequireAuth(api.SearchEntriesHandler))
+	handle("GET", "/entries/changes", api.RequireAuth(api.EntryChangesHandler))
+	handle("GET", "/entries/{id}", api.RequireAuth(api.GetEntryHandler))
+	handle("PUT", "/entries/{id}", api.RequireAuth(api.UpdateEntryHandler))
+	handle("DELETE", "/entries/{id}", api.RequireAuth(api.DeleteEntryHandler))
+	handle("GET", "/entries/{id}/revisions", api.RequireAuth(api.ListEntryRevisionsHandler))
+
+	handle("GET", "/tags", api.RequireAuth(api.TagsHandler))
+	handle("GET", "/stats", api.RequireAuth(api.StatsHandler))
+	handle("GET", "/stats/streak", api.RequireAuth(api.StreakHandler))
+	handle("GET", "/keys", api.RequireAuth(api.GetKeyHandler))
+	handle("PUT", "/keys", api.RequireAuth(api.PutKeyHandler))
+	handle("GET", "/settings", api.RequireAuth(api.GetSettingsHandler))
+	handle("PUT", "/settings", api.RequireAuth(api.PutSettingsHandler))
+
+	return routeErrors(mux)
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"journalCli/db"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// themeName matches the names of built-in and user themes.
+var themeName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+// settingsFieldErrors returns what is wrong with settings being saved, if
+// anything.
+func settingsFieldErrors(settings db.UserSettings) map[string]string {
+	fields := map[string]string{}
+	if settings.EditorMode != db.EditorStandard && settings.EditorMode != db.EditorVim {
+		fields["editor_mode"] = "must be standard or vim"
+	}
+	if settings.TimeZone != "" {
+		if _, err := time.LoadLocation(settings.TimeZone); err != nil {
+			fields["time_zone"] = "is not a known time zone"
+		}
+	}
+	if settings.DateFormat == "" || len(settings.DateFormat) > 64 {
+		fields["date_format"] = "must be between 1 and 64 characters"
+	}
+	if settings.ReminderTime != "" {
+		if _, err := time.Parse("15:04", settings.ReminderTime); err != nil {
+			fields["reminder_time"] = "must be a time like 20:00"
+		}
+	}
+	if !themeName.MatchString(settings.Theme) {
+		fields["theme"] = "must be a lowercase name of letters, digits and dashes"
+	}
+	return fields
+}
+
+// GetSettingsHandler returns the authenticated user's settings, the defaults
+// until they save some. Must be wrapped in RequireAuth.
+func (api *API) GetSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	settings, err := api.store.GetUserSettings(UserFromContext(r.Context()).ID)
+
+	if errors.Is(err, db.ErrNotFound) {
+		defaults := db.DefaultUserSettings()
+		settings, err = &defaults, nil
+	}
+
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(settings)
+}
+
+// PutSettingsHandler replaces the authenticated user's settings with the ones
+// in the body, which must all be given. Must be wrapped in RequireAuth.
+func (api *API) PutSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	var settingsReq db.UserSettings
+	if !decodeJSON(w, r, &settingsReq) {
+		return
+	}
+
+	if fields := settingsFieldErrors(settingsReq); len(fields) > 0 {
+		writeFieldErrors(w, r, "Settings are invalid", fields)
+		return
+	}
+
+	settings, err := api.store.PutUserSettings(UserFromContext(r.Context()).ID, settingsReq)
+
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(settings)
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"journalCli/client"
+	"journalCli/db"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// defaultStatsDays is a year, the span of the calendar heatmap.
+	defaultStatsDays = 365
+	maxStatsDays     = 5 * 366
+)
+
+// StreakHandler serves /stats/streak?tz=, how many days in a row the
+// authenticated user has written. Days are those of the IANA time zone tz, UTC
+// when it is missing, so that an entry written late in the evening counts for
+// the day the user wrote it on. Must be wrapped in RequireAuth.
+func (api *API) StreakHandler(w http.ResponseWriter, r *http.Request) {
+	loc, ok := parseTimeZone(w, r)
+	if !ok {
+		return
+	}
+
+	days, err := api.store.ListEntryDays(UserFromContext(r.Context()).ID, loc)
+
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(db.ComputeStreak(days, time.Now().In(loc)))
+}
+
+// StatsHandler serves /stats?tz=&days=, the word counts, entry counts, hours
+// and moods of the authenticated user's last days days, today included,
+// counted in the IANA time zone tz like StreakHandler. Must be wrapped in
+// RequireAuth.
+func (api *API) StatsHandler(w http.ResponseWriter, r *http.Request) {
+	loc, ok := parseTimeZone(w, r)
+	if !ok {
+		return
+	}
+
+	days := defaultStatsDays
+	if raw := r.URL.Query().Get("days"); raw != "" {
+		var err error
+		if days, err = strconv.Atoi(raw); err != nil || days <= 0 || days > maxStatsDays {
+			writeError(w, r, http.StatusBadRequest, client.CodeInvalidRequest, fmt.Sprintf("Days must be between 1 and %d", maxStatsDays))
+			return
+		}
+	}
+	now := time.Now().In(loc)
+	since := time.Date(now.Year(), now.Month(), now.Day()-(days-1), 0, 0, 0, 0, loc)
+
+	stats, err := api.store.GetStats(UserFromContext(r.Context()).ID, loc, since)
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(stats)
+}
+
+// parseTimeZone reads the tz query parameter, writing an error when it isn't
+// a known time zone.
+func parseTimeZone(w http.ResponseWriter, r *http.Request) (*time.Location, bool) {
+	tz := r.URL.Query().Get("tz")
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, client.CodeInvalidRequest, fmt.Sprintf("Unknown time zone %q", tz))
+		return nil, false
+	}
+	return loc, true
+}
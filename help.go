@@ -0,0 +1,73 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func (m *Model) openHelp() {
+	m.page = PageHelp
+	m.resizeHelpPage()
+	m.helpPage.GotoTop()
+}
+
+// resizeHelpPage fits the Help page to the window, laying the key maps out
+// again for its width.
+func (m *Model) resizeHelpPage() {
+	m.helpPage.Width = m.width
+	m.helpPage.Height = max(m.height-3, 1)
+	m.helpPage.SetContent(renderHelpSections(m.width))
+}
+
+func (m Model) updateHelp(msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, helpKeys.Back):
+		m.page = PageMenu
+		return m, nil
+	case key.Matches(msg, quitKey):
+		return m, tea.Quit
+	}
+
+	var cmd tea.Cmd
+	m.helpPage, cmd = m.helpPage.Update(msg)
+	return m, cmd
+}
+
+func renderHelp(m Model) string {
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		titleStyle.Render("❓ Help"),
+		m.helpPage.View(),
+		keyHints(helpKeyMap, m.width),
+	)
+}
+
+// renderHelpSections lays out the key maps side by side, as many in a row as
+// fit in width.
+func renderHelpSections(width int) string {
+	h := help.New()
+	h.Styles = helpStyles
+
+	var rows, row []string
+	rowWidth := 0
+	for _, k := range keyMaps {
+		section := lipgloss.NewStyle().PaddingRight(4).PaddingBottom(1).Render(lipgloss.JoinVertical(
+			lipgloss.Left,
+			// The name is what [keys] in the config file remaps it by.
+			helpTitleStyle.Render(k.title)+" "+helpStyles.ShortDesc.Render("["+k.name+"]"),
+			h.FullHelpView(k.FullHelp()),
+		))
+		if w := lipgloss.Width(section); rowWidth > 0 && rowWidth+w > width {
+			rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, row...))
+			row, rowWidth = nil, 0
+		}
+		row = append(row, section)
+		rowWidth += lipgloss.Width(section)
+	}
+	rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, row...))
+	return strings.Join(rows, "\n")
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"journalCli/client"
+	"journalCli/importer"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// importReport is what an import did, or would do with --dry-run.
+type importReport struct {
+	Path       string          `json:"path"`
+	Format     importer.Format `json:"format"`
+	DryRun     bool            `json:"dry_run"`
+	Imported   []importedEntry `json:"imported"`
+	Duplicates []string        `json:"duplicates"`
+	Failed     []importFailure `json:"failed"`
+}
+
+type importedEntry struct {
+	Source    string    `json:"source"`
+	ID        string    `json:"id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type importFailure struct {
+	Source string `json:"source"`
+	Error  string `json:"error"`
+}
+
+func importCommand(ctx context.Context, api *client.Client, args []string) error {
+	fs, asJSON := newFlagSet("import")
+	formatName := fs.String("format", "", "dayone, jrnl or markdown; guessed from the path when empty")
+	dryRun := fs.Bool("dry-run", false, "report what would be imported without importing it")
+	rest, err := parseArgs(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	path := rest[0]
+	var format importer.Format
+	if *formatName != "" {
+		format, err = importer.ParseFormat(*formatName)
+	} else {
+		format, err = importer.Detect(path)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Dates without a time zone in jrnl and Markdown files are read in the one
+	// of the Settings page, and days are compared there when deduplicating.
+	loc, err := userLocation(ctx, api)
+	if err != nil {
+		return err
+	}
+
+	vault := &cliVault{api: api}
+	seen, err := entryHashes(ctx, api, vault, loc)
+	if err != nil {
+		return err
+	}
+
+	report := importReport{
+		Path:       path,
+		Format:     format,
+		DryRun:     *dryRun,
+		Imported:   []importedEntry{},
+		Duplicates: []string{},
+		Failed:     []importFailure{},
+	}
+	err = importer.Read(format, path, loc, func(entry importer.Entry) error {
+		if entry.Err != nil {
+			report.Failed = append(report.Failed, importFailure{Source: entry.Source, Error: entry.Err.Error()})
+			return nil
+		}
+		hash := importer.Hash(entry.Body, entry.CreatedAt, loc)
+		if seen[hash] {
+			report.Duplicates = append(report.Duplicates, entry.Source)
+			return nil
+		}
+		seen[hash] = true
+
+		imported := importedEntry{Source: entry.Source, CreatedAt: entry.CreatedAt}
+		if !*dryRun {
+			created, err := importEntry(ctx, api, vault, entry)
+			var apiErr *client.APIError
+			if errors.As(err, &apiErr) && (apiErr.Code == client.CodeValidationFailed || apiErr.Code == client.CodePayloadTooLarge) {
+				report.Failed = append(report.Failed, importFailure{Source: entry.Source, Error: err.Error()})
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %w", entry.Source, err)
+			}
+			imported.ID = created.ID
+		}
+		report.Imported = append(report.Imported, imported)
+		return nil
+	})
+	if err != nil {
+		// Say how far the import got, since it can't be undone.
+		if !*dryRun && len(report.Imported) > 0 {
+			fmt.Fprintf(os.Stderr, "Imported %d entries before failing\n", len(report.Imported))
+		}
+		return err
+	}
+
+	if *asJSON {
+		return printJSON(report)
+	}
+	printImportReport(report)
+	return nil
+}
+
+// entryHashes returns the hashes of the entries already in the journal, to
+// skip importing them again.
+func entryHashes(ctx context.Context, api *client.Client, vault *cliVault, loc *time.Location) (map[string]bool, error) {
+	hashes := map[string]bool{}
+	opts := client.ListEntriesOptions{Limit: 100}
+	for {
+		page, err := api.ListEntries(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		if err := vault.openEntries(ctx, page.Entries); err != nil {
+			return nil, err
+		}
+		for _, entry := range page.Entries {
+			hashes[importer.Hash(entry.Body, entry.CreatedAt, loc)] = true
+		}
+		if page.NextCursor == "" {
+			return hashes, nil
+		}
+		opts.Cursor = page.NextCursor
+	}
+}
+
+func importEntry(ctx context.Context, api *client.Client, vault *cliVault, entry importer.Entry) (*client.Entry, error) {
+	opened, err := vault.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req := client.CreateEntryRequest{Mood: entry.Mood, CreatedAt: entry.CreatedAt}
+	if req.Body, req.Encryption, err = opened.seal(entry.Body); err != nil {
+		return nil, err
+	}
+	return api.CreateEntry(ctx, req)
+}
+
+func printImportReport(report importReport) {
+	verb, skip := "Imported", "skipped"
+	if report.DryRun {
+		verb, skip = "Would import", "skip"
+	}
+	fmt.Printf("%s %s from %s (%s)", verb, plural(len(report.Imported), "entry", "entries"), filepath.Base(report.Path), report.Format)
+	if len(report.Duplicates) > 0 {
+		fmt.Printf(", %s %s", skip, plural(len(report.Duplicates), "duplicate", "duplicates"))
+	}
+	fmt.Println()
+
+	if len(report.Failed) > 0 {
+		fmt.Printf("\nCould not import %s:\n", plural(len(report.Failed), "entry", "entries"))
+		for _, failure := range report.Failed {
+			fmt.Printf("  %s: %s\n", failure.Source, failure.Error)
+		}
+	}
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, one)
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
@@ -0,0 +1,145 @@
+package importer
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// dayOneEntry is the part of an entry of a Day One JSON export that is
+// imported. Photos, locations and weather are left out.
+type dayOneEntry struct {
+	UUID         string   `json:"uuid"`
+	CreationDate string   `json:"creationDate"`
+	Text         string   `json:"text"`
+	Tags         []string `json:"tags"`
+	Starred      bool     `json:"starred"`
+}
+
+// readDayOne reads a Day One export, either the .zip Day One writes or the
+// JSON file of a journal in it.
+func readDayOne(name string, fn func(Entry) error) error {
+	if !strings.EqualFold(filepath.Ext(name), ".zip") {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return readDayOneJSON(f, filepath.Base(name), fn)
+	}
+
+	archive, err := zip.OpenReader(name)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	found := false
+	for _, file := range archive.File {
+		// Each journal is a JSON file at the top; photos are in folders.
+		if strings.Contains(file.Name, "/") || !strings.EqualFold(path.Ext(file.Name), ".json") {
+			continue
+		}
+		found = true
+		r, err := file.Open()
+		if err != nil {
+			return err
+		}
+		err = readDayOneJSON(r, file.Name, fn)
+		r.Close()
+		if err != nil {
+			return err
+		}
+	}
+	if !found {
+		return fmt.Errorf("%s: no journal JSON file in the archive", name)
+	}
+	return nil
+}
+
+// readDayOneJSON reads the entries of a Day One journal file one at a time,
+// skipping its metadata.
+func readDayOneJSON(r io.Reader, name string, fn func(Entry) error) error {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return fmt.Errorf("%s: not a Day One export: %w", name, err)
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if key != "entries" {
+			var skipped json.RawMessage
+			if err := dec.Decode(&skipped); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			continue
+		}
+
+		if err := expectDelim(dec, '['); err != nil {
+			return fmt.Errorf("%s: entries: %w", name, err)
+		}
+		for i := 1; dec.More(); i++ {
+			var raw dayOneEntry
+			if err := dec.Decode(&raw); err != nil {
+				return fmt.Errorf("%s: entry %d: %w", name, i, err)
+			}
+			if err := fn(raw.entry(fmt.Sprintf("%s entry %d", name, i))); err != nil {
+				return err
+			}
+		}
+		if err := expectDelim(dec, ']'); err != nil {
+			return fmt.Errorf("%s: entries: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return fmt.Errorf("expected %v, found %v", delim, tok)
+	}
+	return nil
+}
+
+func (raw dayOneEntry) entry(source string) Entry {
+	entry := Entry{Source: source}
+	if raw.CreationDate == "" {
+		entry.Err = errors.New("no creation date")
+		return entry
+	}
+	created, err := time.Parse(time.RFC3339, raw.CreationDate)
+	if err != nil {
+		entry.Err = fmt.Errorf("invalid creation date %q", raw.CreationDate)
+		return entry
+	}
+	entry.CreatedAt = created
+
+	tags := raw.Tags
+	if raw.Starred {
+		tags = append(tags, "starred")
+	}
+	entry.Body = withTags(unescapeDayOne(raw.Text), tags)
+	return entry
+}
+
+// dayOneEscape matches the punctuation Day One escapes with a backslash in
+// the Markdown of exported entries, e.g. "Done\." for "Done.".
+var dayOneEscape = regexp.MustCompile(`\\([\\.!#*+\-_()\[\]{}<>|` + "`" + `])`)
+
+func unescapeDayOne(text string) string {
+	return dayOneEscape.ReplaceAllString(text, "$1")
+}
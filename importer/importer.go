@@ -0,0 +1,128 @@
+// Package importer reads journals kept with other tools so that they can be
+// imported as entries: Day One JSON exports, jrnl plain text files and
+// folders of dated Markdown files, such as the ones journalCli exports.
+//
+// Tags are kept as hashtags in the body, the way journalCli tags entries, and
+// entries are read one at a time so that large journals don't have to fit in
+// memory.
+package importer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"journalCli/utils"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+type Format string
+
+const (
+	DayOne   Format = "dayone"
+	Jrnl     Format = "jrnl"
+	Markdown Format = "markdown"
+)
+
+var Formats = []Format{DayOne, Jrnl, Markdown}
+
+func ParseFormat(value string) (Format, error) {
+	for _, format := range Formats {
+		if strings.EqualFold(value, string(format)) {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unknown import format %q, expected one of dayone, jrnl or markdown", value)
+}
+
+// Detect guesses the format of path: a directory of Markdown files, a Day One
+// .json or .zip export, or else a jrnl file.
+func Detect(path string) (Format, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case info.IsDir():
+		return Markdown, nil
+	case strings.EqualFold(filepath.Ext(path), ".json"), strings.EqualFold(filepath.Ext(path), ".zip"):
+		return DayOne, nil
+	}
+	return Jrnl, nil
+}
+
+// Entry is an entry read from another tool. Source tells where it was read
+// from for reports, e.g. a file and line. Entries that could not be read have
+// Err set; reading goes on with the next one.
+type Entry struct {
+	Source    string
+	CreatedAt time.Time
+	Body      string
+	Mood      *int
+	Err       error
+}
+
+// Read calls fn with every entry of the journal at path, in the order they
+// appear. It stops at the first error returned by fn.
+func Read(format Format, path string, loc *time.Location, fn func(Entry) error) error {
+	switch format {
+	case DayOne:
+		return readDayOne(path, fn)
+	case Jrnl:
+		return readJrnl(path, loc, fn)
+	case Markdown:
+		return readMarkdownDir(path, loc, fn)
+	}
+	return fmt.Errorf("unknown import format %q", format)
+}
+
+// Hash identifies the content of an entry to find duplicates: its body and
+// the day it was written in loc. The time of day is left out, since not every
+// format keeps it to the second.
+func Hash(body string, createdAt time.Time, loc *time.Location) string {
+	sum := sha256.Sum256([]byte(createdAt.In(loc).Format(time.DateOnly) + "\n" + normalizeBody(body)))
+	return hex.EncodeToString(sum[:])
+}
+
+func normalizeBody(body string) string {
+	return strings.TrimSpace(strings.ReplaceAll(body, "\r\n", "\n"))
+}
+
+var tagUnsafe = regexp.MustCompile(`[^\p{L}\p{N}_-]+`)
+
+// withTags returns body with the tags it doesn't already have as hashtags
+// added on a last line.
+func withTags(body string, tags []string) string {
+	body = normalizeBody(body)
+	have := map[string]bool{}
+	for _, tag := range utils.ParseHashtags(body) {
+		have[tag] = true
+	}
+
+	var missing []string
+	for _, tag := range tags {
+		tag = strings.Trim(tagUnsafe.ReplaceAllString(strings.ToLower(strings.TrimSpace(tag)), "-"), "-")
+		if tag != "" && !have[tag] {
+			have[tag] = true
+			missing = append(missing, "#"+tag)
+		}
+	}
+	if len(missing) == 0 {
+		return body
+	}
+	if body == "" {
+		return strings.Join(missing, " ")
+	}
+	return body + "\n\n" + strings.Join(missing, " ")
+}
+
+// validMood returns mood when it is on the 1 to 5 scale of entries.
+func validMood(mood int) *int {
+	if mood < 1 || mood > 5 {
+		return nil
+	}
+	return &mood
+}
@@ -0,0 +1,84 @@
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// jrnlHeader matches the line starting an entry of a jrnl file, e.g.
+// "[2024-03-01 09:30] Title", or without brackets as older versions wrote it.
+var jrnlHeader = regexp.MustCompile(`^\[?(\d{4}-\d{2}-\d{2} \d{1,2}:\d{2}(?::\d{2})?(?: ?[AaPp][Mm])?)\]? ?(.*)$`)
+
+var jrnlLayouts = []string{
+	"2006-01-02 15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 3:04 PM",
+	"2006-01-02 3:04PM",
+	"2006-01-02 3:04:05 PM",
+}
+
+// jrnlTag matches jrnl's @tags, which become hashtags. Email addresses are
+// left alone since the @ has to start a word.
+var jrnlTag = regexp.MustCompile(`(^|\s)@([\p{L}\p{N}_-]+)`)
+
+// readJrnl reads a jrnl journal file, or the text jrnl exports with
+// --export txt. Times have no zone and are read in loc.
+func readJrnl(name string, loc *time.Location, fn func(Entry) error) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var entry *Entry
+	var body []string
+	flush := func() error {
+		if entry == nil {
+			return nil
+		}
+		entry.Body = withTags(jrnlTag.ReplaceAllString(strings.Join(body, "\n"), "$1#$2"), nil)
+		return fn(*entry)
+	}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	base := filepath.Base(name)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+		match := jrnlHeader.FindStringSubmatch(text)
+		if match == nil {
+			if entry == nil && strings.TrimSpace(text) != "" {
+				return fmt.Errorf("%s:%d: expected an entry starting with a date like [2006-01-02 15:04]", base, line)
+			}
+			body = append(body, text)
+			continue
+		}
+
+		if err := flush(); err != nil {
+			return err
+		}
+		entry = &Entry{Source: fmt.Sprintf("%s:%d", base, line)}
+		body = []string{match[2]}
+		if entry.CreatedAt, err = parseJrnlTime(match[1], loc); err != nil {
+			entry.Err = err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
+
+func parseJrnlTime(value string, loc *time.Location) (time.Time, error) {
+	for _, layout := range jrnlLayouts {
+		if t, err := time.ParseInLocation(layout, strings.ToUpper(value), loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
@@ -0,0 +1,232 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// fileDate matches a date at the start of a file name, with an optional time,
+// e.g. 2024-03-01.md, 2024-03-01 0930.md or 2024-03-01T09-30 notes.md.
+var fileDate = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})(?:[ T_](\d{2})[-:.h]?(\d{2}))?`)
+
+var frontMatterDates = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	time.DateOnly,
+}
+
+// exportedTime and exportedDetails match how journalCli exports the entries of
+// a day, so that its own exports can be imported back.
+var (
+	exportedTime    = regexp.MustCompile(`^## (\d{2}:\d{2})$`)
+	exportedDetails = regexp.MustCompile(`^\*(?:Mood (\d)/5)?(?: · )?(?:#[^*]+)?\*$`)
+)
+
+// readMarkdownDir reads every Markdown file under dir as an entry, dated by
+// its front matter or else by its name. Files journalCli exported hold the
+// entries of a day and are split back into them.
+func readMarkdownDir(dir string, loc *time.Location, fn func(Entry) error) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// Skip .git, .obsidian and the like.
+		if d.IsDir() && path != dir && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		if d.IsDir() || (ext != ".md" && ext != ".markdown") {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		source, err := filepath.Rel(dir, path)
+		if err != nil {
+			source = path
+		}
+		for _, entry := range markdownEntries(source, string(content), loc) {
+			if err := fn(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func markdownEntries(source, content string, loc *time.Location) []Entry {
+	meta, body := parseFrontMatter(strings.ReplaceAll(content, "\r\n", "\n"))
+	entry := Entry{Source: source}
+
+	date, err := markdownDate(meta, filepath.Base(source), loc)
+	if err != nil {
+		entry.Err = err
+		return []Entry{entry}
+	}
+	if _, exported := meta["entries"]; exported {
+		return exportedEntries(source, body, date)
+	}
+
+	entry.CreatedAt = date
+	if moods := meta["mood"]; len(moods) == 1 {
+		if mood, err := strconv.ParseFloat(moods[0], 64); err == nil {
+			entry.Mood = validMood(int(math.Round(mood)))
+		}
+	}
+	entry.Body = withTags(body, splitTags(meta["tags"]))
+	if entry.Body == "" {
+		entry.Err = errors.New("empty file")
+	}
+	return []Entry{entry}
+}
+
+// markdownDate returns the date of a file from its front matter, or else from
+// its name. Dates without a time are at midnight in loc.
+func markdownDate(meta map[string][]string, name string, loc *time.Location) (time.Time, error) {
+	if dates := meta["date"]; len(dates) == 1 {
+		for _, layout := range frontMatterDates {
+			if date, err := time.ParseInLocation(layout, dates[0], loc); err == nil {
+				return date, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("invalid date %q in front matter", dates[0])
+	}
+
+	match := fileDate.FindStringSubmatch(name)
+	if match == nil {
+		return time.Time{}, errors.New("no date in front matter or file name")
+	}
+	value, layout := match[1], time.DateOnly
+	if match[2] != "" {
+		value, layout = value+" "+match[2]+match[3], "2006-01-02 1504"
+	}
+	date, err := time.ParseInLocation(layout, value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date in file name %q", name)
+	}
+	return date, nil
+}
+
+// exportedEntries splits a day journalCli exported into its entries, one per
+// "## 15:04" section.
+func exportedEntries(source, body string, day time.Time) []Entry {
+	var entries []Entry
+	var lines []string
+	var current *Entry
+	flush := func() {
+		if current == nil {
+			return
+		}
+		if len(lines) > 0 {
+			if details := exportedDetails.FindStringSubmatch(lines[0]); details != nil {
+				lines = lines[1:]
+				if mood, err := strconv.Atoi(details[1]); err == nil {
+					current.Mood = validMood(mood)
+				}
+			}
+		}
+		current.Body = normalizeBody(strings.Join(lines, "\n"))
+		if current.Body == "" {
+			current.Err = errors.New("empty entry")
+		}
+		entries = append(entries, *current)
+	}
+
+	for _, line := range strings.Split(body, "\n") {
+		match := exportedTime.FindStringSubmatch(line)
+		if match == nil {
+			// The "# Monday, 2 January 2006" title before the first entry is
+			// dropped with the rest of what comes before it.
+			if current != nil && (len(lines) > 0 || strings.TrimSpace(line) != "") {
+				lines = append(lines, line)
+			}
+			continue
+		}
+		flush()
+		clock, _ := time.Parse("15:04", match[1])
+		created := time.Date(day.Year(), day.Month(), day.Day(), clock.Hour(), clock.Minute(), 0, 0, day.Location())
+		current = &Entry{Source: source + " " + match[1], CreatedAt: created}
+		lines = nil
+	}
+	flush()
+
+	if len(entries) == 0 {
+		return []Entry{{Source: source, Err: errors.New("no entries")}}
+	}
+	return entries
+}
+
+// splitTags splits tags written on one line, as in "tags: work, garden".
+func splitTags(values []string) []string {
+	var tags []string
+	for _, value := range values {
+		tags = append(tags, strings.Split(value, ",")...)
+	}
+	return tags
+}
+
+// parseFrontMatter splits the YAML front matter off a Markdown file. Only what
+// journals use is read: scalars, and lists written as [a, b] or as "- a"
+// lines. Values are unquoted.
+func parseFrontMatter(content string) (map[string][]string, string) {
+	meta := map[string][]string{}
+	rest, ok := strings.CutPrefix(content, "---\n")
+	if !ok {
+		return meta, content
+	}
+	header, body, ok := strings.Cut(rest, "\n---\n")
+	if !ok {
+		if header, ok = strings.CutSuffix(rest, "\n---"); !ok {
+			return meta, content
+		}
+	}
+
+	var key string
+	for _, line := range strings.Split(header, "\n") {
+		if item, ok := strings.CutPrefix(strings.TrimSpace(line), "- "); ok && key != "" {
+			meta[key] = append(meta[key], unquote(item))
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(name))
+		value = strings.TrimSpace(value)
+		switch {
+		case value == "":
+			meta[key] = nil
+		case strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]"):
+			meta[key] = []string{}
+			for _, item := range strings.Split(value[1:len(value)-1], ",") {
+				if item = unquote(item); item != "" {
+					meta[key] = append(meta[key], item)
+				}
+			}
+		default:
+			meta[key] = []string{unquote(value)}
+		}
+	}
+	return meta, body
+}
+
+func unquote(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
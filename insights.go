@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"journalCli/client"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// heatmapWeeks is the most weeks the calendar shows, a year like GitHub's.
+const heatmapWeeks = 53
+
+var (
+	// heatmapColors shade a day by how much was written on it, from nothing
+	// to the busiest day. They and the styles below are set by applyTheme.
+	heatmapColors []lipgloss.TerminalColor
+	sparkBlocks   = []rune("▁▂▃▄▅▆▇█")
+
+	insightsLabelStyle lipgloss.Style
+	insightsValueStyle lipgloss.Style
+	insightsBarStyle   lipgloss.Style
+)
+
+type StatsLoadedMsg struct {
+	Stats *client.Stats
+}
+
+func fetchStats(api *client.Client, tz string) tea.Msg {
+	// The calendar's first Sunday is at most 53 weeks less a day ago.
+	stats, err := api.Stats(context.Background(), tz, heatmapWeeks*7)
+	if err != nil {
+		return ErrMsg{err}
+	}
+	return StatsLoadedMsg{Stats: stats}
+}
+
+func (m *Model) openInsights() tea.Cmd {
+	m.page = PageInsights
+	m.stats = nil
+	m.err = nil
+	api, tz := m.api, localTimeZone(m.location)
+	return func() tea.Msg { return fetchStats(api, tz) }
+}
+
+func (m Model) updateInsights(msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, insightsKeys.Back):
+		m.page = PageMenu
+		m.err = nil
+	case key.Matches(msg, insightsKeys.Refresh):
+		return m, m.openInsights()
+	case key.Matches(msg, quitKey):
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func renderInsights(m Model) string {
+	title := titleStyle.Render("📊 Insights")
+	footer := keyHints(insightsKeyMap, m.width)
+
+	var sections []string
+	switch {
+	case m.err != nil:
+		sections = []string{errorStyle.Render(fmt.Sprintf("Error: %v", m.err))}
+	case m.stats == nil:
+		sections = []string{"Loading insights..."}
+	case m.stats.Entries == 0:
+		sections = []string{"Nothing written in the last year yet."}
+	default:
+		sections = insightsSections(m)
+	}
+
+	// Sections that don't fit the window are left out, the least important
+	// ones first.
+	room := m.height - lipgloss.Height(title) - lipgloss.Height(footer) - 1
+	content := sections[0]
+	for _, section := range sections[1:] {
+		next := lipgloss.JoinVertical(lipgloss.Left, content, "", section)
+		if m.height > 0 && lipgloss.Height(next) > room {
+			break
+		}
+		content = next
+	}
+
+	page := lipgloss.JoinVertical(lipgloss.Left, title, content, "", footer)
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Top, page)
+}
+
+// insightsSections returns the parts of the Insights page, most important
+// first.
+func insightsSections(m Model) []string {
+	now := m.currentTime.In(m.location)
+	stats := m.stats
+	days := map[string]client.DayStats{}
+	for _, day := range stats.Days {
+		days[day.Date] = day
+	}
+
+	summary := []string{
+		plural(stats.Entries, "entry", "entries"),
+		plural(stats.Words, "word", "words"),
+		plural(len(stats.Days), "day", "days") + " written on",
+	}
+	if stats.MoodAverage != nil {
+		summary = append(summary, fmt.Sprintf("mood %.1f/5", *stats.MoodAverage))
+	}
+
+	sections := []string{
+		insightsValueStyle.Width(m.width).Render(strings.Join(summary, " · ")),
+		renderHeatmap(days, now, m.width),
+		renderMoodTrend(days, now, m.width),
+	}
+	hours, tags := renderHours(stats.Hours), renderTopTags(stats.Tags, m.width)
+	// Side by side when there is room, the tags under the hours otherwise.
+	if side := lipgloss.JoinHorizontal(lipgloss.Top, hours, "    ", tags); lipgloss.Width(side) <= m.width {
+		return append(sections, side)
+	}
+	return append(sections, hours, tags)
+}
+
+// renderHeatmap draws a GitHub-style calendar of the weeks that fit in width,
+// a column per week from Sunday to Saturday, shading days by entries written.
+func renderHeatmap(days map[string]client.DayStats, now time.Time, width int) string {
+	const labelWidth = 4
+	weeks := min(heatmapWeeks, max(1, (width-labelWidth)/2))
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	start := today.AddDate(0, 0, -int(today.Weekday())-(weeks-1)*7)
+
+	busiest := 0
+	for _, day := range days {
+		busiest = max(busiest, day.Entries)
+	}
+
+	months := []rune(strings.Repeat(" ", labelWidth+weeks*2+2))
+	rows := make([]string, 7)
+	for weekday := range rows {
+		label := ""
+		if weekday%2 == 1 {
+			label = time.Weekday(weekday).String()[:3]
+		}
+		rows[weekday] = insightsLabelStyle.Render(fmt.Sprintf("%-*s", labelWidth, label))
+	}
+
+	for week := range weeks {
+		first := start.AddDate(0, 0, week*7)
+		// A month is labelled over the first week starting in it, when the
+		// label doesn't run into the one before.
+		if first.Day() <= 7 && week < weeks-1 {
+			col := labelWidth + week*2
+			if strings.TrimSpace(string(months[max(0, col-1):col+3])) == "" {
+				copy(months[col:], []rune(first.Month().String()[:3]))
+			}
+		}
+		for weekday := range rows {
+			day := first.AddDate(0, 0, weekday)
+			if day.After(today) {
+				continue
+			}
+			level := 0
+			if entries := days[day.Format(time.DateOnly)].Entries; entries > 0 {
+				level = max(1, int(math.Ceil(float64(entries)/float64(busiest)*4)))
+			}
+			rows[weekday] += lipgloss.NewStyle().Foreground(heatmapColors[level]).Render("■") + " "
+		}
+	}
+
+	legend := []string{insightsLabelStyle.Render("Less ")}
+	for _, color := range heatmapColors {
+		legend = append(legend, lipgloss.NewStyle().Foreground(color).Render("■")+" ")
+	}
+	legend = append(legend, insightsLabelStyle.Render("More"))
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		insightsLabelStyle.Render(strings.TrimRight(string(months), " ")),
+		strings.Join(rows, "\n"),
+		strings.Repeat(" ", labelWidth)+strings.Join(legend, ""),
+	)
+}
+
+// renderMoodTrend is a sparkline of the daily mood average of the days that
+// fit in width, leaving days without a mood blank.
+func renderMoodTrend(days map[string]client.DayStats, now time.Time, width int) string {
+	const label = "Mood "
+	n := min(heatmapWeeks*7, max(7, width-len(label)-2))
+	line := make([]rune, n)
+	moods := 0
+	for i := range line {
+		day := days[now.AddDate(0, 0, i-n+1).Format(time.DateOnly)]
+		if day.Mood == nil {
+			line[i] = ' '
+			continue
+		}
+		moods++
+		line[i] = spark(*day.Mood-1, 4)
+	}
+	if moods == 0 {
+		return insightsLabelStyle.Render(label) + "no moods recorded"
+	}
+	return lipgloss.JoinVertical(lipgloss.Left,
+		insightsLabelStyle.Render(label)+insightsBarStyle.Render(string(line)),
+		insightsLabelStyle.Render(fmt.Sprintf("%*s", len(label)+n, fmt.Sprintf("last %d days", n))),
+	)
+}
+
+// renderHours is a sparkline of the hours of the day entries were written at.
+func renderHours(hours [24]int) string {
+	busiest := 0
+	for _, count := range hours {
+		busiest = max(busiest, count)
+	}
+	line := []rune{}
+	for _, count := range hours {
+		block := ' '
+		if count > 0 {
+			block = spark(float64(count), float64(busiest))
+		}
+		line = append(line, block, ' ')
+	}
+	return lipgloss.JoinVertical(lipgloss.Left,
+		insightsLabelStyle.Render("When you write"),
+		insightsBarStyle.Render(string(line)),
+		insightsLabelStyle.Render(fmt.Sprintf("%-12s%-12s%-12s%s", "0h", "6h", "12h", "18h")),
+	)
+}
+
+// renderTopTags is a bar chart of the most used tags.
+func renderTopTags(tags []client.TagCount, width int) string {
+	if len(tags) == 0 {
+		return insightsLabelStyle.Render("No tags yet")
+	}
+	nameWidth := 0
+	for _, tag := range tags {
+		nameWidth = max(nameWidth, lipgloss.Width(tag.Name)+1)
+	}
+	barWidth := max(5, min(30, width-nameWidth-8))
+
+	rows := []string{insightsLabelStyle.Render("Top tags")}
+	for _, tag := range tags {
+		bar := strings.Repeat("█", max(1, tag.Count*barWidth/tags[0].Count))
+		rows = append(rows, fmt.Sprintf("%-*s %s %d", nameWidth, "#"+tag.Name, insightsBarStyle.Render(bar), tag.Count))
+	}
+	return strings.Join(rows, "\n")
+}
+
+// spark returns the block for value out of top, the lowest for anything at or
+// under zero.
+func spark(value, top float64) rune {
+	if top <= 0 || value <= 0 {
+		return sparkBlocks[0]
+	}
+	i := int(math.Round(value / top * float64(len(sparkBlocks)-1)))
+	return sparkBlocks[min(i, len(sparkBlocks)-1)]
+}
@@ -0,0 +1,506 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// keyMap is the key bindings of a page, or of a prompt shown on one, in the
+// order they are listed. The page's footer and the Help page are both built
+// from it, so neither can go out of date.
+type keyMap struct {
+	// name is the table of the config's [keys] that remaps the bindings.
+	name     string
+	title    string
+	bindings []binding
+}
+
+// binding is a key binding in a key map, with the name the config remaps it
+// by. Bindings without a name can't be remapped there.
+type binding struct {
+	name string
+	*key.Binding
+}
+
+func (k keyMap) ShortHelp() []key.Binding {
+	bindings := make([]key.Binding, len(k.bindings))
+	for i, b := range k.bindings {
+		bindings[i] = *b.Binding
+	}
+	return bindings
+}
+
+func (k keyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.ShortHelp()}
+}
+
+// quitKey quits from any page, even while typing.
+var quitKey = key.NewBinding(key.WithKeys("ctrl+c"), key.WithHelp("ctrl+c", "quit"))
+
+var loginKeys = struct {
+	Submit, NextField, Signup, Google key.Binding
+}{
+	Submit:    key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "log in")),
+	NextField: key.NewBinding(key.WithKeys("tab", "down", "up"), key.WithHelp("tab/↑/↓", "next field")),
+	Signup:    key.NewBinding(key.WithKeys("ctrl+s"), key.WithHelp("ctrl+s", "sign up instead")),
+	Google:    key.NewBinding(key.WithKeys("ctrl+g"), key.WithHelp("ctrl+g", "log in with Google")),
+}
+
+var signupKeys = struct {
+	Submit, NextField, PrevField, Login, Google key.Binding
+}{
+	Submit:    key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "sign up")),
+	NextField: key.NewBinding(key.WithKeys("tab", "down"), key.WithHelp("tab/↓", "next field")),
+	PrevField: key.NewBinding(key.WithKeys("up"), key.WithHelp("↑", "previous field")),
+	Login:     key.NewBinding(key.WithKeys("ctrl+l"), key.WithHelp("ctrl+l", "log in instead")),
+	Google:    key.NewBinding(key.WithKeys("ctrl+g"), key.WithHelp("ctrl+g", "sign up with Google")),
+}
+
+var googleKeys = struct {
+	Cancel key.Binding
+}{
+	Cancel: key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "cancel")),
+}
+
+var menuKeys = struct {
+	Journal, Read, Settings, Help, Insights, Logout, Quit key.Binding
+}{
+	Journal:  key.NewBinding(key.WithKeys("1"), key.WithHelp("1", "Journal")),
+	Read:     key.NewBinding(key.WithKeys("2"), key.WithHelp("2", "Read")),
+	Settings: key.NewBinding(key.WithKeys("3"), key.WithHelp("3", "Settings")),
+	Help:     key.NewBinding(key.WithKeys("4"), key.WithHelp("4", "Help")),
+	Insights: key.NewBinding(key.WithKeys("5"), key.WithHelp("5", "Insights")),
+	Logout:   key.NewBinding(key.WithKeys("l"), key.WithHelp("l", "Logout")),
+	Quit:     key.NewBinding(key.WithKeys("q"), key.WithHelp("q", "Quit")),
+}
+
+var journalKeys = struct {
+	Save, Back key.Binding
+}{
+	Save: key.NewBinding(key.WithKeys("ctrl+s"), key.WithHelp("ctrl+s", "save")),
+	Back: key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "back")),
+}
+
+// vimKeys are the keys of the journal editor's normal and visual modes when
+// the editor mode setting is vim. Typed twice, Delete and Yank act on the
+// line under the cursor and Top goes to the first line.
+var vimKeys = struct {
+	Left, Down, Up, Right, WordForward, WordBackward, LineStart, LineEnd, Top, Bottom key.Binding
+	Insert, InsertStart, Append, AppendEnd, OpenBelow, OpenAbove, Visual, VisualLine  key.Binding
+	DeleteChar, Delete, Yank, Paste, PasteBefore, Command, Normal                     key.Binding
+}{
+	Left:         key.NewBinding(key.WithKeys("h", "left"), key.WithHelp("h", "left")),
+	Down:         key.NewBinding(key.WithKeys("j", "down"), key.WithHelp("j", "down")),
+	Up:           key.NewBinding(key.WithKeys("k", "up"), key.WithHelp("k", "up")),
+	Right:        key.NewBinding(key.WithKeys("l", "right"), key.WithHelp("l", "right")),
+	WordForward:  key.NewBinding(key.WithKeys("w"), key.WithHelp("w", "next word")),
+	WordBackward: key.NewBinding(key.WithKeys("b"), key.WithHelp("b", "previous word")),
+	LineStart:    key.NewBinding(key.WithKeys("0", "home"), key.WithHelp("0", "line start")),
+	LineEnd:      key.NewBinding(key.WithKeys("$", "end"), key.WithHelp("$", "line end")),
+	Top:          key.NewBinding(key.WithKeys("g"), key.WithHelp("gg", "first line")),
+	Bottom:       key.NewBinding(key.WithKeys("G"), key.WithHelp("G", "last line")),
+	Insert:       key.NewBinding(key.WithKeys("i"), key.WithHelp("i", "insert")),
+	InsertStart:  key.NewBinding(key.WithKeys("I"), key.WithHelp("I", "insert at line start")),
+	Append:       key.NewBinding(key.WithKeys("a"), key.WithHelp("a", "append")),
+	AppendEnd:    key.NewBinding(key.WithKeys("A"), key.WithHelp("A", "append at line end")),
+	OpenBelow:    key.NewBinding(key.WithKeys("o"), key.WithHelp("o", "open line below")),
+	OpenAbove:    key.NewBinding(key.WithKeys("O"), key.WithHelp("O", "open line above")),
+	Visual:       key.NewBinding(key.WithKeys("v"), key.WithHelp("v", "visual")),
+	VisualLine:   key.NewBinding(key.WithKeys("V"), key.WithHelp("V", "visual line")),
+	DeleteChar:   key.NewBinding(key.WithKeys("x"), key.WithHelp("x", "delete character")),
+	Delete:       key.NewBinding(key.WithKeys("d"), key.WithHelp("dd", "delete line or selection")),
+	Yank:         key.NewBinding(key.WithKeys("y"), key.WithHelp("yy", "yank line or selection")),
+	Paste:        key.NewBinding(key.WithKeys("p"), key.WithHelp("p", "paste after")),
+	PasteBefore:  key.NewBinding(key.WithKeys("P"), key.WithHelp("P", "paste before")),
+	Command:      key.NewBinding(key.WithKeys(":"), key.WithHelp(":", ":w save, :q back, :wq both, :q! discard")),
+	Normal:       key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "normal mode")),
+}
+
+var moodKeys = struct {
+	Prev, Next, Pick, Save, SaveWithout, Back key.Binding
+}{
+	Prev:        key.NewBinding(key.WithKeys("left", "h"), key.WithHelp("←/h", "sadder")),
+	Next:        key.NewBinding(key.WithKeys("right", "l"), key.WithHelp("→/l", "happier")),
+	Pick:        key.NewBinding(key.WithKeys("1", "2", "3", "4", "5"), key.WithHelp("1-5", "pick")),
+	Save:        key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "save")),
+	SaveWithout: key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "save without mood")),
+	Back:        key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "keep writing")),
+}
+
+var readKeys = struct {
+	Up, Down, PrevPage, NextPage, Open, Edit, Delete, Revisions, Search, Tags, Back key.Binding
+}{
+	Up:   key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", "up")),
+	Down: key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "down")),
+	// "b" goes back to the menu, so it can't also mean previous page.
+	PrevPage:  key.NewBinding(key.WithKeys("left", "h", "pgup"), key.WithHelp("←/h/pgup", "prev page")),
+	NextPage:  key.NewBinding(key.WithKeys("right", "l", "pgdown"), key.WithHelp("→/l/pgdn", "next page")),
+	Open:      key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "open")),
+	Edit:      key.NewBinding(key.WithKeys("e"), key.WithHelp("e", "edit")),
+	Delete:    key.NewBinding(key.WithKeys("d"), key.WithHelp("d", "delete")),
+	Revisions: key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "revisions")),
+	Search:    key.NewBinding(key.WithKeys("/"), key.WithHelp("/", "search")),
+	Tags:      key.NewBinding(key.WithKeys("t"), key.WithHelp("t", "filter by tag")),
+	Back:      key.NewBinding(key.WithKeys("esc", "b"), key.WithHelp("esc/b", "back")),
+}
+
+// scrollKey stands for the keys of a viewport, which handles them itself.
+var scrollKey = key.NewBinding(key.WithKeys("up", "down", "pgup", "pgdown"), key.WithHelp("↑/↓/pgup/pgdn", "scroll"))
+
+var entryKeys = struct {
+	Edit, Back key.Binding
+}{
+	Edit: key.NewBinding(key.WithKeys("e"), key.WithHelp("e", "edit")),
+	Back: key.NewBinding(key.WithKeys("esc", "b", "q"), key.WithHelp("esc/b/q", "back")),
+}
+
+var deleteKeys = struct {
+	Confirm, Cancel key.Binding
+}{
+	Confirm: key.NewBinding(key.WithKeys("y"), key.WithHelp("y", "delete")),
+	// Any other key cancels too.
+	Cancel: key.NewBinding(key.WithKeys("n", "esc"), key.WithHelp("n/esc", "keep it")),
+}
+
+var revisionKeys = struct {
+	Up, Down, Mark, Diff, Restore, Back key.Binding
+}{
+	Up:      key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", "up")),
+	Down:    key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "down")),
+	Mark:    key.NewBinding(key.WithKeys(" "), key.WithHelp("space", "mark")),
+	Diff:    key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "diff with marked or current")),
+	Restore: key.NewBinding(key.WithKeys("R"), key.WithHelp("shift+r", "restore")),
+	Back:    key.NewBinding(key.WithKeys("esc", "b", "q"), key.WithHelp("esc/b/q", "back")),
+}
+
+var diffKeys = struct {
+	Back key.Binding
+}{
+	Back: key.NewBinding(key.WithKeys("esc", "b", "q"), key.WithHelp("esc/b/q", "back")),
+}
+
+var searchKeys = struct {
+	Search, Cancel key.Binding
+}{
+	Search: key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "search, or show all when empty")),
+	Cancel: key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "cancel")),
+}
+
+var tagKeys = struct {
+	Up, Down, Filter, Back key.Binding
+}{
+	Up:     key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", "up")),
+	Down:   key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "down")),
+	Filter: key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "filter")),
+	Back:   key.NewBinding(key.WithKeys("esc", "b", "q"), key.WithHelp("esc/b/q", "back")),
+}
+
+var settingsKeys = struct {
+	Up, Down, Prev, Next, Activate, Save, Back, Encrypt, Unlock, Passphrase, Lock, Export key.Binding
+}{
+	Up:         key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", "up")),
+	Down:       key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "down")),
+	Prev:       key.NewBinding(key.WithKeys("left"), key.WithHelp("←", "prev option")),
+	Next:       key.NewBinding(key.WithKeys("right", " "), key.WithHelp("→/space", "next option")),
+	Activate:   key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "edit")),
+	Save:       key.NewBinding(key.WithKeys("ctrl+s"), key.WithHelp("ctrl+s", "save")),
+	Back:       key.NewBinding(key.WithKeys("esc", "b"), key.WithHelp("esc/b", "back")),
+	Encrypt:    key.NewBinding(key.WithKeys("e"), key.WithHelp("e", "enable encryption")),
+	Unlock:     key.NewBinding(key.WithKeys("u"), key.WithHelp("u", "unlock")),
+	Passphrase: key.NewBinding(key.WithKeys("p"), key.WithHelp("p", "change passphrase")),
+	Lock:       key.NewBinding(key.WithKeys("l"), key.WithHelp("l", "lock")),
+	Export:     key.NewBinding(key.WithKeys("x"), key.WithHelp("x", "export")),
+}
+
+var settingInputKeys = struct {
+	Confirm, Cancel key.Binding
+}{
+	Confirm: key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "confirm")),
+	Cancel:  key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "cancel")),
+}
+
+var cryptoKeys = struct {
+	NextField, PrevField, Submit, Cancel key.Binding
+}{
+	NextField: key.NewBinding(key.WithKeys("tab", "down"), key.WithHelp("tab/↓", "next field")),
+	PrevField: key.NewBinding(key.WithKeys("shift+tab", "up"), key.WithHelp("shift+tab/↑", "previous field")),
+	Submit:    key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "submit")),
+	Cancel:    key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "cancel")),
+}
+
+var exportKeys = struct {
+	NextFormat, PrevFormat, Export, Cancel key.Binding
+}{
+	NextFormat: key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "next format")),
+	PrevFormat: key.NewBinding(key.WithKeys("shift+tab"), key.WithHelp("shift+tab", "previous format")),
+	Export:     key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "export")),
+	Cancel:     key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "cancel")),
+}
+
+var insightsKeys = struct {
+	Refresh, Back key.Binding
+}{
+	Refresh: key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "refresh")),
+	Back:    key.NewBinding(key.WithKeys("esc", "b"), key.WithHelp("esc/b", "back")),
+}
+
+var helpKeys = struct {
+	Back key.Binding
+}{
+	Back: key.NewBinding(key.WithKeys("esc", "b"), key.WithHelp("esc/b", "back")),
+}
+
+var (
+	globalKeyMap = keyMap{"global", "Everywhere", []binding{
+		{"quit", &quitKey},
+	}}
+	loginKeyMap = keyMap{"login", "Login", []binding{
+		{"submit", &loginKeys.Submit},
+		{"next_field", &loginKeys.NextField},
+		{"signup", &loginKeys.Signup},
+		{"google", &loginKeys.Google},
+	}}
+	signupKeyMap = keyMap{"signup", "Sign Up", []binding{
+		{"submit", &signupKeys.Submit},
+		{"next_field", &signupKeys.NextField},
+		{"prev_field", &signupKeys.PrevField},
+		{"login", &signupKeys.Login},
+		{"google", &signupKeys.Google},
+	}}
+	googleKeyMap = keyMap{"google", "Login with Google", []binding{
+		{"cancel", &googleKeys.Cancel},
+	}}
+	menuKeyMap = keyMap{"menu", "Menu", []binding{
+		{"journal", &menuKeys.Journal},
+		{"read", &menuKeys.Read},
+		{"settings", &menuKeys.Settings},
+		{"help", &menuKeys.Help},
+		{"insights", &menuKeys.Insights},
+		{"logout", &menuKeys.Logout},
+		{"quit", &menuKeys.Quit},
+	}}
+	journalKeyMap = keyMap{"journal", "Journal", []binding{
+		{"save", &journalKeys.Save},
+		{"back", &journalKeys.Back},
+		{"", &quitKey},
+	}}
+	vimKeyMap = keyMap{"vim", "Journal in Vim Mode", []binding{
+		{"left", &vimKeys.Left},
+		{"down", &vimKeys.Down},
+		{"up", &vimKeys.Up},
+		{"right", &vimKeys.Right},
+		{"word_forward", &vimKeys.WordForward},
+		{"word_backward", &vimKeys.WordBackward},
+		{"line_start", &vimKeys.LineStart},
+		{"line_end", &vimKeys.LineEnd},
+		{"top", &vimKeys.Top},
+		{"bottom", &vimKeys.Bottom},
+		{"insert", &vimKeys.Insert},
+		{"insert_start", &vimKeys.InsertStart},
+		{"append", &vimKeys.Append},
+		{"append_end", &vimKeys.AppendEnd},
+		{"open_below", &vimKeys.OpenBelow},
+		{"open_above", &vimKeys.OpenAbove},
+		{"visual", &vimKeys.Visual},
+		{"visual_line", &vimKeys.VisualLine},
+		{"delete_char", &vimKeys.DeleteChar},
+		{"delete", &vimKeys.Delete},
+		{"yank", &vimKeys.Yank},
+		{"paste", &vimKeys.Paste},
+		{"paste_before", &vimKeys.PasteBefore},
+		{"command", &vimKeys.Command},
+		{"normal", &vimKeys.Normal},
+	}}
+	moodKeyMap = keyMap{"mood", "Mood", []binding{
+		{"prev", &moodKeys.Prev},
+		{"next", &moodKeys.Next},
+		{"pick", &moodKeys.Pick},
+		{"save", &moodKeys.Save},
+		{"save_without", &moodKeys.SaveWithout},
+		{"back", &moodKeys.Back},
+	}}
+	readKeyMap = keyMap{"entries", "Entries", []binding{
+		{"up", &readKeys.Up},
+		{"down", &readKeys.Down},
+		{"prev_page", &readKeys.PrevPage},
+		{"next_page", &readKeys.NextPage},
+		{"open", &readKeys.Open},
+		{"edit", &readKeys.Edit},
+		{"delete", &readKeys.Delete},
+		{"revisions", &readKeys.Revisions},
+		{"search", &readKeys.Search},
+		{"tags", &readKeys.Tags},
+		{"back", &readKeys.Back},
+	}}
+	entryKeyMap = keyMap{"entry", "Entry", []binding{
+		{"", &scrollKey},
+		{"edit", &entryKeys.Edit},
+		{"back", &entryKeys.Back},
+	}}
+	deleteKeyMap = keyMap{"delete", "Delete Entry", []binding{
+		{"confirm", &deleteKeys.Confirm},
+		{"cancel", &deleteKeys.Cancel},
+	}}
+	revisionKeyMap = keyMap{"revisions", "Revisions", []binding{
+		{"up", &revisionKeys.Up},
+		{"down", &revisionKeys.Down},
+		{"mark", &revisionKeys.Mark},
+		{"diff", &revisionKeys.Diff},
+		{"restore", &revisionKeys.Restore},
+		{"back", &revisionKeys.Back},
+	}}
+	diffKeyMap = keyMap{"diff", "Diff", []binding{
+		{"", &scrollKey},
+		{"back", &diffKeys.Back},
+	}}
+	searchKeyMap = keyMap{"search", "Search", []binding{
+		{"search", &searchKeys.Search},
+		{"cancel", &searchKeys.Cancel},
+	}}
+	tagKeyMap = keyMap{"tags", "Tags", []binding{
+		{"up", &tagKeys.Up},
+		{"down", &tagKeys.Down},
+		{"filter", &tagKeys.Filter},
+		{"back", &tagKeys.Back},
+	}}
+	settingsKeyMap = keyMap{"settings", "Settings", []binding{
+		{"up", &settingsKeys.Up},
+		{"down", &settingsKeys.Down},
+		{"prev", &settingsKeys.Prev},
+		{"next", &settingsKeys.Next},
+		{"save", &settingsKeys.Save},
+		{"back", &settingsKeys.Back},
+		{"activate", &settingsKeys.Activate},
+		{"encrypt", &settingsKeys.Encrypt},
+		{"unlock", &settingsKeys.Unlock},
+		{"passphrase", &settingsKeys.Passphrase},
+		{"lock", &settingsKeys.Lock},
+		{"export", &settingsKeys.Export},
+	}}
+	settingInputKeyMap = keyMap{"setting_input", "Editing a Setting", []binding{
+		{"confirm", &settingInputKeys.Confirm},
+		{"cancel", &settingInputKeys.Cancel},
+	}}
+	cryptoKeyMap = keyMap{"passphrase", "Passphrase", []binding{
+		{"next_field", &cryptoKeys.NextField},
+		{"prev_field", &cryptoKeys.PrevField},
+		{"submit", &cryptoKeys.Submit},
+		{"cancel", &cryptoKeys.Cancel},
+	}}
+	exportKeyMap = keyMap{"export", "Export", []binding{
+		{"next_format", &exportKeys.NextFormat},
+		{"prev_format", &exportKeys.PrevFormat},
+		{"export", &exportKeys.Export},
+		{"cancel", &exportKeys.Cancel},
+	}}
+	insightsKeyMap = keyMap{"insights", "Insights", []binding{
+		{"refresh", &insightsKeys.Refresh},
+		{"back", &insightsKeys.Back},
+	}}
+	helpKeyMap = keyMap{"help", "Help", []binding{
+		{"", &scrollKey},
+		{"back", &helpKeys.Back},
+	}}
+)
+
+// keyMaps are all the key maps, in the order the Help page shows them.
+var keyMaps = []*keyMap{
+	&globalKeyMap, &loginKeyMap, &signupKeyMap, &googleKeyMap, &menuKeyMap, &journalKeyMap, &vimKeyMap, &moodKeyMap,
+	&readKeyMap, &entryKeyMap, &deleteKeyMap, &revisionKeyMap, &diffKeyMap, &searchKeyMap, &tagKeyMap,
+	&settingsKeyMap, &settingInputKeyMap, &cryptoKeyMap, &exportKeyMap, &insightsKeyMap, &helpKeyMap,
+}
+
+// remapKeys replaces the keys of the bindings named in the config's [keys]
+// table, a table of key maps holding a list of keys per binding. An empty
+// list turns a binding off.
+func remapKeys(remaps map[string]map[string][]string) error {
+	var errs []error
+	for _, mapName := range slices.Sorted(maps.Keys(remaps)) {
+		i := slices.IndexFunc(keyMaps, func(k *keyMap) bool { return k.name == mapName })
+		if i < 0 {
+			errs = append(errs, fmt.Errorf("keys.%s: unknown key map, expected one of %s", mapName, strings.Join(keyMapNames(), ", ")))
+			continue
+		}
+		k := keyMaps[i]
+
+		for _, name := range slices.Sorted(maps.Keys(remaps[mapName])) {
+			j := slices.IndexFunc(k.bindings, func(b binding) bool { return b.name != "" && b.name == name })
+			if j < 0 {
+				errs = append(errs, fmt.Errorf("keys.%s.%s: unknown binding, expected one of %s", mapName, name, strings.Join(k.names(), ", ")))
+				continue
+			}
+			remapBinding(k.bindings[j].Binding, remaps[mapName][name])
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func remapBinding(b *key.Binding, keys []string) {
+	if len(keys) == 0 {
+		b.SetEnabled(false)
+		return
+	}
+
+	keys = slices.Clone(keys)
+	shown := slices.Clone(keys)
+	// Operators like dd are shown typed twice.
+	doubled := len(b.Keys()) > 0 && b.Help().Key == b.Keys()[0]+b.Keys()[0]
+	for i, k := range keys {
+		if k == "space" {
+			keys[i] = " "
+		}
+		if doubled {
+			shown[i] = k + k
+		}
+	}
+	b.SetKeys(keys...)
+	b.SetHelp(strings.Join(shown, "/"), b.Help().Desc)
+	b.SetEnabled(true)
+}
+
+func keyMapNames() []string {
+	var names []string
+	for _, k := range keyMaps {
+		names = append(names, k.name)
+	}
+	return names
+}
+
+// names are the names of the bindings of k that can be remapped.
+func (k keyMap) names() []string {
+	var names []string
+	for _, b := range k.bindings {
+		if b.name != "" {
+			names = append(names, b.name)
+		}
+	}
+	return names
+}
+
+// helpStyles are the styles of the key hints, set by applyTheme.
+var helpStyles help.Styles
+
+// keyHints is the footer of a page listing the bindings of k, cut short to
+// fit in width.
+func keyHints(k keyMap, width int) string {
+	h := help.New()
+	h.Styles = helpStyles
+	if width <= 0 {
+		return h.View(k)
+	}
+	// help adds every binding when the line ends just short of where its
+	// ellipsis would go, so narrow it until the hints do fit.
+	for h.Width = width; h.Width > 1; h.Width-- {
+		if hints := h.View(k); lipgloss.Width(hints) <= width {
+			return hints
+		}
+	}
+	return ""
+}
@@ -1,101 +1,108 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"journalCli/db"
+	"journalCli/client"
+	"journalCli/config"
+	"journalCli/offline"
 	"journalCli/utils"
-	"net/http"
 	"os"
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
-var url string = "http://localhost:8080"
-
-var (
-	// Titles and section headers
-	titleStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("#ba8f95")). // indigo
-			PaddingBottom(1)
-
-	// Input boxes
-	inputBoxStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("#897c80")). // cyan border
-			Width(30)
-
-	// Buttons
-	buttonStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("#0D0D1A")). // dark text
-			Background(lipgloss.Color("#CFBCDF")).
-			Margin(1).
-			Align(lipgloss.Center)
-
-	// Error messages
-	errorStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#EF4444")) // red
-)
-
 type Page int
 
 type tickMsg time.Time
 
 type Model struct {
-	page            Page
-	msg             string
-	user            User
-	err             error
-	inputing        bool
-	textarea        textarea.Model
-	username        textinput.Model
-	password        textinput.Model
-	email           textinput.Model
-	confirmPassword textinput.Model
-	journal         textarea.Model
-	currentTime     time.Time
-	Focused         int
-	width           int
-	height          int
-	senderStyle     lipgloss.Style
-	Client          *http.Client
-}
-
-type User struct {
-	Id       string `json:"id"`
-	Email    string `json:"email"`
-	Username string `json:"username"`
+	page              Page
+	msg               string
+	user              client.User
+	err               error
+	inputing          bool
+	textarea          textarea.Model
+	username          textinput.Model
+	password          textinput.Model
+	email             textinput.Model
+	confirmPassword   textinput.Model
+	journal           textarea.Model
+	entries           list.Model
+	reader            viewport.Model
+	helpPage          viewport.Model
+	readMode          readMode
+	editingEntryId    string
+	journalDraft      string
+	revisions         []client.EntryRevision
+	revisionCursor    int
+	revisionMark      int
+	loadingEntries    bool
+	nextCursor        string
+	search            textinput.Model
+	searchQuery       string
+	pickingMood       bool
+	mood              int
+	tags              []client.TagCount
+	tagCursor         int
+	tagFilter         string
+	currentTime       time.Time
+	location          *time.Location
+	Focused           int
+	width             int
+	height            int
+	api               *client.Client
+	local             *offline.Store
+	syncing           bool
+	syncErr           error
+	lastSync          time.Time
+	pendingSync       int
+	vault             *vault
+	cryptoPrompt      cryptoPrompt
+	cryptoFocus       int
+	passphrase        textinput.Model
+	newPassphrase     textinput.Model
+	confirmPassphrase textinput.Model
+	googleLogin       *client.DeviceAuthorization
+	exportOpen        bool
+	exportFormat      int
+	exportPath        textinput.Model
+	streak            *client.Streak
+	stats             *client.Stats
+	settings          client.Settings
+	settingsDraft     client.Settings
+	settingsCursor    settingsField
+	settingsEditing   bool
+	settingsInput     textinput.Model
+	vim               vimState
 }
 
 type LoginSuccessMsg struct {
-	User User
+	User  client.User
+	Token string
 }
 
 type SignupSuccessMsg struct {
-	User User
-}
-
-type ErrMsg struct {
-	err error
+	User  client.User
+	Token string
 }
 
-type LoginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+type EntrySavedMsg struct {
+	Entry client.Entry
 }
 
-type SignupRequest struct {
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+type ErrMsg struct {
+	err error
 }
 
 const (
@@ -106,9 +113,10 @@ const (
 	PageRead
 	PageSettings
 	PageHelp
+	PageInsights
 )
 
-func initialModel() Model {
+func initialModel(serverURL string) Model {
 	username := textinput.New()
 	username.Placeholder = "Username"
 	username.Focus()
@@ -136,7 +144,6 @@ func initialModel() Model {
 
 	journal := textarea.New()
 	journal.Placeholder = "Write your thoughts here..."
-	journal.ShowLineNumbers = true
 	journal.CharLimit = -1
 
 	journal.FocusedStyle = textarea.Style{
@@ -147,20 +154,30 @@ func initialModel() Model {
 		Base: lipgloss.NewStyle(),
 	}
 
-	return Model{
-		page:            PageLogin,
-		journal:         journal,
-		senderStyle:     lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FAFAFA")),
-		username:        username,
-		email:           email,
-		password:        password,
-		confirmPassword: confirmPassword,
-		inputing:        true,
-		currentTime:     time.Now(),
-		Client: &http.Client{
-			Timeout: time.Second * 10,
-		},
+	m := Model{
+		page:              PageLogin,
+		journal:           journal,
+		entries:           newEntriesList(),
+		reader:            viewport.New(0, 0),
+		helpPage:          viewport.New(0, 0),
+		search:            newSearchInput(),
+		username:          username,
+		email:             email,
+		password:          password,
+		confirmPassword:   confirmPassword,
+		inputing:          true,
+		currentTime:       time.Now(),
+		vault:             &vault{},
+		passphrase:        newPassphraseInput("Passphrase"),
+		newPassphrase:     newPassphraseInput("New Passphrase"),
+		confirmPassphrase: newPassphraseInput("Confirm Passphrase"),
+		exportPath:        newExportPathInput(),
+		settingsInput:     newSettingsInput(),
+		api:               client.New(serverURL),
 	}
+	m.applySettings(defaultSettings)
+	m.restoreSession()
+	return m
 }
 
 func tickEverySecond() tea.Cmd {
@@ -169,96 +186,47 @@ func tickEverySecond() tea.Cmd {
 	})
 }
 
-func (m Model) Init() tea.Cmd {
-	return tickEverySecond()
-}
-
-func checkServerLogin(email, password string, client *http.Client) tea.Msg {
-	loginReq := LoginRequest{
-		Email:    email,
-		Password: password,
-	}
-
-	body, err := json.Marshal(loginReq)
-
-	if err != nil {
-		return ErrMsg{err}
-	}
-
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s/login", url), bytes.NewBuffer(body))
-
-	if err != nil {
-		return ErrMsg{err}
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", " application/json")
-
-	//send the request
-	res, err := client.Do(req)
-
-	if err != nil {
-		return ErrMsg{err}
-	}
+// sessionRestoredMsg starts a session restored from an earlier run.
+type sessionRestoredMsg struct{}
 
-	defer res.Body.Close()
-
-	if err != nil {
-		return ErrMsg{err}
-	}
-
-	if res.StatusCode != http.StatusOK {
-		return ErrMsg{fmt.Errorf("server returned status: %s", res.Status)}
-	}
-
-	var user User
-	if err := json.NewDecoder(res.Body).Decode(&user); err != nil {
-		return ErrMsg{err}
+func (m Model) Init() tea.Cmd {
+	cmds := []tea.Cmd{tickEverySecond(), syncEvery()}
+	if m.user.ID != "" {
+		cmds = append(cmds, func() tea.Msg { return sessionRestoredMsg{} })
 	}
-
-	return LoginSuccessMsg{User: user}
+	return tea.Batch(cmds...)
 }
 
-func checkServerSignup(username, email, password string, client *http.Client) tea.Msg {
-	signupReq := SignupRequest{
-		Username: username,
-		Email:    email,
-		Password: password,
-	}
-	body, err := json.Marshal(signupReq)
+func checkServerLogin(email, password string, api *client.Client) tea.Msg {
+	auth, err := api.Login(context.Background(), email, password)
 	if err != nil {
 		return ErrMsg{err}
 	}
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s/signup", url), bytes.NewBuffer(body))
+	rememberSession(api, auth)
+	return LoginSuccessMsg{User: auth.User, Token: auth.Token}
+}
 
+func checkServerSignup(username, email, password string, api *client.Client) tea.Msg {
+	auth, err := api.Signup(context.Background(), username, email, password)
 	if err != nil {
 		return ErrMsg{err}
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", " application/json")
-
-	res, err := client.Do(req)
+	rememberSession(api, auth)
+	return SignupSuccessMsg{User: auth.User, Token: auth.Token}
+}
 
+func saveEntry(body string, mood *int, v *vault, api *client.Client) tea.Msg {
+	sealed, enc, err := v.seal(body)
 	if err != nil {
 		return ErrMsg{err}
 	}
-	defer res.Body.Close()
-
+	entry, err := api.CreateEntry(context.Background(), client.CreateEntryRequest{Body: sealed, Encryption: enc, Mood: mood})
 	if err != nil {
 		return ErrMsg{err}
 	}
+	v.openEntry(entry)
 
-	if res.StatusCode != http.StatusCreated {
-		return ErrMsg{fmt.Errorf("server returned status: %s", res.Status)}
-	}
-
-	var user User
-
-	if err := json.NewDecoder(res.Body).Decode(&user); err != nil {
-		return ErrMsg{err}
-	}
-
-	return SignupSuccessMsg{User: user}
+	return EntrySavedMsg{Entry: *entry}
 }
 
 func (m *Model) updateFocusSignup() {
@@ -295,23 +263,145 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tickMsg:
 		m.currentTime = time.Time(msg)
 		return m, tickEverySecond()
+	case syncTickMsg:
+		return m, tea.Batch(syncEvery(), m.startSync())
+	case SyncDoneMsg:
+		m.syncing = false
+		if errors.Is(msg.Err, client.ErrUnauthorized) && m.user.ID != "" {
+			m.sessionExpired()
+			return m, nil
+		}
+		m.syncErr = msg.Err
+		m.pendingSync = msg.Pending
+		if msg.Err == nil {
+			m.lastSync = time.Now()
+			// Entries written offline only count once they are pushed.
+			return m, func() tea.Msg { return fetchStreak(m.api, localTimeZone(m.location)) }
+		}
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
+		m.resizeReadPage()
+		m.resizeHelpPage()
 
 	// ----------- SERVER RESPONSES -----------
+	case GoogleDeviceMsg, googlePollMsg, GooglePendingMsg, GoogleLoginFailedMsg:
+		return m.updateGoogleLogin(msg)
+
 	case LoginSuccessMsg:
+		m.googleLogin = nil
 		m.user = msg.User
 		m.page = PageMenu
 		m.inputing = false
+		m.err = nil
+		return m, tea.Batch(m.startSync(), func() tea.Msg { return fetchKey(m.api) }, func() tea.Msg { return fetchStreak(m.api, localTimeZone(m.location)) },
+			func() tea.Msg { return fetchSettings(m.api) })
 
 	case SignupSuccessMsg:
 		m.user = msg.User
 		m.page = PageMenu
 		m.inputing = false
+		m.err = nil
+		return m, tea.Batch(m.startSync(), func() tea.Msg { return fetchKey(m.api) }, func() tea.Msg { return fetchStreak(m.api, localTimeZone(m.location)) },
+			func() tea.Msg { return fetchSettings(m.api) })
+
+	case sessionRestoredMsg:
+		return m, tea.Batch(m.startSync(), func() tea.Msg { return fetchKey(m.api) }, func() tea.Msg { return fetchStreak(m.api, localTimeZone(m.location)) },
+			func() tea.Msg { return fetchSettings(m.api) })
+
+	case LogoutSuccessMsg:
+		m.resetToLogin()
+		if msg.ServerErr != nil {
+			m.err = fmt.Errorf("Logged out on this device, but the server session may still be open: %w", msg.ServerErr)
+		}
+
+	case EntrySavedMsg:
+		m.err = nil
+		m.journal.SetValue("")
+		if m.vim.quitAfterSave && m.page == PageJournal {
+			m.leaveJournal(true)
+		}
+		m.msg = fmt.Sprintf("Entry saved at %s", msg.Entry.CreatedAt.In(m.location).Format("15:04:05"))
+		return m, tea.Batch(m.startSync(), func() tea.Msg { return fetchStreak(m.api, localTimeZone(m.location)) })
+
+	case EntryUpdatedMsg:
+		m.err = nil
+		cmd = m.replaceEntry(msg.Entry)
+		if m.page == PageRead {
+			m.msg = "Revision restored"
+			m.readMode = readRevisions
+			m.revisionCursor = 0
+			m.revisionMark = -1
+			id := msg.Entry.ID
+			return m, tea.Batch(cmd, func() tea.Msg { return fetchRevisions(id, m.vault, m.api) })
+		}
+		if m.vim.quitAfterSave && m.page == PageJournal {
+			m.leaveJournal(false)
+		}
+		m.msg = fmt.Sprintf("Entry updated at %s", msg.Entry.UpdatedAt.In(m.location).Format("15:04:05"))
+		return m, tea.Batch(cmd, m.startSync())
+
+	case EntryDeletedMsg:
+		m.err = nil
+		m.removeEntry(msg.Id)
+		m.msg = "Entry deleted"
+		return m, tea.Batch(m.startSync(), func() tea.Msg { return fetchStreak(m.api, localTimeZone(m.location)) })
+
+	case KeyLoadedMsg:
+		m.vault.SetEnvelope(msg.Envelope)
+
+	case EncryptionEnabledMsg:
+		m.msg = "Encryption enabled, new entries are encrypted before they are sent"
+		return m, m.startSync()
+
+	case VaultUnlockedMsg:
+		m.msg = "Journal unlocked"
+		return m, m.startSync()
+
+	case PassphraseChangedMsg:
+		m.msg = "Passphrase changed"
+
+	case ExportDoneMsg:
+		m.err = nil
+		m.msg = fmt.Sprintf("Exported %d entries to %s", msg.Count, msg.Path)
+
+	case StreakLoadedMsg:
+		m.streak = msg.Streak
+
+	case StatsLoadedMsg:
+		m.stats = msg.Stats
+
+	case SettingsLoadedMsg:
+		m.applySettings(*msg.Settings)
+
+	case SettingsSavedMsg:
+		m.err = nil
+		m.msg = "Settings saved"
+		m.applySettings(*msg.Settings)
+
+	case TagsLoadedMsg:
+		m.tags = msg.Tags
+
+	case RevisionsLoadedMsg:
+		if entry, ok := m.selectedEntry(); ok && entry.ID == msg.EntryId {
+			m.revisions = msg.Revisions
+		}
+
+	case SearchResultsMsg:
+		m.err = nil
+		return m, m.applySearchResults(msg)
+
+	case EntriesLoadedMsg:
+		m.err = nil
+		return m, m.applyEntries(msg)
 
 	case ErrMsg:
+		m.msg = ""
 		m.err = msg.err
+		m.loadingEntries = false
+		if errors.Is(msg.err, client.ErrUnauthorized) && m.user.ID != "" {
+			m.sessionExpired()
+		}
 
 	// ----------- KEY EVENTS -----------
 	case tea.KeyMsg:
@@ -319,20 +409,27 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		// ----------- LOGIN PAGE -----------
 		case PageLogin:
+			if m.googleLogin != nil {
+				return m.updateGoogleLogin(msg)
+			}
+			if key.Matches(msg, loginKeys.Google) {
+				m.err = nil
+				return m, func() tea.Msg { return startGoogleLogin(m.api) }
+			}
 			m.username, cmd = m.username.Update(msg)
 			cmds = append(cmds, cmd)
 			m.password, cmd = m.password.Update(msg)
 			cmds = append(cmds, cmd)
 
-			switch msg.Type {
-			case tea.KeyCtrlS:
+			switch {
+			case key.Matches(msg, loginKeys.Signup):
 				m.page = PageSignup
 				m.Focused = 0
 				m.username.SetValue("")
 				m.password.SetValue("")
 				m.username.Focus()
 				m.password.Blur()
-			case tea.KeyTab, tea.KeyDown, tea.KeyUp:
+			case key.Matches(msg, loginKeys.NextField):
 				m.Focused = (m.Focused + 1) % 2
 				if m.Focused == 0 {
 					m.username.Focus()
@@ -341,17 +438,24 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.password.Focus()
 					m.username.Blur()
 				}
-			case tea.KeyEnter:
+			case key.Matches(msg, loginKeys.Submit):
 				username := m.username.Value()
 				password := m.password.Value()
-				return m, func() tea.Msg { return checkServerLogin(username, password, m.Client) }
-			case tea.KeyCtrlC:
+				return m, func() tea.Msg { return checkServerLogin(username, password, m.api) }
+			case key.Matches(msg, quitKey):
 				return m, tea.Quit
 			}
 			return m, tea.Batch(cmds...)
 
 		// ----------- SIGNUP PAGE -----------
 		case PageSignup:
+			if m.googleLogin != nil {
+				return m.updateGoogleLogin(msg)
+			}
+			if key.Matches(msg, signupKeys.Google) {
+				m.err = nil
+				return m, func() tea.Msg { return startGoogleLogin(m.api) }
+			}
 			m.username, cmd = m.username.Update(msg)
 			cmds = append(cmds, cmd)
 
@@ -364,8 +468,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.confirmPassword, cmd = m.confirmPassword.Update(msg)
 			cmds = append(cmds, cmd)
 
-			switch msg.Type {
-			case tea.KeyCtrlL:
+			switch {
+			case key.Matches(msg, signupKeys.Login):
 				m.page = PageLogin
 				m.Focused = 0
 				m.username.SetValue("")
@@ -376,13 +480,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.password.Blur()
 				m.confirmPassword.Blur()
 
-			case tea.KeyTab, tea.KeyDown:
+			case key.Matches(msg, signupKeys.NextField):
 				m.Focused = (m.Focused + 1) % 4
 				m.updateFocusSignup()
-			case tea.KeyUp:
+			case key.Matches(msg, signupKeys.PrevField):
 				m.Focused = (m.Focused + 3) % 4
 				m.updateFocusSignup()
-			case tea.KeyEnter:
+			case key.Matches(msg, signupKeys.Submit):
 				username := m.username.Value()
 				password := m.password.Value()
 				email := m.email.Value()
@@ -391,69 +495,83 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if !isValid {
 					m.err = err
 				} else {
-					return m, func() tea.Msg { return checkServerSignup(username, email, password, m.Client) }
+					return m, func() tea.Msg { return checkServerSignup(username, email, password, m.api) }
 				}
 
-			case tea.KeyCtrlC:
+			case key.Matches(msg, quitKey):
 				return m, tea.Quit
 			}
 			return m, tea.Batch(cmds...)
 
 		// ----------- MENU PAGE -----------
 		case PageMenu:
-			switch msg.String() {
-			case "1":
+			switch {
+			case key.Matches(msg, menuKeys.Journal):
 				m.page = PageJournal
-			case "2":
+			case key.Matches(msg, menuKeys.Read):
 				m.page = PageRead
-			case "3":
-				m.page = PageSettings
-			case "4":
-				m.page = PageHelp
-			case "q", "ctrl+c":
+				m.readMode = readList
+				return m, m.applyTagFilter("")
+			case key.Matches(msg, menuKeys.Settings):
+				m.openSettings()
+			case key.Matches(msg, menuKeys.Help):
+				m.openHelp()
+			case key.Matches(msg, menuKeys.Insights):
+				return m, m.openInsights()
+			case key.Matches(msg, menuKeys.Logout):
+				return m, func() tea.Msg { return logout(m.api) }
+			case key.Matches(msg, menuKeys.Quit, quitKey):
 				return m, tea.Quit
 			}
 
 		// ----------- JOURNAL PAGE -----------
 		case PageJournal:
+			if m.pickingMood {
+				return m.updateMoodPicker(msg)
+			}
 
 			if !m.inputing {
 				m.journal.Focus()
 				m.inputing = true
 			}
+			if m.vimEnabled() {
+				switch {
+				case key.Matches(msg, journalKeys.Save):
+					m.requestSave()
+					return m, nil
+				case key.Matches(msg, quitKey):
+					return m, tea.Quit
+				}
+				return m.updateVim(msg)
+			}
 			m.journal, cmd = m.journal.Update(msg)
 			cmds = append(cmds, cmd)
 
-			switch msg.Type {
-			case tea.KeyCtrlS:
-			//TODO
-			case tea.KeyEsc:
-				m.page = PageMenu
-				m.journal.SetValue("")
-				m.journal.Blur()
-				m.inputing = false
-			case tea.KeyCtrlC:
+			switch {
+			case key.Matches(msg, journalKeys.Save):
+				m.requestSave()
+			case key.Matches(msg, journalKeys.Back):
+				m.leaveJournal(false)
+			case key.Matches(msg, quitKey):
 				return m, tea.Quit
 			}
 			return m, tea.Batch(cmds...)
 
 		// ----------- READ PAGE -----------
 		case PageRead:
-			if msg.String() == "b" {
-				m.page = PageMenu
-			}
+			return m.updateReadPage(msg)
 
 		// ----------- SETTINGS PAGE -----------
 		case PageSettings:
-			if msg.String() == "b" {
-				m.page = PageMenu
-			}
+			return m.updateSettings(msg)
 
 		// ----------- HELP PAGE -----------
 		case PageHelp:
-			if msg.String() == "b" {
-				m.page = PageMenu
-			}
+			return m.updateHelp(msg)
+
+		// ----------- INSIGHTS PAGE -----------
+		case PageInsights:
+			return m.updateInsights(msg)
 		}
 	}
 
@@ -463,19 +581,30 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m Model) View() string {
 	switch m.page {
 	case PageLogin:
+		if m.googleLogin != nil {
+			return renderGoogleLogin(m)
+		}
 		return renderLoginPage(m)
 	case PageSignup:
+		if m.googleLogin != nil {
+			return renderGoogleLogin(m)
+		}
 		return renderSignupPage(m)
 	case PageMenu:
-		return renderWelcomeMsg(m) + "Menu Page\n\n1. Journal\n2. Read\n3. Settings\n4. Help\nq. Quit"
+		return renderWelcomeMsg(m) + renderMenu()
 	case PageJournal:
+		if m.pickingMood {
+			return renderMoodPicker(m)
+		}
 		return renderJournal(m)
 	case PageRead:
-		return "Read Page\n\n[Read Entries Here]\nb. Back to Menu"
+		return renderReadPage(m)
 	case PageSettings:
-		return "Settings Page\n\n[Settings Here]\nb. Back to Menu"
+		return renderSettings(m)
 	case PageHelp:
-		return "Help Page\n\n[Help Info Here]\nb. Back to Menu"
+		return renderHelp(m)
+	case PageInsights:
+		return renderInsights(m)
 	default:
 		return "Unknown Page"
 	}
@@ -495,15 +624,15 @@ func renderLoginPage(m Model) string {
 	title := titleStyle.Render("🔐 Login")
 	userEmailStyle := inputBoxStyle
 	passwordStyle := inputBoxStyle
-	googleLoginLink := lipgloss.NewStyle().Italic(true).Underline(true).Render("Press Ctrl+g to Login with")
+	googleLoginLink := lipgloss.NewStyle().Italic(true).Underline(true).Render("Press " + loginKeys.Google.Help().Key + " to Login with")
 	googleLogo := renderGoogleLogo()
 	baseFooter := lipgloss.NewStyle().Italic(true).Bold(true).PaddingTop(1).Render("First time? ")
-	underlineFooter := lipgloss.NewStyle().Italic(true).Underline(true).Render("Press Ctrl+s to go to SignUp Page")
+	underlineFooter := lipgloss.NewStyle().Italic(true).Underline(true).Render("Press " + loginKeys.Signup.Help().Key + " to go to SignUp Page")
 	if m.Focused == 0 {
-		userEmailStyle = inputBoxStyle.BorderForeground(lipgloss.Color("#A78BFA"))
+		userEmailStyle = focusedInputStyle
 	}
 	if m.Focused == 1 {
-		passwordStyle = inputBoxStyle.BorderForeground(lipgloss.Color("#A78BFA"))
+		passwordStyle = focusedInputStyle
 	}
 
 	form := lipgloss.JoinVertical(
@@ -536,10 +665,10 @@ func renderLoginPage(m Model) string {
 
 func renderSignupPage(m Model) string {
 	title := titleStyle.Render("🔐 SignUp")
-	googleSignupLink := lipgloss.NewStyle().Italic(true).Underline(true).Render("Press Ctrl+g to SignUp with Google")
+	googleSignupLink := lipgloss.NewStyle().Italic(true).Underline(true).Render("Press " + signupKeys.Google.Help().Key + " to SignUp with Google")
 	googleLogo := renderGoogleLogo()
 	baseFooter := lipgloss.NewStyle().Italic(true).Bold(true).Render("Already have an account? ")
-	underlineFooter := lipgloss.NewStyle().Italic(true).Underline(true).Render("Press Ctrl+l to go to Login Page")
+	underlineFooter := lipgloss.NewStyle().Italic(true).Underline(true).Render("Press " + signupKeys.Login.Help().Key + " to go to Login Page")
 
 	userNameStyle := inputBoxStyle
 	emailStyle := inputBoxStyle
@@ -547,16 +676,16 @@ func renderSignupPage(m Model) string {
 	confirmPasswordStyle := inputBoxStyle
 
 	if m.Focused == 0 {
-		userNameStyle = inputBoxStyle.BorderForeground(lipgloss.Color("#A78BFA"))
+		userNameStyle = focusedInputStyle
 	}
 	if m.Focused == 1 {
-		emailStyle = inputBoxStyle.BorderForeground(lipgloss.Color("#A78BFA"))
+		emailStyle = focusedInputStyle
 	}
 	if m.Focused == 2 {
-		passwordStyle = inputBoxStyle.BorderForeground(lipgloss.Color("#A78BFA"))
+		passwordStyle = focusedInputStyle
 	}
 	if m.Focused == 3 {
-		confirmPasswordStyle = inputBoxStyle.BorderForeground(lipgloss.Color("#A78BFA"))
+		confirmPasswordStyle = focusedInputStyle
 	}
 
 	form := lipgloss.JoinVertical(
@@ -590,38 +719,84 @@ func renderSignupPage(m Model) string {
 
 func renderWelcomeMsg(m Model) string {
 
-	baseStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#E6E6E6")).PaddingTop(1).PaddingBottom(1)
-	usernameStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#6C63FF")).Bold(true)
+	baseStyle := lipgloss.NewStyle().Foreground(currentTheme.Text.Terminal()).PaddingTop(1).PaddingBottom(1)
+	usernameStyle := lipgloss.NewStyle().Foreground(currentTheme.Accent.Terminal()).Bold(true)
 	welcomeMsg := fmt.Sprintf("Welcome, %s💜", usernameStyle.Render(m.user.Username))
 	styledMsg := baseStyle.Render(welcomeMsg)
 
-	border := lipgloss.NewStyle().Foreground(lipgloss.Color("#A78BFA")).Render(strings.Repeat("─", len(welcomeMsg)))
+	border := lipgloss.NewStyle().Foreground(currentTheme.Primary.Terminal()).Render(strings.Repeat("─", len(welcomeMsg)))
+
+	rows := []string{styledMsg, border}
+	if m.streak != nil {
+		rows = append(rows, renderStreak(m.streak), "")
+	}
+	welcome := lipgloss.JoinVertical(lipgloss.Center, rows...)
 
 	return lipgloss.Place(
 		m.width,
-		3, // height: 2 for message + 1 for border
+		lipgloss.Height(welcome),
 		lipgloss.Center,
 		lipgloss.Center,
-		lipgloss.JoinVertical(lipgloss.Center, styledMsg, border),
+		welcome,
 	)
 }
 
+// renderMenu lists the pages with the keys that open them.
+func renderMenu() string {
+	rows := []string{"Menu Page", ""}
+	for _, b := range menuKeyMap.bindings {
+		rows = append(rows, b.Help().Key+". "+b.Help().Desc)
+	}
+	return strings.Join(rows, "\n")
+}
+
+// requestSave opens the mood picker that saves the journal, unless there is
+// nothing to save.
+func (m *Model) requestSave() {
+	if strings.TrimSpace(m.journal.Value()) == "" {
+		m.err = fmt.Errorf("Nothing to save, write something first")
+		return
+	}
+	m.openMoodPicker()
+}
+
+// leaveJournal goes back to where the journal was opened from. What was
+// written in a new entry stays for next time unless discard is set; leaving
+// an entry being edited brings back the new entry that was there before.
+func (m *Model) leaveJournal(discard bool) {
+	m.page = PageMenu
+	if m.editingEntryId != "" {
+		m.page = PageRead
+		m.readMode = readList
+		m.editingEntryId = ""
+		m.journal.SetValue(m.journalDraft)
+		m.journalDraft = ""
+	} else if discard {
+		m.journal.SetValue("")
+	}
+	m.msg = ""
+	m.err = nil
+	m.journal.Blur()
+	m.inputing = false
+	m.resetVim()
+}
+
 func renderJournal(m Model) string {
-	currentTime := m.currentTime.Format("2006/01/02 15:04:05")
-	clock := lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("#E0AfA0")).Render("🕰️ " + currentTime)
+	currentTime := m.currentTime.In(m.location).Format(dateFormat + " 15:04:05")
+	clock := lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(currentTheme.Secondary.Terminal()).Render("🕰️ " + currentTime)
 
 	header := lipgloss.Place(
 		m.width,
 		1,
 		lipgloss.Center,
 		lipgloss.Center,
-		clock,
+		lipgloss.JoinHorizontal(lipgloss.Center, clock, " ", renderSyncStatus(m)),
 	)
 
-	instructions := lipgloss.NewStyle().
-		Italic(true).
-		Foreground(lipgloss.Color("#A78BFA")).
-		Render("\nCtrl+S to Save | Esc to Back | Ctrl+C to Quit")
+	instructions := "\n" + keyHints(journalKeyMap, m.width)
+	if m.vimEnabled() {
+		instructions = "\n" + renderVimStatus(m)
+	}
 
 	centeredInstructions := lipgloss.Place(
 		m.width,
@@ -649,6 +824,12 @@ func renderJournal(m Model) string {
 			content,
 			errorStyle.Render(fmt.Sprintf("Error: %v", m.err)),
 		)
+	} else if m.msg != "" {
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			content,
+			successStyle.Render(m.msg),
+		)
 	}
 
 	return content
@@ -657,6 +838,29 @@ func renderJournal(m Model) string {
 var debugFile *os.File
 
 func main() {
+	cfg, args, err := config.Load("journalcli", os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if len(args) > 0 {
+		err := runCommand(cfg, args)
+		switch {
+		case err == nil, errors.Is(err, flag.ErrHelp):
+		case errors.Is(err, errUsage):
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		default:
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	f, err := os.OpenFile("debug.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
 		fmt.Printf("Error opening file: %v\n", err)
@@ -665,11 +869,23 @@ func main() {
 	debugFile = f
 	defer f.Close()
 
-	database := db.GetDB()
+	if err := remapKeys(cfg.Keys); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
 
-	defer db.CloseDB(database)
+	loadUserThemes()
+	model := initialModel(cfg.Client.ServerURL)
+	if path, err := offline.DefaultPath(); err != nil {
+		fmt.Fprintf(debugFile, "Local store disabled: %v\n", err)
+	} else if store, err := offline.Open(path); err != nil {
+		fmt.Fprintf(debugFile, "Local store disabled: %v\n", err)
+	} else {
+		model.local = store
+		defer store.Close()
+	}
 
-	p := tea.NewProgram(initialModel())
+	p := tea.NewProgram(model)
 	if err := p.Start(); err != nil {
 		fmt.Printf("Error starting program: %v\n", err)
 	}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// moodEmojis maps mood scores 1..5 (index 0 is unused) to how they are shown.
+var moodEmojis = []string{"", "😞", "🙁", "😐", "🙂", "😄"}
+
+func moodEmoji(mood *int) string {
+	if mood == nil || *mood < 1 || *mood >= len(moodEmojis) {
+		return ""
+	}
+	return moodEmojis[*mood]
+}
+
+// openMoodPicker shows the mood picker that comes before saving, preselecting
+// the mood of the entry being edited, if any.
+func (m *Model) openMoodPicker() {
+	m.pickingMood = true
+	m.mood = 0
+	for _, item := range m.entries.Items() {
+		if entry := item.(entryItem).entry; entry.ID == m.editingEntryId && entry.Mood != nil {
+			m.mood = *entry.Mood
+		}
+	}
+	if m.mood == 0 {
+		m.mood = 3
+	}
+	m.journal.Blur()
+}
+
+func (m Model) updateMoodPicker(msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, moodKeys.Prev):
+		if m.mood > 1 {
+			m.mood--
+		}
+	case key.Matches(msg, moodKeys.Next):
+		if m.mood < len(moodEmojis)-1 {
+			m.mood++
+		}
+	case key.Matches(msg, moodKeys.Pick):
+		if mood := int(msg.String()[0] - '0'); mood >= 1 && mood < len(moodEmojis) {
+			m.mood = mood
+		}
+	case key.Matches(msg, moodKeys.Save):
+		mood := m.mood
+		return m.saveJournal(&mood)
+	case key.Matches(msg, moodKeys.SaveWithout):
+		return m.saveJournal(nil)
+	case key.Matches(msg, moodKeys.Back):
+		m.pickingMood = false
+		m.vim.quitAfterSave = false
+		return m, m.journal.Focus()
+	case key.Matches(msg, quitKey):
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// saveJournal saves the journal textarea as a new entry, or as the entry being
+// edited, with the mood chosen in the picker.
+func (m Model) saveJournal(mood *int) (Model, tea.Cmd) {
+	m.pickingMood = false
+	body := m.journal.Value()
+	focus := m.journal.Focus()
+	if m.local != nil {
+		return m, tea.Batch(focus, m.saveLocal(body, mood))
+	}
+	if id := m.editingEntryId; id != "" {
+		return m, tea.Batch(focus, func() tea.Msg { return updateEntry(id, body, mood, m.vault, m.api) })
+	}
+	return m, tea.Batch(focus, func() tea.Msg { return saveEntry(body, mood, m.vault, m.api) })
+}
+
+func renderMoodPicker(m Model) string {
+	var moods []string
+	for i := 1; i < len(moodEmojis); i++ {
+		style := lipgloss.NewStyle().Padding(0, 1)
+		if i == m.mood {
+			style = style.Border(lipgloss.RoundedBorder()).BorderForeground(currentTheme.Primary.Terminal())
+		} else {
+			style = style.Border(lipgloss.HiddenBorder())
+		}
+		moods = append(moods, style.Render(moodEmojis[i]))
+	}
+
+	instructions := keyHints(moodKeyMap, m.width)
+
+	picker := lipgloss.JoinVertical(
+		lipgloss.Center,
+		titleStyle.Render("How are you feeling?"),
+		lipgloss.JoinHorizontal(lipgloss.Center, moods...),
+		instructions,
+	)
+
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, picker)
+}
+
+// entryTagsLine renders the tags of an entry as #hashtags.
+func entryTagsLine(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	return "#" + strings.Join(tags, " #")
+}
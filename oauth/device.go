@@ -0,0 +1,176 @@
+// Package oauth implements login with an OpenID Connect provider through the
+// OAuth 2.0 device authorization grant (RFC 8628), which suits a terminal UI:
+// the user opens a URL on any device and types in a short code while the
+// client polls for the result.
+//
+// The server does the talking to the provider, so the client secret never
+// leaves it, and verifies the ID token it gets back before trusting it.
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"journalCli/config"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+)
+
+// Errors returned by Provider.PollDeviceToken, mirroring the RFC 8628 error
+// codes.
+var (
+	ErrAuthorizationPending = errors.New("authorization pending")
+	ErrSlowDown             = errors.New("polling too fast")
+	ErrAccessDenied         = errors.New("access denied")
+	ErrExpiredToken         = errors.New("device code expired")
+	ErrUnverifiedEmail      = errors.New("email address is not verified")
+)
+
+// DeviceAuthorization is what the user needs to approve the login, plus the
+// device code to poll with.
+type DeviceAuthorization struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// Identity is the verified subject of an ID token.
+type Identity struct {
+	Subject string
+	Email   string
+	Name    string
+}
+
+type Provider struct {
+	name     string
+	cfg      config.OAuthProvider
+	client   *http.Client
+	verifier *oidc.IDTokenVerifier
+}
+
+func NewProvider(name string, cfg config.OAuthProvider) *Provider {
+	ctx := context.Background()
+	keys := oidc.NewRemoteKeySet(ctx, cfg.JWKSURL)
+	return &Provider{
+		name:     name,
+		cfg:      cfg,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		verifier: oidc.NewVerifier(cfg.Issuer, keys, &oidc.Config{ClientID: cfg.ClientID}),
+	}
+}
+
+// Name identifies the provider in oauth_identities.
+func (p *Provider) Name() string {
+	return p.name
+}
+
+// tokenError is the error body of an OAuth endpoint.
+type tokenError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (p *Provider) post(ctx context.Context, endpoint string, form neturl.Values, out any) error {
+	form.Set("client_id", p.cfg.ClientID)
+	if p.cfg.ClientSecret != "" {
+		form.Set("client_secret", p.cfg.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		var tokenErr tokenError
+		if err := json.NewDecoder(res.Body).Decode(&tokenErr); err != nil || tokenErr.Code == "" {
+			return fmt.Errorf("%s returned status: %s", p.name, res.Status)
+		}
+		switch tokenErr.Code {
+		case "authorization_pending":
+			return ErrAuthorizationPending
+		case "slow_down":
+			return ErrSlowDown
+		case "access_denied":
+			return ErrAccessDenied
+		case "expired_token":
+			return ErrExpiredToken
+		}
+		return fmt.Errorf("%s returned %s: %s", p.name, tokenErr.Code, tokenErr.Description)
+	}
+
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+// StartDeviceAuthorization asks the provider for a device and user code.
+func (p *Provider) StartDeviceAuthorization(ctx context.Context) (DeviceAuthorization, error) {
+	var auth struct {
+		DeviceAuthorization
+		// Google calls verification_uri verification_url.
+		VerificationURL string `json:"verification_url"`
+	}
+	form := neturl.Values{"scope": {"openid email profile"}}
+	if err := p.post(ctx, p.cfg.DeviceAuthURL, form, &auth); err != nil {
+		return DeviceAuthorization{}, err
+	}
+	if auth.VerificationURI == "" {
+		auth.VerificationURI = auth.VerificationURL
+	}
+	if auth.Interval <= 0 {
+		auth.Interval = 5
+	}
+	return auth.DeviceAuthorization, nil
+}
+
+// PollDeviceToken checks once whether the user approved the login. Until they
+// do it returns ErrAuthorizationPending, or ErrSlowDown when polled too often.
+func (p *Provider) PollDeviceToken(ctx context.Context, deviceCode string) (Identity, error) {
+	var token struct {
+		IDToken string `json:"id_token"`
+	}
+	form := neturl.Values{
+		"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+		"device_code": {deviceCode},
+	}
+	if err := p.post(ctx, p.cfg.TokenURL, form, &token); err != nil {
+		return Identity{}, err
+	}
+	if token.IDToken == "" {
+		return Identity{}, fmt.Errorf("%s did not return an ID token", p.name)
+	}
+
+	idToken, err := p.verifier.Verify(ctx, token.IDToken)
+	if err != nil {
+		return Identity{}, fmt.Errorf("invalid ID token: %w", err)
+	}
+
+	var claims struct {
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		Name          string `json:"name"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return Identity{}, fmt.Errorf("invalid ID token claims: %w", err)
+	}
+	if claims.Email == "" || !claims.EmailVerified {
+		return Identity{}, ErrUnverifiedEmail
+	}
+
+	return Identity{Subject: idToken.Subject, Email: claims.Email, Name: claims.Name}, nil
+}
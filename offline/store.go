@@ -0,0 +1,343 @@
+package offline
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Entry is the local copy of a journal entry. ServerID is empty until the
+// entry has been pushed, and Version is the server version it was based on.
+// Dirty entries have local changes that still have to be pushed; Deleted ones
+// are kept as tombstones until the deletion has been pushed. Entries pulled
+// from the server stay end-to-end encrypted when they were; Nonce is empty for
+// plaintext bodies.
+type Entry struct {
+	LocalID   string    `json:"local_id"`
+	ServerID  string    `json:"server_id"`
+	Body      string    `json:"body"`
+	Nonce     string    `json:"nonce,omitempty"`
+	KeyCheck  string    `json:"key_check,omitempty"`
+	Mood      *int      `json:"mood"`
+	Tags      []string  `json:"tags"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Dirty     bool      `json:"dirty"`
+	Deleted   bool      `json:"deleted"`
+}
+
+// Store keeps each user's entries in their own buckets of a bbolt file.
+type Store struct {
+	db *bolt.DB
+}
+
+var (
+	entriesBucket = []byte("entries")
+	metaBucket    = []byte("meta")
+	cursorKey     = []byte("sync_cursor")
+)
+
+// DefaultPath is the store file under the user's config directory.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "journalcli", "local.db"), nil
+}
+
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open local store: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func newLocalID() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// userBucket returns the bucket of a user, creating it in writable transactions.
+func userBucket(tx *bolt.Tx, userID string, name []byte) (*bolt.Bucket, error) {
+	if tx.Writable() {
+		user, err := tx.CreateBucketIfNotExists([]byte("user:" + userID))
+		if err != nil {
+			return nil, err
+		}
+		return user.CreateBucketIfNotExists(name)
+	}
+	user := tx.Bucket([]byte("user:" + userID))
+	if user == nil {
+		return nil, nil
+	}
+	return user.Bucket(name), nil
+}
+
+func putEntry(bucket *bolt.Bucket, entry Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return bucket.Put([]byte(entry.LocalID), data)
+}
+
+func (s *Store) all(userID string) ([]Entry, error) {
+	entries := []Entry{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket, err := userBucket(tx, userID, entriesBucket)
+		if err != nil || bucket == nil {
+			return err
+		}
+		return bucket.ForEach(func(_, data []byte) error {
+			var entry Entry
+			if err := json.Unmarshal(data, &entry); err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+			return nil
+		})
+	})
+	return entries, err
+}
+
+// Save writes an entry locally and marks it for pushing. Entries without a
+// LocalID are new and get one assigned.
+func (s *Store) Save(userID string, entry Entry) (Entry, error) {
+	now := time.Now()
+	if entry.LocalID == "" {
+		entry.LocalID = newLocalID()
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = now
+	}
+	entry.UpdatedAt = now
+	entry.Dirty = true
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := userBucket(tx, userID, entriesBucket)
+		if err != nil {
+			return err
+		}
+		return putEntry(bucket, entry)
+	})
+	return entry, err
+}
+
+// Delete removes an entry locally. Entries the server knows about are kept as
+// tombstones until the deletion has been pushed.
+func (s *Store) Delete(userID string, entry Entry) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := userBucket(tx, userID, entriesBucket)
+		if err != nil {
+			return err
+		}
+		if entry.ServerID == "" {
+			return bucket.Delete([]byte(entry.LocalID))
+		}
+		entry.Deleted = true
+		entry.Dirty = true
+		entry.UpdatedAt = time.Now()
+		return putEntry(bucket, entry)
+	})
+}
+
+func (s *Store) Get(userID, localID string) (Entry, error) {
+	var entry Entry
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket, err := userBucket(tx, userID, entriesBucket)
+		if err != nil {
+			return err
+		}
+		var data []byte
+		if bucket != nil {
+			data = bucket.Get([]byte(localID))
+		}
+		if data == nil {
+			return fmt.Errorf("entry %s not found in the local store", localID)
+		}
+		return json.Unmarshal(data, &entry)
+	})
+	return entry, err
+}
+
+func (s *Store) FindByServerID(userID, serverID string) (Entry, bool, error) {
+	entries, err := s.all(userID)
+	if err != nil {
+		return Entry{}, false, err
+	}
+	for _, entry := range entries {
+		if entry.ServerID == serverID {
+			return entry, true, nil
+		}
+	}
+	return Entry{}, false, nil
+}
+
+// List returns up to limit live entries of a user, newest first, skipping the
+// first offset ones. An empty tag doesn't filter.
+func (s *Store) List(userID, tag string, offset, limit int) ([]Entry, error) {
+	entries, err := s.all(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	live := []Entry{}
+	for _, entry := range entries {
+		if entry.Deleted || (tag != "" && !hasTag(entry, tag)) {
+			continue
+		}
+		live = append(live, entry)
+	}
+	sort.Slice(live, func(i, j int) bool {
+		return live[i].CreatedAt.After(live[j].CreatedAt)
+	})
+
+	if offset >= len(live) {
+		return []Entry{}, nil
+	}
+	return live[offset:min(offset+limit, len(live))], nil
+}
+
+func hasTag(entry Entry, tag string) bool {
+	for _, t := range entry.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Pending returns the entries with local changes that have not been pushed.
+func (s *Store) Pending(userID string) ([]Entry, error) {
+	entries, err := s.all(userID)
+	if err != nil {
+		return nil, err
+	}
+	pending := []Entry{}
+	for _, entry := range entries {
+		if entry.Dirty {
+			pending = append(pending, entry)
+		}
+	}
+	return pending, nil
+}
+
+// MarkPushed records that a local change has reached the server, unless the
+// entry was changed again locally in the meantime.
+func (s *Store) MarkPushed(userID string, pushed Entry, serverID string, version int, updatedAt time.Time) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := userBucket(tx, userID, entriesBucket)
+		if err != nil {
+			return err
+		}
+		var current Entry
+		data := bucket.Get([]byte(pushed.LocalID))
+		if data == nil {
+			return nil
+		}
+		if err := json.Unmarshal(data, &current); err != nil {
+			return err
+		}
+		if current.Deleted && pushed.Deleted {
+			return bucket.Delete([]byte(pushed.LocalID))
+		}
+		current.ServerID = serverID
+		current.Version = version
+		if current.UpdatedAt.Equal(pushed.UpdatedAt) {
+			current.Dirty = false
+			current.UpdatedAt = updatedAt
+		}
+		return putEntry(bucket, current)
+	})
+}
+
+// ApplyRemote stores the server's copy of an entry, unless there are local
+// changes to it still waiting to be pushed.
+func (s *Store) ApplyRemote(userID string, remote Entry) error {
+	local, found, err := s.FindByServerID(userID, remote.ServerID)
+	if err != nil {
+		return err
+	}
+	if found && local.Dirty {
+		return nil
+	}
+	if found {
+		remote.LocalID = local.LocalID
+	} else {
+		remote.LocalID = newLocalID()
+	}
+	remote.Dirty = false
+	remote.Deleted = false
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := userBucket(tx, userID, entriesBucket)
+		if err != nil {
+			return err
+		}
+		return putEntry(bucket, remote)
+	})
+}
+
+// ApplyRemoteDeletion forgets an entry deleted on the server, unless there are
+// local changes to it still waiting to be pushed.
+func (s *Store) ApplyRemoteDeletion(userID, serverID string) error {
+	local, found, err := s.FindByServerID(userID, serverID)
+	if err != nil || !found || local.Dirty {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := userBucket(tx, userID, entriesBucket)
+		if err != nil {
+			return err
+		}
+		return bucket.Delete([]byte(local.LocalID))
+	})
+}
+
+// SyncCursor is the server time up to which changes have been pulled.
+func (s *Store) SyncCursor(userID string) (time.Time, error) {
+	var cursor time.Time
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket, err := userBucket(tx, userID, metaBucket)
+		if err != nil || bucket == nil {
+			return err
+		}
+		if data := bucket.Get(cursorKey); data != nil {
+			return cursor.UnmarshalText(data)
+		}
+		return nil
+	})
+	return cursor, err
+}
+
+func (s *Store) SetSyncCursor(userID string, cursor time.Time) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := userBucket(tx, userID, metaBucket)
+		if err != nil {
+			return err
+		}
+		data, err := cursor.MarshalText()
+		if err != nil {
+			return err
+		}
+		return bucket.Put(cursorKey, data)
+	})
+}
@@ -0,0 +1,203 @@
+package offline
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func openStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(filepath.Join(t.TempDir(), "local.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func save(t *testing.T, s *Store, entry Entry) Entry {
+	t.Helper()
+	saved, err := s.Save("alice", entry)
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	return saved
+}
+
+func get(t *testing.T, s *Store, localID string) Entry {
+	t.Helper()
+	entry, err := s.Get("alice", localID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	return entry
+}
+
+// synced saves an entry and marks it pushed as serverID, like after a sync.
+func synced(t *testing.T, s *Store, body, serverID string) Entry {
+	t.Helper()
+	entry := save(t, s, Entry{Body: body})
+	if err := s.MarkPushed("alice", entry, serverID, 1, entry.UpdatedAt); err != nil {
+		t.Fatalf("MarkPushed: %v", err)
+	}
+	return get(t, s, entry.LocalID)
+}
+
+func TestMarkPushed(t *testing.T) {
+	s := openStore(t)
+	serverTime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	entry := save(t, s, Entry{Body: "first"})
+	if err := s.MarkPushed("alice", entry, "s1", 1, serverTime); err != nil {
+		t.Fatalf("MarkPushed: %v", err)
+	}
+	got := get(t, s, entry.LocalID)
+	if got.Dirty || got.ServerID != "s1" || got.Version != 1 || !got.UpdatedAt.Equal(serverTime) {
+		t.Errorf("after MarkPushed = %+v, want clean at s1 version 1 updated at %v", got, serverTime)
+	}
+
+	// The entry is edited again while its first change is being pushed.
+	pushed := save(t, s, got)
+	edited := pushed
+	edited.Body = "second"
+	time.Sleep(time.Millisecond)
+	edited = save(t, s, edited)
+	if err := s.MarkPushed("alice", pushed, "s1", 2, serverTime); err != nil {
+		t.Fatalf("MarkPushed: %v", err)
+	}
+	got = get(t, s, entry.LocalID)
+	if !got.Dirty || got.Body != "second" || got.Version != 2 || !got.UpdatedAt.Equal(edited.UpdatedAt) {
+		t.Errorf("after MarkPushed of an older change = %+v, want the edit still dirty at version 2", got)
+	}
+	if pending, _ := s.Pending("alice"); len(pending) != 1 {
+		t.Errorf("Pending = %d entries, want the edit", len(pending))
+	}
+}
+
+func TestDeleteTombstones(t *testing.T) {
+	s := openStore(t)
+
+	local := save(t, s, Entry{Body: "never pushed"})
+	if err := s.Delete("alice", local); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := s.Get("alice", local.LocalID); err == nil {
+		t.Error("Get of a deleted entry the server never had: err = nil")
+	}
+
+	remote := synced(t, s, "pushed", "s1")
+	if err := s.Delete("alice", remote); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	tombstone := get(t, s, remote.LocalID)
+	if !tombstone.Deleted || !tombstone.Dirty {
+		t.Errorf("deleted entry = %+v, want a dirty tombstone", tombstone)
+	}
+	if entries, _ := s.List("alice", "", 0, 10); len(entries) != 0 {
+		t.Errorf("List = %v, want tombstones left out", entries)
+	}
+
+	if err := s.MarkPushed("alice", tombstone, "s1", 1, time.Now()); err != nil {
+		t.Fatalf("MarkPushed: %v", err)
+	}
+	if _, err := s.Get("alice", remote.LocalID); err == nil {
+		t.Error("Get of a tombstone whose deletion was pushed: err = nil")
+	}
+}
+
+func TestApplyRemote(t *testing.T) {
+	s := openStore(t)
+
+	clean := synced(t, s, "clean", "s1")
+	dirty := synced(t, s, "dirty", "s2")
+	dirty.Body = "edited here"
+	save(t, s, dirty)
+
+	for _, remote := range []Entry{
+		{ServerID: "s1", Body: "clean, edited there", Version: 2},
+		{ServerID: "s2", Body: "dirty, edited there", Version: 2},
+		{ServerID: "s3", Body: "new there", Version: 1},
+	} {
+		if err := s.ApplyRemote("alice", remote); err != nil {
+			t.Fatalf("ApplyRemote: %v", err)
+		}
+	}
+
+	if got := get(t, s, clean.LocalID); got.Body != "clean, edited there" || got.Version != 2 || got.Dirty {
+		t.Errorf("clean entry after ApplyRemote = %+v, want the server copy", got)
+	}
+	if got := get(t, s, dirty.LocalID); got.Body != "edited here" || !got.Dirty {
+		t.Errorf("dirty entry after ApplyRemote = %+v, want the local edit kept", got)
+	}
+	if got, found, _ := s.FindByServerID("alice", "s3"); !found || got.Body != "new there" || got.LocalID == "" {
+		t.Errorf("new entry after ApplyRemote = %+v, %v, want it stored", got, found)
+	}
+}
+
+func TestApplyRemoteDeletion(t *testing.T) {
+	s := openStore(t)
+
+	clean := synced(t, s, "clean", "s1")
+	dirty := synced(t, s, "dirty", "s2")
+	dirty.Body = "edited here"
+	save(t, s, dirty)
+
+	for _, serverID := range []string{"s1", "s2", "unknown"} {
+		if err := s.ApplyRemoteDeletion("alice", serverID); err != nil {
+			t.Fatalf("ApplyRemoteDeletion(%s): %v", serverID, err)
+		}
+	}
+
+	if _, err := s.Get("alice", clean.LocalID); err == nil {
+		t.Error("clean entry deleted on the server is still there")
+	}
+	if got := get(t, s, dirty.LocalID); got.Body != "edited here" || !got.Dirty {
+		t.Errorf("dirty entry after ApplyRemoteDeletion = %+v, want the local edit kept", got)
+	}
+}
+
+func TestList(t *testing.T) {
+	s := openStore(t)
+
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, tags := range [][]string{{"work"}, nil, {"work", "gym"}, {"gym"}, {"work"}} {
+		save(t, s, Entry{Body: string(rune('a' + i)), Tags: tags, CreatedAt: start.AddDate(0, 0, i)})
+	}
+	if _, err := s.Save("bob", Entry{Body: "bob's", Tags: []string{"work"}}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	bodies := func(tag string, offset, limit int) []string {
+		entries, err := s.List("alice", tag, offset, limit)
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		var bodies []string
+		for _, entry := range entries {
+			bodies = append(bodies, entry.Body)
+		}
+		return bodies
+	}
+
+	tests := []struct {
+		tag           string
+		offset, limit int
+		want          []string
+	}{
+		{"", 0, 2, []string{"e", "d"}},
+		{"", 2, 2, []string{"c", "b"}},
+		{"", 4, 2, []string{"a"}},
+		{"", 5, 2, nil},
+		{"work", 0, 10, []string{"e", "c", "a"}},
+		{"work", 1, 1, []string{"c"}},
+		{"gym", 0, 10, []string{"d", "c"}},
+		{"travel", 0, 10, nil},
+	}
+	for _, tt := range tests {
+		if got := bodies(tt.tag, tt.offset, tt.limit); !slices.Equal(got, tt.want) {
+			t.Errorf("List(%q, %d, %d) = %q, want %q", tt.tag, tt.offset, tt.limit, got, tt.want)
+		}
+	}
+}
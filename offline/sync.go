@@ -0,0 +1,102 @@
+package offline
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrConflict is returned by Remote.Update when the server copy has moved
+	// past the version the local change was based on.
+	ErrConflict = errors.New("entry changed on the server")
+	// ErrNotFound is returned by Remote.Update and Remote.Delete when the
+	// server no longer has the entry.
+	ErrNotFound = errors.New("entry not found on the server")
+)
+
+// Remote is the server side of a sync. Entries it returns are filled in except
+// for LocalID, Dirty and Deleted.
+type Remote interface {
+	Create(entry Entry) (Entry, error)
+	Update(entry Entry) (Entry, error)
+	Delete(serverID string) error
+	Changes(since time.Time) (entries []Entry, deleted []string, cursor time.Time, err error)
+}
+
+type Result struct {
+	Pushed    int
+	Pulled    int
+	Conflicts int
+}
+
+// Sync pushes the local changes of a user and then pulls the server's.
+//
+// Conflicts are resolved without losing text: when a local edit was based on
+// an older server version, or the entry was deleted on the server meanwhile,
+// the server copy is kept as is and the local edit is pushed as a new entry.
+// A local deletion of an entry that changed on the server still deletes it.
+func Sync(store *Store, remote Remote, userID string) (Result, error) {
+	var result Result
+
+	pending, err := store.Pending(userID)
+	if err != nil {
+		return result, err
+	}
+
+	for _, entry := range pending {
+		var pushed Entry
+		var err error
+
+		switch {
+		case entry.Deleted:
+			err = remote.Delete(entry.ServerID)
+			if errors.Is(err, ErrNotFound) {
+				err = nil
+			}
+			pushed = entry
+		case entry.ServerID == "":
+			pushed, err = remote.Create(entry)
+		default:
+			pushed, err = remote.Update(entry)
+			if errors.Is(err, ErrConflict) || errors.Is(err, ErrNotFound) {
+				result.Conflicts++
+				fork := entry
+				fork.ServerID = ""
+				pushed, err = remote.Create(fork)
+			}
+		}
+		if err != nil {
+			return result, fmt.Errorf("failed to push entry: %w", err)
+		}
+
+		if err := store.MarkPushed(userID, entry, pushed.ServerID, pushed.Version, pushed.UpdatedAt); err != nil {
+			return result, err
+		}
+		result.Pushed++
+	}
+
+	since, err := store.SyncCursor(userID)
+	if err != nil {
+		return result, err
+	}
+
+	entries, deleted, cursor, err := remote.Changes(since)
+	if err != nil {
+		return result, fmt.Errorf("failed to pull changes: %w", err)
+	}
+
+	for _, entry := range entries {
+		if err := store.ApplyRemote(userID, entry); err != nil {
+			return result, err
+		}
+		result.Pulled++
+	}
+	for _, serverID := range deleted {
+		if err := store.ApplyRemoteDeletion(userID, serverID); err != nil {
+			return result, err
+		}
+	}
+
+	return result, store.SetSyncCursor(userID, cursor)
+}
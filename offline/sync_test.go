@@ -0,0 +1,98 @@
+package offline
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// fakeRemote is a server holding entries by ID, with every entry updated
+// from an older version conflicting.
+type fakeRemote struct {
+	entries map[string]Entry
+	deleted []string
+	nextID  int
+}
+
+func (r *fakeRemote) Create(entry Entry) (Entry, error) {
+	r.nextID++
+	entry.ServerID = fmt.Sprintf("s%d", r.nextID)
+	entry.Version = 1
+	r.entries[entry.ServerID] = entry
+	return entry, nil
+}
+
+func (r *fakeRemote) Update(entry Entry) (Entry, error) {
+	current, ok := r.entries[entry.ServerID]
+	if !ok {
+		return Entry{}, ErrNotFound
+	}
+	if current.Version != entry.Version {
+		return Entry{}, ErrConflict
+	}
+	entry.Version++
+	r.entries[entry.ServerID] = entry
+	return entry, nil
+}
+
+func (r *fakeRemote) Delete(serverID string) error {
+	if _, ok := r.entries[serverID]; !ok {
+		return ErrNotFound
+	}
+	delete(r.entries, serverID)
+	r.deleted = append(r.deleted, serverID)
+	return nil
+}
+
+func (r *fakeRemote) Changes(since time.Time) ([]Entry, []string, time.Time, error) {
+	var entries []Entry
+	for _, entry := range r.entries {
+		entries = append(entries, entry)
+	}
+	return entries, r.deleted, time.Now(), nil
+}
+
+func TestSync(t *testing.T) {
+	s := openStore(t)
+	remote := &fakeRemote{entries: map[string]Entry{}}
+
+	created := save(t, s, Entry{Body: "written offline"})
+	stale := synced(t, s, "edited offline", "s100")
+	// The server copy moved on while this device was offline.
+	remote.entries["s100"] = Entry{ServerID: "s100", Body: "edited elsewhere", Version: 2}
+	stale.Body = "edited offline"
+	save(t, s, stale)
+
+	result, err := Sync(s, remote, "alice")
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if result.Pushed != 2 || result.Conflicts != 1 {
+		t.Errorf("Sync = %+v, want 2 pushed with 1 conflict", result)
+	}
+
+	// The conflicting edit went up as a new entry, next to the server copy.
+	bodies := map[string]bool{}
+	for _, entry := range remote.entries {
+		bodies[entry.Body] = true
+	}
+	for _, body := range []string{"written offline", "edited offline", "edited elsewhere"} {
+		if !bodies[body] {
+			t.Errorf("server is missing %q after Sync, has %v", body, bodies)
+		}
+	}
+
+	if got := get(t, s, created.LocalID); got.Dirty || got.ServerID == "" {
+		t.Errorf("pushed entry = %+v, want it clean with a server ID", got)
+	}
+	// Locally the edit becomes the new entry, and the server copy is pulled.
+	if got := get(t, s, stale.LocalID); got.Body != "edited offline" || got.ServerID == "s100" || got.Dirty {
+		t.Errorf("conflicting entry after Sync = %+v, want it pushed as a new entry", got)
+	}
+	if got, found, _ := s.FindByServerID("alice", "s100"); !found || got.Body != "edited elsewhere" {
+		t.Errorf("server copy after Sync = %+v, %v, want it pulled", got, found)
+	}
+	if pending, _ := s.Pending("alice"); len(pending) != 0 {
+		t.Errorf("Pending after Sync = %v, want none", pending)
+	}
+}
@@ -0,0 +1,386 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"journalCli/client"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// entriesPageSize is how many entries are requested per GET /entries call.
+const entriesPageSize = 20
+
+// entriesPrefetchMargin is how close to the end of the loaded entries the
+// cursor has to get before the next page is requested.
+const entriesPrefetchMargin = 5
+
+type readMode int
+
+const (
+	readList readMode = iota
+	readEntry
+	readConfirmDelete
+	readRevisions
+	readDiff
+	readSearch
+	readTags
+)
+
+type EntriesLoadedMsg struct {
+	Entries    []client.Entry
+	NextCursor string
+	Reset      bool
+	// Offline is set when the entries come from the local store because the
+	// server couldn't be reached.
+	Offline bool
+}
+
+type EntryUpdatedMsg struct {
+	Entry client.Entry
+}
+
+type EntryDeletedMsg struct {
+	Id string
+}
+
+type entryItem struct {
+	entry   client.Entry
+	snippet string
+	// location is the time zone the date is shown in.
+	location *time.Location
+}
+
+func (i entryItem) Title() string {
+	return entryTitle(i.entry.Body)
+}
+
+func (i entryItem) Description() string {
+	date := i.entry.CreatedAt.In(i.location).Format(dateFormat + " 15:04")
+	if emoji := moodEmoji(i.entry.Mood); emoji != "" {
+		date += " " + emoji
+	}
+	if i.snippet != "" {
+		return fmt.Sprintf("%s · %s", date, highlightSnippet(i.snippet))
+	}
+	description := fmt.Sprintf("%s · %d words", date, wordCount(i.entry.Body))
+	if tags := entryTagsLine(i.entry.Tags); tags != "" {
+		description += " · " + tags
+	}
+	return description
+}
+
+func (i entryItem) FilterValue() string {
+	return i.entry.Body
+}
+
+func entryTitle(body string) string {
+	for _, line := range strings.Split(body, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return "(empty entry)"
+}
+
+func wordCount(body string) int {
+	return len(strings.Fields(body))
+}
+
+func newEntriesList() list.Model {
+	entries := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+	entries.Title = "📖 Your Entries"
+	entries.Styles.Title = titleStyle
+	entries.SetStatusBarItemName("entry", "entries")
+	entries.SetFilteringEnabled(false)
+	entries.DisableQuitKeybindings()
+	entries.KeyMap.CursorUp = readKeys.Up
+	entries.KeyMap.CursorDown = readKeys.Down
+	entries.KeyMap.PrevPage = readKeys.PrevPage
+	entries.KeyMap.NextPage = readKeys.NextPage
+	entries.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{readKeys.Open, readKeys.Edit, readKeys.Delete, readKeys.Revisions, readKeys.Search,
+			readKeys.Tags, readKeys.Back}
+	}
+	return entries
+}
+
+func fetchEntries(cursor, tag string, v *vault, api *client.Client) tea.Msg {
+	opts := client.ListEntriesOptions{Limit: entriesPageSize, Cursor: cursor, Tag: tag}
+	page, err := api.ListEntries(context.Background(), opts)
+	if err != nil {
+		return ErrMsg{err}
+	}
+
+	for i := range page.Entries {
+		v.openEntry(&page.Entries[i])
+	}
+
+	return EntriesLoadedMsg{Entries: page.Entries, NextCursor: page.NextCursor, Reset: cursor == ""}
+}
+
+func updateEntry(id, body string, mood *int, v *vault, api *client.Client) tea.Msg {
+	sealed, enc, err := v.seal(body)
+	if err != nil {
+		return ErrMsg{err}
+	}
+	entry, err := api.UpdateEntry(context.Background(), id, client.UpdateEntryRequest{Body: sealed, Encryption: enc, Mood: mood})
+	if err != nil {
+		return ErrMsg{err}
+	}
+	v.openEntry(entry)
+
+	return EntryUpdatedMsg{Entry: *entry}
+}
+
+func deleteEntry(id string, api *client.Client) tea.Msg {
+	if err := api.DeleteEntry(context.Background(), id); err != nil {
+		return ErrMsg{err}
+	}
+	return EntryDeletedMsg{Id: id}
+}
+
+// loadEntries starts loading the first page of entries, discarding any that
+// were loaded before.
+func (m *Model) loadEntries() tea.Cmd {
+	m.loadingEntries = true
+	m.nextCursor = ""
+	store, userId, tag, v, api := m.local, m.user.ID, m.tagFilter, m.vault, m.api
+	return func() tea.Msg { return fetchEntriesOrLocal(store, userId, "", tag, v, api) }
+}
+
+// loadMoreEntries requests the next page once the cursor gets close to the
+// end of what has been loaded so far.
+func (m *Model) loadMoreEntries() tea.Cmd {
+	if m.loadingEntries || m.nextCursor == "" {
+		return nil
+	}
+	if m.entries.Index() < len(m.entries.Items())-entriesPrefetchMargin {
+		return nil
+	}
+	m.loadingEntries = true
+	store, userId, cursor, tag, v, api := m.local, m.user.ID, m.nextCursor, m.tagFilter, m.vault, m.api
+	return func() tea.Msg { return fetchEntriesOrLocal(store, userId, cursor, tag, v, api) }
+}
+
+func (m *Model) applyEntries(msg EntriesLoadedMsg) tea.Cmd {
+	m.loadingEntries = false
+	m.nextCursor = msg.NextCursor
+
+	items := []list.Item{}
+	if !msg.Reset {
+		items = m.entries.Items()
+	}
+	for _, entry := range msg.Entries {
+		items = append(items, entryItem{entry: entry, location: m.location})
+	}
+	cmd := m.entries.SetItems(items)
+	if msg.Reset {
+		m.entries.Title = entriesTitle(m.tagFilter, msg.Offline)
+		m.entries.ResetSelected()
+	}
+	return cmd
+}
+
+// replaceEntry swaps an edited entry into the list, if it is loaded.
+func (m *Model) replaceEntry(entry client.Entry) tea.Cmd {
+	for i, item := range m.entries.Items() {
+		if item.(entryItem).entry.ID == entry.ID {
+			return m.entries.SetItem(i, entryItem{entry: entry, snippet: item.(entryItem).snippet, location: m.location})
+		}
+	}
+	return nil
+}
+
+func (m *Model) removeEntry(id string) {
+	for i, item := range m.entries.Items() {
+		if item.(entryItem).entry.ID == id {
+			m.entries.RemoveItem(i)
+			return
+		}
+	}
+}
+
+func (m Model) selectedEntry() (client.Entry, bool) {
+	item, ok := m.entries.SelectedItem().(entryItem)
+	return item.entry, ok
+}
+
+// editEntry opens an existing entry in the journal textarea; saving it then
+// updates the entry instead of creating a new one.
+func (m *Model) editEntry(entry client.Entry) {
+	if entry.Locked {
+		m.err = errVaultLocked
+		return
+	}
+	m.page = PageJournal
+	// Keep the new entry being written for when the user is done here.
+	m.journalDraft = m.journal.Value()
+	m.editingEntryId = entry.ID
+	m.msg = ""
+	m.err = nil
+	m.journal.SetValue(entry.Body)
+	m.journal.Focus()
+	m.inputing = true
+}
+
+func (m *Model) openEntry(entry client.Entry) {
+	m.readMode = readEntry
+	m.reader.SetContent(lipgloss.NewStyle().Width(m.reader.Width).Render(entry.Body))
+	m.reader.GotoTop()
+}
+
+func (m *Model) resizeReadPage() {
+	m.entries.SetSize(m.width, m.height-2)
+	m.reader.Width = m.width
+	m.reader.Height = m.height - 4
+}
+
+func (m Model) updateReadPage(msg tea.KeyMsg) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	if key.Matches(msg, quitKey) {
+		return m, tea.Quit
+	}
+
+	switch m.readMode {
+	case readEntry:
+		switch {
+		case key.Matches(msg, entryKeys.Back):
+			m.readMode = readList
+			return m, nil
+		case key.Matches(msg, entryKeys.Edit):
+			if entry, ok := m.selectedEntry(); ok {
+				m.editEntry(entry)
+			}
+			return m, nil
+		}
+		m.reader, cmd = m.reader.Update(msg)
+		return m, cmd
+
+	case readConfirmDelete:
+		m.readMode = readList
+		if !key.Matches(msg, deleteKeys.Confirm) {
+			return m, nil
+		}
+		if entry, ok := m.selectedEntry(); ok {
+			id := entry.ID
+			if m.local != nil {
+				return m, m.deleteLocal(id)
+			}
+			return m, func() tea.Msg { return deleteEntry(id, m.api) }
+		}
+		return m, nil
+
+	case readRevisions:
+		return m.updateRevisions(msg)
+
+	case readSearch:
+		return m.updateSearch(msg)
+
+	case readTags:
+		return m.updateTagFilter(msg)
+
+	case readDiff:
+		if key.Matches(msg, diffKeys.Back) {
+			m.readMode = readRevisions
+			return m, nil
+		}
+		m.reader, cmd = m.reader.Update(msg)
+		return m, cmd
+	}
+
+	m.msg = ""
+	switch {
+	case key.Matches(msg, readKeys.Back):
+		if m.searchQuery != "" {
+			return m, m.clearSearch()
+		}
+		m.page = PageMenu
+		m.err = nil
+		return m, nil
+	case key.Matches(msg, readKeys.Search):
+		return m, m.openSearch()
+	case key.Matches(msg, readKeys.Tags):
+		return m, m.openTagFilter()
+	case key.Matches(msg, readKeys.Open):
+		if entry, ok := m.selectedEntry(); ok {
+			m.openEntry(entry)
+		}
+		return m, nil
+	case key.Matches(msg, readKeys.Edit):
+		if entry, ok := m.selectedEntry(); ok {
+			m.editEntry(entry)
+		}
+		return m, nil
+	case key.Matches(msg, readKeys.Delete):
+		if _, ok := m.selectedEntry(); ok {
+			m.readMode = readConfirmDelete
+		}
+		return m, nil
+	case key.Matches(msg, readKeys.Revisions):
+		if entry, ok := m.selectedEntry(); ok {
+			return m, m.openRevisions(entry)
+		}
+		return m, nil
+	}
+
+	m.entries, cmd = m.entries.Update(msg)
+	return m, tea.Batch(cmd, m.loadMoreEntries())
+}
+
+func renderReadPage(m Model) string {
+	var content string
+	switch m.readMode {
+	case readEntry:
+		entry, _ := m.selectedEntry()
+		header := titleStyle.Render(entry.CreatedAt.In(m.location).Format("Monday, 02 January 2006 15:04"))
+		footer := fmt.Sprintf("%3.f%% ", m.reader.ScrollPercent()*100) + keyHints(entryKeyMap, m.width-5)
+		content = lipgloss.JoinVertical(lipgloss.Left, header, m.reader.View(), footer)
+	case readRevisions:
+		content = renderRevisions(m)
+	case readTags:
+		content = renderTagFilter(m)
+	case readDiff:
+		footer := fmt.Sprintf("%3.f%% ", m.reader.ScrollPercent()*100) + keyHints(diffKeyMap, m.width-5)
+		content = lipgloss.JoinVertical(lipgloss.Left, titleStyle.Render("🔀 Diff"), m.reader.View(), footer)
+	default:
+		// The title and help follow the theme, which may have changed since
+		// the list was made.
+		entries := m.entries
+		entries.Styles.Title = titleStyle
+		entries.Help.Styles = helpStyles
+		content = entries.View()
+		if m.loadingEntries && len(m.entries.Items()) == 0 {
+			content = lipgloss.JoinVertical(lipgloss.Left, titleStyle.Render("📖 Your Entries"), "Loading entries...")
+		}
+		if m.readMode == readSearch {
+			content = lipgloss.JoinVertical(lipgloss.Left, inputBoxStyle.Width(m.width-2).Render(m.search.View()), content)
+		}
+		if m.readMode == readConfirmDelete {
+			entry, _ := m.selectedEntry()
+			prompt := fmt.Sprintf("Delete %q? This can't be undone.", entryTitle(entry.Body))
+			content = lipgloss.JoinVertical(lipgloss.Left, content, errorStyle.Render(prompt), keyHints(deleteKeyMap, m.width))
+		}
+	}
+
+	if m.err != nil {
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			content,
+			errorStyle.Render(fmt.Sprintf("Error: %v", m.err)),
+		)
+	} else if m.msg != "" {
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			content,
+			successStyle.Render(m.msg),
+		)
+	}
+	return content
+}
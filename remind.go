@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"journalCli/client"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// remindCommand runs until interrupted, sending a desktop notification at a
+// time of day when nothing has been written that day. With --once it checks
+// only once, to be run from cron or a systemd timer instead.
+func remindCommand(ctx context.Context, api *client.Client, at string, args []string) error {
+	fs, _ := newFlagSet("remind")
+	fs.StringVar(&at, "at", at, "time of day to remind at, as HH:MM")
+	once := fs.Bool("once", false, "check now and exit instead of waiting for the time")
+	if _, err := parseArgs(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	// The account's settings win over the config, and --at over both.
+	settings, err := api.Settings(ctx)
+	if err != nil {
+		return err
+	}
+	if settings.ReminderTime != "" && !flagPassed(fs, "at") {
+		at = settings.ReminderTime
+	}
+	loc := time.Local
+	if settings.TimeZone != "" {
+		if loc, err = time.LoadLocation(settings.TimeZone); err != nil {
+			return err
+		}
+	}
+	tz := localTimeZone(loc)
+
+	clock, err := time.Parse("15:04", at)
+	if err != nil {
+		return fmt.Errorf("invalid --at %q, expected a time like 20:00", at)
+	}
+
+	if *once {
+		return remindIfNotWritten(ctx, api, tz)
+	}
+
+	fmt.Printf("Reminding you at %s on days without an entry, Ctrl+C to stop\n", at)
+	next := nextReminder(time.Now().In(loc), clock)
+	// Waking up every minute rather than sleeping until the reminder keeps it
+	// on time after the computer was suspended.
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			now = now.In(loc)
+			if now.Before(next) {
+				continue
+			}
+			// A reminder missed while suspended overnight is dropped rather
+			// than sent the next morning.
+			if now.Format(time.DateOnly) == next.Format(time.DateOnly) {
+				err := remindIfNotWritten(ctx, api, tz)
+				if errors.Is(err, client.ErrUnauthorized) {
+					return err
+				}
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "%s: %v\n", now.Format("2006-01-02 15:04"), err)
+				}
+			}
+			next = nextReminder(now, clock)
+		}
+	}
+}
+
+// nextReminder returns the first time at the clock time of day after now.
+func nextReminder(now, clock time.Time) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+func remindIfNotWritten(ctx context.Context, api *client.Client, tz string) error {
+	streak, err := api.Streak(ctx, tz)
+	if err != nil {
+		return err
+	}
+	if streak.WroteToday {
+		return nil
+	}
+
+	body := "You haven't written in your journal today."
+	if streak.Current > 0 {
+		body = fmt.Sprintf("Write today to keep your %d-day streak going.", streak.Current)
+	}
+	return notify("Time to journal ✏️", body)
+}
+
+// notify shows a desktop notification with notify-send, or straight over
+// D-Bus where it isn't installed.
+func notify(title, body string) error {
+	if path, err := exec.LookPath("notify-send"); err == nil {
+		if out, err := exec.Command(path, "--app-name=journalCli", title, body).CombinedOutput(); err != nil {
+			return fmt.Errorf("notify-send failed: %w: %s", err, out)
+		}
+		return nil
+	}
+
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return fmt.Errorf("failed to send a notification, install notify-send or run a desktop session: %w", err)
+	}
+	defer conn.Close()
+	notifications := conn.Object("org.freedesktop.Notifications", "/org/freedesktop/Notifications")
+	call := notifications.Call("org.freedesktop.Notifications.Notify", 0,
+		"journalCli", uint32(0), "", title, body, []string{}, map[string]dbus.Variant{}, int32(-1))
+	if call.Err != nil {
+		return fmt.Errorf("failed to send a notification: %w", call.Err)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"journalCli/client"
+	"journalCli/utils"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type RevisionsLoadedMsg struct {
+	EntryId   string
+	Revisions []client.EntryRevision
+}
+
+// Styles of the lines a diff adds and removes, set by applyTheme.
+var diffInsertStyle, diffDeleteStyle lipgloss.Style
+
+func fetchRevisions(entryId string, v *vault, api *client.Client) tea.Msg {
+	revisions, err := api.EntryRevisions(context.Background(), entryId)
+	if err != nil {
+		return ErrMsg{err}
+	}
+
+	for i, revision := range revisions {
+		var ok bool
+		revisions[i].Body, ok = v.open(revision.Body, revision.Encryption)
+		revisions[i].Locked = !ok
+	}
+
+	return RevisionsLoadedMsg{EntryId: entryId, Revisions: revisions}
+}
+
+func (m *Model) openRevisions(entry client.Entry) tea.Cmd {
+	m.readMode = readRevisions
+	m.revisions = nil
+	m.revisionCursor = 0
+	m.revisionMark = -1
+	id := entry.ID
+	v, api := m.vault, m.api
+	return func() tea.Msg { return fetchRevisions(id, v, api) }
+}
+
+// versions lists the current body of the selected entry followed by its
+// revisions, newest first. The current version has an empty Id.
+func (m Model) versions() []client.EntryRevision {
+	entry, _ := m.selectedEntry()
+	current := client.EntryRevision{EntryID: entry.ID, Body: entry.Body, CreatedAt: entry.UpdatedAt, Locked: entry.Locked}
+	return append([]client.EntryRevision{current}, m.revisions...)
+}
+
+func (m Model) updateRevisions(msg tea.KeyMsg) (Model, tea.Cmd) {
+	versions := m.versions()
+
+	switch {
+	case key.Matches(msg, revisionKeys.Back):
+		m.readMode = readList
+		m.msg = ""
+	case key.Matches(msg, revisionKeys.Up):
+		if m.revisionCursor > 0 {
+			m.revisionCursor--
+		}
+	case key.Matches(msg, revisionKeys.Down):
+		if m.revisionCursor < len(versions)-1 {
+			m.revisionCursor++
+		}
+	case key.Matches(msg, revisionKeys.Mark):
+		if m.revisionMark == m.revisionCursor {
+			m.revisionMark = -1
+		} else {
+			m.revisionMark = m.revisionCursor
+		}
+	case key.Matches(msg, revisionKeys.Diff):
+		// Without a mark, compare the selected version with the current one.
+		from := m.revisionMark
+		if from < 0 {
+			from = 0
+		}
+		older, newer := versions[max(from, m.revisionCursor)], versions[min(from, m.revisionCursor)]
+		m.readMode = readDiff
+		m.reader.SetContent(renderDiff(older, newer, m.location))
+		m.reader.GotoTop()
+	case key.Matches(msg, revisionKeys.Restore):
+		if m.revisionCursor == 0 {
+			m.err = fmt.Errorf("That is already the current version")
+			return m, nil
+		}
+		revision := versions[m.revisionCursor]
+		if revision.Locked {
+			m.err = errVaultLocked
+			return m, nil
+		}
+		return m, func() tea.Msg { return updateEntry(revision.EntryID, revision.Body, nil, m.vault, m.api) }
+	}
+	return m, nil
+}
+
+func versionLabel(version client.EntryRevision) string {
+	if version.ID == "" {
+		return "current"
+	}
+	return "rev " + version.ID
+}
+
+func renderDiff(older, newer client.EntryRevision, loc *time.Location) string {
+	var b strings.Builder
+	b.WriteString(diffDeleteStyle.Render(fmt.Sprintf("--- %s (%s)", versionLabel(older), older.CreatedAt.In(loc).Format(dateFormat+" 15:04:05"))))
+	b.WriteString("\n")
+	b.WriteString(diffInsertStyle.Render(fmt.Sprintf("+++ %s (%s)", versionLabel(newer), newer.CreatedAt.In(loc).Format(dateFormat+" 15:04:05"))))
+	b.WriteString("\n\n")
+
+	for _, line := range utils.DiffLines(older.Body, newer.Body) {
+		switch line.Op {
+		case utils.DiffInsert:
+			b.WriteString(diffInsertStyle.Render("+ " + line.Text))
+		case utils.DiffDelete:
+			b.WriteString(diffDeleteStyle.Render("- " + line.Text))
+		default:
+			b.WriteString("  " + line.Text)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func renderRevisions(m Model) string {
+	var rows []string
+	for i, version := range m.versions() {
+		cursor := "  "
+		if i == m.revisionCursor {
+			cursor = "> "
+		}
+		mark := " "
+		if i == m.revisionMark {
+			mark = "*"
+		}
+		row := fmt.Sprintf("%s%s %-10s %s · %d words", cursor, mark, versionLabel(version),
+			version.CreatedAt.In(m.location).Format(dateFormat+" 15:04:05"), wordCount(version.Body))
+		if i == m.revisionCursor {
+			row = selectedStyle.Render(row)
+		}
+		rows = append(rows, row)
+	}
+
+	footer := "\n" + keyHints(revisionKeyMap, m.width)
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		titleStyle.Render("🕓 Revisions"),
+		strings.Join(rows, "\n"),
+		footer,
+	)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"journalCli/client"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The server wraps matching words of search snippets in these markers.
+const (
+	highlightStart = "<mark>"
+	highlightStop  = "</mark>"
+)
+
+// highlightStyle marks matching words, set by applyTheme.
+var highlightStyle lipgloss.Style
+
+type SearchResultsMsg struct {
+	Query   string
+	Results []client.SearchResult
+}
+
+func newSearchInput() textinput.Model {
+	search := textinput.New()
+	search.Placeholder = "Search your journal..."
+	search.Prompt = "🔎 "
+	search.CharLimit = 200
+	return search
+}
+
+func searchEntries(q string, api *client.Client) tea.Msg {
+	results, err := api.SearchEntries(context.Background(), q, 0)
+	if err != nil {
+		return ErrMsg{err}
+	}
+	return SearchResultsMsg{Query: q, Results: results}
+}
+
+// highlightSnippet flattens a search snippet to one line and styles the
+// highlighted words.
+func highlightSnippet(snippet string) string {
+	snippet = strings.Join(strings.Fields(snippet), " ")
+	var b strings.Builder
+	for {
+		before, rest, found := strings.Cut(snippet, highlightStart)
+		b.WriteString(before)
+		if !found {
+			return b.String()
+		}
+		match, after, _ := strings.Cut(rest, highlightStop)
+		b.WriteString(highlightStyle.Render(match))
+		snippet = after
+	}
+}
+
+func (m *Model) openSearch() tea.Cmd {
+	m.readMode = readSearch
+	m.search.SetValue(m.searchQuery)
+	m.search.CursorEnd()
+	return m.search.Focus()
+}
+
+func (m *Model) applySearchResults(msg SearchResultsMsg) tea.Cmd {
+	m.searchQuery = msg.Query
+	m.nextCursor = ""
+
+	items := []list.Item{}
+	for _, result := range msg.Results {
+		items = append(items, entryItem{entry: result.Entry, snippet: result.Snippet, location: m.location})
+	}
+	m.entries.Title = fmt.Sprintf("🔎 Results for %q", msg.Query)
+	cmd := m.entries.SetItems(items)
+	m.entries.ResetSelected()
+	return cmd
+}
+
+// clearSearch leaves the search results and goes back to the full list.
+func (m *Model) clearSearch() tea.Cmd {
+	return m.applyTagFilter(m.tagFilter)
+}
+
+func (m Model) updateSearch(msg tea.KeyMsg) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch {
+	case key.Matches(msg, searchKeys.Cancel):
+		m.readMode = readList
+		m.search.Blur()
+		return m, nil
+	case key.Matches(msg, searchKeys.Search):
+		m.readMode = readList
+		m.search.Blur()
+		q := strings.TrimSpace(m.search.Value())
+		if q == "" {
+			if m.searchQuery == "" {
+				return m, nil
+			}
+			return m, m.clearSearch()
+		}
+		return m, func() tea.Msg { return searchEntries(q, m.api) }
+	}
+
+	m.search, cmd = m.search.Update(msg)
+	return m, cmd
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"journalCli/db"
+	"os"
+	"strconv"
+	"text/tabwriter"
+)
+
+const migrateUsage = `usage: server migrate <command>
+
+commands:
+  up [N]     apply all pending migrations, or only the next N
+  down [N]   revert the last applied migration, or the last N
+  status     list migrations and whether they are applied`
+
+// migrate runs `server migrate up|down|status`.
+func migrate(args []string) error {
+	if len(args) == 0 || len(args) > 2 {
+		return errors.New(migrateUsage)
+	}
+
+	steps := 0
+	if len(args) == 2 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid number of migrations %q\n\n%s", args[1], migrateUsage)
+		}
+		steps = n
+	}
+
+	switch args[0] {
+	case "up", "down", "status":
+	default:
+		return fmt.Errorf("unknown migrate command %q\n\n%s", args[0], migrateUsage)
+	}
+	if args[0] == "status" && len(args) > 1 {
+		return errors.New(migrateUsage)
+	}
+
+	database, err := db.Connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect to the database: %w", err)
+	}
+	defer db.CloseDB(database)
+
+	switch args[0] {
+	case "up":
+		applied, err := db.MigrateUp(database, steps)
+		for _, migration := range applied {
+			fmt.Printf("applied  %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("schema is up to date")
+		}
+		return err
+	case "down":
+		if steps == 0 {
+			steps = 1
+		}
+		reverted, err := db.MigrateDown(database, steps)
+		for _, migration := range reverted {
+			fmt.Printf("reverted %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err == nil && len(reverted) == 0 {
+			fmt.Println("no migrations to revert")
+		}
+		return err
+	default:
+		return printMigrationStatus(database)
+	}
+}
+
+func printMigrationStatus(database *sql.DB) error {
+	statuses, err := db.MigrationStatuses(database)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Local().Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if err := db.CheckSchema(database); err != nil {
+		fmt.Println()
+		fmt.Println(err)
+	}
+	return nil
+}
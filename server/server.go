@@ -1,25 +1,133 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"journalCli/config"
 	"journalCli/db"
+	"journalCli/db/memory"
+	"journalCli/db/sqlite"
 	"journalCli/handlers"
+	"journalCli/oauth"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
-func server() {
-	database := db.InitDB()
+// openStore opens the store the config asks for. Postgres has to be migrated
+// with `server migrate up` first; the other stores set themselves up.
+func openStore(cfg config.Database) (db.Store, error) {
+	switch cfg.Driver {
+	case "sqlite":
+		return sqlite.Open(cfg.Path)
+	case "memory":
+		return memory.New(), nil
+	default:
+		db.Configure(cfg)
+		return db.NewPostgres(db.InitDB()), nil
+	}
+}
+
+// server serves the API until SIGINT or SIGTERM, then stops accepting
+// connections and waits for in-flight requests before closing the store.
+func server(cfg config.Config) error {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	store, err := openStore(cfg.Database)
+	if err != nil {
+		return fmt.Errorf("failed to open the %s database: %w", cfg.Database.Driver, err)
+	}
+
+	defer store.Close()
+
+	if err := store.DeleteExpiredSessions(); err != nil {
+		logger.Warn("failed to delete expired sessions", slog.Any("error", err))
+	}
+
+	var google *oauth.Provider
+	if cfg.OAuth.Google.Enabled() {
+		google = oauth.NewProvider("google", cfg.OAuth.Google)
+	}
+	api := handlers.New(store, google)
+
+	srv := &http.Server{
+		Addr: cfg.Server.Listen,
+		Handler: handlers.Chain(api.Routes(),
+			handlers.RequestID,
+			handlers.AccessLog(logger),
+			handlers.Recover(logger),
+			handlers.Timeout(cfg.Server.RequestTimeout),
+			handlers.LimitBody(int64(cfg.Server.MaxBodyBytes)),
+		),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       cfg.Server.RequestTimeout,
+		// Leaves Timeout the time to write its 503.
+		WriteTimeout: cfg.Server.RequestTimeout + 5*time.Second,
+		IdleTimeout:  2 * time.Minute,
+		ErrorLog:     slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.ListenAndServe()
+	}()
+	logger.Info("server running", slog.String("listen", cfg.Server.Listen), slog.String("database", cfg.Database.Driver))
 
-	defer db.CloseDB(database)
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+	// A second signal kills the server without waiting.
+	stop()
 
-	http.HandleFunc("/signup", handlers.SignUpHandler)
-	http.HandleFunc("/login", handlers.LoginHandler)
-	fmt.Println("Server running on http://localhost:8080")
-	if err := http.ListenAndServe(":8080", nil); err != nil {
-		fmt.Printf("Server error: %v\n", err)
+	logger.Info("shutting down, draining in-flight requests", slog.Duration("timeout", cfg.Server.ShutdownTimeout))
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to drain in-flight requests: %w", err)
 	}
+	logger.Info("server stopped")
+	return nil
 }
 
 func main() {
-	server()
+	cfg, args, err := config.Load("server", os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if len(args) > 0 && args[0] == "migrate" {
+		if cfg.Database.Driver != "postgres" {
+			fmt.Fprintf(os.Stderr, "migrate only applies to postgres, the %s store migrates itself\n", cfg.Database.Driver)
+			os.Exit(2)
+		}
+		db.Configure(cfg.Database)
+		if err := migrate(args[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(args) > 0 {
+		fmt.Fprintf(os.Stderr, "unknown command %q, expected migrate\n", args[0])
+		os.Exit(2)
+	}
+
+	if err := server(cfg); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"journalCli/client"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// LogoutSuccessMsg means the user is logged out on this device. ServerErr is
+// why the server couldn't end the session too, if it couldn't.
+type LogoutSuccessMsg struct {
+	ServerErr error
+}
+
+// logout forgets the saved session even when the server can't be told, so a
+// network error or an expired token doesn't leave the user logged in here.
+func logout(api *client.Client) tea.Msg {
+	err := api.Logout(context.Background(), false)
+	if err := deleteSession(); err != nil {
+		fmt.Fprintf(debugFile, "Failed to delete the saved session: %v\n", err)
+	}
+	return LogoutSuccessMsg{ServerErr: err}
+}
+
+// rememberSession saves the session after logging in, so the next start goes
+// straight to the menu. Failing to save it doesn't fail the login.
+func rememberSession(api *client.Client, auth *client.AuthResponse) {
+	session := savedSession{ServerURL: api.ServerURL(), User: auth.User, Token: auth.Token, ExpiresAt: auth.ExpiresAt}
+	if err := saveSession(session); err != nil {
+		fmt.Fprintf(debugFile, "%v\n", err)
+	}
+}
+
+// restoreSession logs the model in with the saved session, if there is a valid
+// one for its server.
+func (m *Model) restoreSession() bool {
+	session, err := loadSession(m.api.ServerURL())
+	if err != nil {
+		return false
+	}
+	m.api.SetToken(session.Token)
+	m.user = session.User
+	m.page = PageMenu
+	m.inputing = false
+	m.username.Blur()
+	return true
+}
+
+// sessionExpired sends the user back to the login page once the server stops
+// accepting the token.
+func (m *Model) sessionExpired() {
+	if err := deleteSession(); err != nil {
+		fmt.Fprintf(debugFile, "Failed to delete the saved session: %v\n", err)
+	}
+	m.api.SetToken("")
+	m.resetToLogin()
+	m.err = fmt.Errorf("Session expired, please log in again")
+}
+
+func (m *Model) resetToLogin() {
+	m.vault.SetEnvelope(nil)
+	m.api.SetToken("")
+	m.user = client.User{}
+	m.streak = nil
+	m.stats = nil
+	m.applySettings(defaultSettings)
+	m.page = PageLogin
+	m.inputing = true
+	m.err = nil
+	m.Focused = 0
+	m.username.SetValue("")
+	m.password.SetValue("")
+	m.username.Focus()
+	m.password.Blur()
+}
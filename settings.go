@@ -0,0 +1,413 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"journalCli/client"
+	"journalCli/theme"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// defaultSettings apply until the user's own are loaded, and match the
+// server's defaults.
+var defaultSettings = client.Settings{
+	EditorMode:  client.EditorStandard,
+	DateFormat:  "Mon, 02 Jan 2006",
+	Theme:       "default",
+	LineNumbers: true,
+}
+
+var (
+	editorModes = []string{client.EditorStandard, client.EditorVim}
+	dateFormats = []string{"Mon, 02 Jan 2006", "2006-01-02", "02/01/2006", "01/02/2006", "2 January 2006"}
+)
+
+// dateFormat is the layout dates are shown with, from the user's settings.
+var dateFormat = defaultSettings.DateFormat
+
+// settingsField is a row of the Settings form.
+type settingsField int
+
+const (
+	fieldEditorMode settingsField = iota
+	fieldTimeZone
+	fieldDateFormat
+	fieldReminderTime
+	fieldTheme
+	fieldLineNumbers
+	fieldEncryption
+	fieldLocked
+	fieldPassphrase
+	fieldExport
+	fieldCount
+)
+
+var settingsLabels = map[settingsField]string{
+	fieldEditorMode:   "Editor mode",
+	fieldTimeZone:     "Time zone",
+	fieldDateFormat:   "Date format",
+	fieldReminderTime: "Reminder time",
+	fieldTheme:        "Theme",
+	fieldLineNumbers:  "Line numbers",
+	fieldEncryption:   "Encryption",
+	fieldLocked:       "Journal locked",
+	fieldPassphrase:   "Change passphrase",
+	fieldExport:       "Export journal",
+}
+
+type SettingsLoadedMsg struct {
+	Settings *client.Settings
+}
+
+type SettingsSavedMsg struct {
+	Settings *client.Settings
+}
+
+func fetchSettings(api *client.Client) tea.Msg {
+	settings, err := api.Settings(context.Background())
+	if errors.Is(err, client.ErrUnauthorized) {
+		return ErrMsg{err}
+	}
+	if err != nil {
+		// The defaults stay until the next login, which is better than not
+		// letting the user in while the server is unreachable.
+		fmt.Fprintf(debugFile, "Failed to load settings: %v\n", err)
+		return nil
+	}
+	return SettingsLoadedMsg{Settings: settings}
+}
+
+func saveSettings(settings client.Settings, api *client.Client) tea.Msg {
+	saved, err := api.PutSettings(context.Background(), settings)
+	if err != nil {
+		return ErrMsg{err}
+	}
+	return SettingsSavedMsg{Settings: saved}
+}
+
+func newSettingsInput() textinput.Model {
+	input := textinput.New()
+	input.CharLimit = 64
+	input.Width = 30
+	return input
+}
+
+// applySettings makes the model follow the user's settings.
+func (m *Model) applySettings(settings client.Settings) {
+	m.settings = settings
+	m.settingsDraft = settings
+	m.journal.ShowLineNumbers = settings.LineNumbers
+	dateFormat = settings.DateFormat
+	applyTheme(themeNamed(settings.Theme))
+
+	m.location = time.Local
+	if settings.TimeZone != "" {
+		if loc, err := time.LoadLocation(settings.TimeZone); err == nil {
+			m.location = loc
+		} else {
+			fmt.Fprintf(debugFile, "Ignoring the time zone setting: %v\n", err)
+		}
+	}
+	// Entries already listed show their dates in the new time zone too.
+	items := m.entries.Items()
+	for i, item := range items {
+		entry := item.(entryItem)
+		entry.location = m.location
+		items[i] = entry
+	}
+	m.entries.SetItems(items)
+}
+
+func (m *Model) openSettings() {
+	m.page = PageSettings
+	m.settingsDraft = m.settings
+	m.settingsCursor = 0
+	m.settingsEditing = false
+	m.msg = ""
+	m.err = nil
+}
+
+// cycle returns the option after current in options, or before it when back
+// is set, wrapping around at either end.
+func cycle(options []string, current string, back bool) string {
+	i := slices.Index(options, current)
+	if back {
+		i = (i + len(options) - 1) % len(options)
+	} else {
+		i = (i + 1) % len(options)
+	}
+	return options[max(i, 0)]
+}
+
+// changeSetting changes the option of the field under the cursor, reporting
+// whether it has options to change.
+func (m *Model) changeSetting(back bool) bool {
+	draft := &m.settingsDraft
+	switch m.settingsCursor {
+	case fieldEditorMode:
+		draft.EditorMode = cycle(editorModes, draft.EditorMode, back)
+	case fieldDateFormat:
+		draft.DateFormat = cycle(dateFormats, draft.DateFormat, back)
+	case fieldTheme:
+		draft.Theme = cycle(theme.Names(themes), draft.Theme, back)
+		// The new theme shows right away, until the change is dropped.
+		applyTheme(themeNamed(draft.Theme))
+	case fieldLineNumbers:
+		draft.LineNumbers = !draft.LineNumbers
+	default:
+		return false
+	}
+	return true
+}
+
+// editSetting starts typing in the text field under the cursor, reporting
+// whether it is one.
+func (m *Model) editSetting() (tea.Cmd, bool) {
+	switch m.settingsCursor {
+	case fieldTimeZone:
+		m.settingsInput.Placeholder = "e.g. Europe/Berlin, empty for this device's"
+		m.settingsInput.SetValue(m.settingsDraft.TimeZone)
+	case fieldReminderTime:
+		m.settingsInput.Placeholder = "e.g. 20:00, empty for the config's"
+		m.settingsInput.SetValue(m.settingsDraft.ReminderTime)
+	default:
+		return nil, false
+	}
+	m.settingsEditing = true
+	m.err = nil
+	m.settingsInput.CursorEnd()
+	return m.settingsInput.Focus(), true
+}
+
+func (m Model) updateSettingsInput(msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, settingInputKeys.Cancel):
+		m.settingsEditing = false
+		m.settingsInput.Blur()
+		m.err = nil
+		return m, nil
+	case key.Matches(msg, settingInputKeys.Confirm):
+		value := strings.TrimSpace(m.settingsInput.Value())
+		switch m.settingsCursor {
+		case fieldTimeZone:
+			if _, err := time.LoadLocation(value); err != nil {
+				m.err = fmt.Errorf("Unknown time zone %q", value)
+				return m, nil
+			}
+			m.settingsDraft.TimeZone = value
+		case fieldReminderTime:
+			if _, err := time.Parse("15:04", value); value != "" && err != nil {
+				m.err = fmt.Errorf("Invalid time %q, expected a time like 20:00", value)
+				return m, nil
+			}
+			m.settingsDraft.ReminderTime = value
+		}
+		m.settingsEditing = false
+		m.settingsInput.Blur()
+		m.err = nil
+		return m, nil
+	case key.Matches(msg, quitKey):
+		return m, tea.Quit
+	}
+
+	var cmd tea.Cmd
+	m.settingsInput, cmd = m.settingsInput.Update(msg)
+	return m, cmd
+}
+
+// activateSetting runs the action of the row under the cursor.
+func (m Model) activateSetting() (Model, tea.Cmd) {
+	if m.changeSetting(false) {
+		return m, nil
+	}
+	if cmd, ok := m.editSetting(); ok {
+		return m, cmd
+	}
+
+	switch m.settingsCursor {
+	case fieldEncryption:
+		if m.vault.Enabled() {
+			m.err = fmt.Errorf("Encryption can't be turned off once entries may be encrypted")
+			return m, nil
+		}
+		return m, m.openCryptoPrompt(cryptoEnable)
+	case fieldLocked:
+		switch {
+		case !m.vault.Enabled():
+			m.err = fmt.Errorf("Encryption is not enabled")
+		case m.vault.Unlocked():
+			m.vault.SetKey(nil)
+			m.msg = "Journal locked"
+		default:
+			return m, m.openCryptoPrompt(cryptoUnlock)
+		}
+	case fieldPassphrase:
+		if !m.vault.Enabled() {
+			m.err = fmt.Errorf("Encryption is not enabled")
+			return m, nil
+		}
+		return m, m.openCryptoPrompt(cryptoChange)
+	case fieldExport:
+		return m, m.openExportPrompt()
+	}
+	return m, nil
+}
+
+func (m Model) updateSettings(msg tea.KeyMsg) (Model, tea.Cmd) {
+	if m.cryptoPrompt != cryptoNone {
+		return m.updateCryptoPrompt(msg)
+	}
+	if m.exportOpen {
+		return m.updateExportPrompt(msg)
+	}
+	if m.settingsEditing {
+		return m.updateSettingsInput(msg)
+	}
+
+	switch {
+	case key.Matches(msg, settingsKeys.Back):
+		// Unsaved changes are dropped.
+		m.page = PageMenu
+		m.settingsDraft = m.settings
+		applyTheme(themeNamed(m.settings.Theme))
+		m.msg = ""
+		m.err = nil
+	case key.Matches(msg, settingsKeys.Up):
+		m.settingsCursor = (m.settingsCursor + fieldCount - 1) % fieldCount
+	case key.Matches(msg, settingsKeys.Down):
+		m.settingsCursor = (m.settingsCursor + 1) % fieldCount
+	case key.Matches(msg, settingsKeys.Prev):
+		m.changeSetting(true)
+	case key.Matches(msg, settingsKeys.Next):
+		m.changeSetting(false)
+	case key.Matches(msg, settingsKeys.Activate):
+		m.msg = ""
+		m.err = nil
+		return m.activateSetting()
+	case key.Matches(msg, settingsKeys.Save):
+		m.msg = "Saving..."
+		m.err = nil
+		draft, api := m.settingsDraft, m.api
+		return m, func() tea.Msg { return saveSettings(draft, api) }
+	case key.Matches(msg, settingsKeys.Encrypt):
+		if m.vault.Enabled() {
+			m.err = fmt.Errorf("Encryption is already enabled")
+			return m, nil
+		}
+		return m, m.openCryptoPrompt(cryptoEnable)
+	case key.Matches(msg, settingsKeys.Unlock):
+		if !m.vault.Enabled() || m.vault.Unlocked() {
+			return m, nil
+		}
+		return m, m.openCryptoPrompt(cryptoUnlock)
+	case key.Matches(msg, settingsKeys.Passphrase):
+		if !m.vault.Enabled() {
+			m.err = fmt.Errorf("Encryption is not enabled")
+			return m, nil
+		}
+		return m, m.openCryptoPrompt(cryptoChange)
+	case key.Matches(msg, settingsKeys.Lock):
+		if m.vault.Unlocked() {
+			m.vault.SetKey(nil)
+			m.msg = "Journal locked"
+		}
+	case key.Matches(msg, settingsKeys.Export):
+		return m, m.openExportPrompt()
+	case key.Matches(msg, quitKey):
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// settingValue is how the value of a row is shown.
+func settingValue(m Model, field settingsField) string {
+	draft := m.settingsDraft
+	onOff := func(on bool) string {
+		if on {
+			return "on"
+		}
+		return "off"
+	}
+
+	switch field {
+	case fieldEditorMode:
+		return draft.EditorMode
+	case fieldTimeZone:
+		if draft.TimeZone == "" {
+			return "this device's (" + deviceTimeZone() + ")"
+		}
+		return draft.TimeZone
+	case fieldDateFormat:
+		return m.currentTime.In(m.location).Format(draft.DateFormat)
+	case fieldReminderTime:
+		if draft.ReminderTime == "" {
+			return "from config"
+		}
+		return draft.ReminderTime
+	case fieldTheme:
+		if _, ok := theme.Find(themes, draft.Theme); !ok {
+			return draft.Theme + " (not on this device)"
+		}
+		return draft.Theme
+	case fieldLineNumbers:
+		return onOff(draft.LineNumbers)
+	case fieldEncryption:
+		return onOff(m.vault.Enabled())
+	case fieldLocked:
+		if !m.vault.Enabled() {
+			return "-"
+		}
+		return onOff(!m.vault.Unlocked())
+	}
+	return ""
+}
+
+func renderSettings(m Model) string {
+	if m.cryptoPrompt != cryptoNone {
+		return renderCryptoPrompt(m)
+	}
+	if m.exportOpen {
+		return renderExportPrompt(m)
+	}
+
+	rows := []string{titleStyle.Render("⚙️ Settings"), encryptionStatus(m), ""}
+	for field := range fieldCount {
+		if field == fieldEncryption {
+			rows = append(rows, "")
+		}
+		label := fmt.Sprintf("%-18s", settingsLabels[field])
+		value := settingValue(m, field)
+		if field == m.settingsCursor && m.settingsEditing {
+			value = m.settingsInput.View()
+		}
+		row := "  " + label + value
+		if field == m.settingsCursor {
+			row = selectedStyle.Render("> " + label + value)
+		}
+		rows = append(rows, row)
+	}
+
+	keys := settingsKeyMap
+	if m.settingsEditing {
+		keys = settingInputKeyMap
+	}
+	rows = append(rows, "", keyHints(keys, m.width))
+
+	switch {
+	case m.err != nil:
+		rows = append(rows, errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
+	case m.msg != "":
+		rows = append(rows, successStyle.Render(m.msg))
+	case m.settingsDraft != m.settings:
+		rows = append(rows, lipgloss.NewStyle().Italic(true).Render("Unsaved changes"))
+	}
+	return strings.Join(rows, "\n")
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"journalCli/client"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type StreakLoadedMsg struct {
+	Streak *client.Streak
+}
+
+// localTimeZone returns the IANA name of loc, for the server to count days
+// in: the time zone of the user's settings, or the device's for time.Local.
+func localTimeZone(loc *time.Location) string {
+	if loc != time.Local {
+		return loc.String()
+	}
+	return deviceTimeZone()
+}
+
+// deviceTimeZone returns the IANA name of the device's time zone, falling
+// back to UTC when the name can't be found.
+func deviceTimeZone() string {
+	if tz := strings.TrimPrefix(os.Getenv("TZ"), ":"); tz != "" {
+		return tz
+	}
+	// /etc/localtime links to e.g. /usr/share/zoneinfo/Europe/Berlin.
+	if target, err := os.Readlink("/etc/localtime"); err == nil {
+		if _, name, ok := strings.Cut(target, "zoneinfo/"); ok {
+			return name
+		}
+	}
+	return "UTC"
+}
+
+func fetchStreak(api *client.Client, tz string) tea.Msg {
+	streak, err := api.Streak(context.Background(), tz)
+	if errors.Is(err, client.ErrUnauthorized) {
+		return ErrMsg{err}
+	}
+	if err != nil {
+		// The streak is only a greeting and is fetched again after the next
+		// sync, so being offline isn't worth an error.
+		return nil
+	}
+	return StreakLoadedMsg{Streak: streak}
+}
+
+// renderStreak is the line under the welcome message on the menu.
+func renderStreak(streak *client.Streak) string {
+	var parts []string
+	if streak.Current > 0 {
+		parts = append(parts, fmt.Sprintf("🔥 %d-day streak", streak.Current))
+	} else {
+		parts = append(parts, "✏️ Start a streak today")
+	}
+	if streak.Longest > streak.Current {
+		parts = append(parts, fmt.Sprintf("longest %d", streak.Longest))
+	}
+	parts = append(parts, plural(streak.DaysThisMonth, "day", "days")+" this month")
+
+	line := lipgloss.NewStyle().Foreground(currentTheme.Secondary.Terminal()).Render(strings.Join(parts, " · "))
+	if streak.Current > 0 && !streak.WroteToday {
+		line += hintStyle.Render(" · write today to keep it going")
+	}
+	return line
+}
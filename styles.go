@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"journalCli/theme"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// themes are the themes to pick from in Settings, the built-in ones followed
+// by the user's.
+var themes = theme.Builtin
+
+// currentTheme is the theme the styles below are built from.
+var currentTheme theme.Theme
+
+var (
+	// Titles and section headers
+	titleStyle lipgloss.Style
+
+	// Input boxes, and the one being typed in
+	inputBoxStyle     lipgloss.Style
+	focusedInputStyle lipgloss.Style
+
+	// Buttons
+	buttonStyle lipgloss.Style
+
+	// Error messages
+	errorStyle lipgloss.Style
+
+	// Success messages
+	successStyle lipgloss.Style
+
+	// Nudges and hints to the user
+	hintStyle lipgloss.Style
+
+	// The row under the cursor of a list
+	selectedStyle lipgloss.Style
+
+	// Headers of the Help page
+	helpTitleStyle lipgloss.Style
+)
+
+func init() {
+	applyTheme(theme.Builtin[0])
+}
+
+// loadUserThemes adds the themes in the user's themes directory to the ones
+// to pick from. A theme that fails to load is left out.
+func loadUserThemes() {
+	dir, err := theme.Dir()
+	if err != nil {
+		return
+	}
+	user, err := theme.LoadDir(dir)
+	if err != nil {
+		fmt.Fprintf(debugFile, "Failed to load themes: %v\n", err)
+	}
+	for _, t := range user {
+		if _, ok := theme.Find(themes, t.Name); ok {
+			fmt.Fprintf(debugFile, "Ignoring theme %s, which is already taken\n", t.Name)
+			continue
+		}
+		themes = append(themes, t)
+	}
+}
+
+// themeNamed returns the theme called name, or the default one when there is
+// no such theme on this device.
+func themeNamed(name string) theme.Theme {
+	t, ok := theme.Find(themes, name)
+	if !ok {
+		fmt.Fprintf(debugFile, "Unknown theme %q, using the default\n", name)
+		return theme.Builtin[0]
+	}
+	return t
+}
+
+// applyTheme rebuilds the styles of the TUI from the colors of t.
+func applyTheme(t theme.Theme) {
+	currentTheme = t
+
+	titleStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(t.Title.Terminal()).
+		PaddingBottom(1)
+
+	inputBoxStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.Muted.Terminal()).
+		Width(30)
+
+	focusedInputStyle = inputBoxStyle.BorderForeground(t.Primary.Terminal())
+	if lipgloss.ColorProfile() == termenv.Ascii {
+		// Without colors the focused box stands out by its border alone.
+		focusedInputStyle = focusedInputStyle.Border(lipgloss.ThickBorder())
+	}
+
+	buttonStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(t.ButtonText.Terminal()).
+		Background(t.Button.Terminal()).
+		Margin(1).
+		Align(lipgloss.Center)
+
+	errorStyle = lipgloss.NewStyle().Foreground(t.Error.Terminal())
+	successStyle = lipgloss.NewStyle().Foreground(t.Success.Terminal())
+	hintStyle = lipgloss.NewStyle().Italic(true).Foreground(t.Primary.Terminal())
+	selectedStyle = lipgloss.NewStyle().Bold(true).Foreground(t.Primary.Terminal())
+	helpTitleStyle = lipgloss.NewStyle().Bold(true).Foreground(t.Title.Terminal())
+
+	helpKey := lipgloss.NewStyle().Foreground(t.Primary.Terminal())
+	helpDesc := lipgloss.NewStyle().Foreground(t.Muted.Terminal())
+	helpStyles = help.Styles{
+		Ellipsis:       helpDesc,
+		ShortKey:       helpKey,
+		ShortDesc:      helpDesc,
+		ShortSeparator: helpDesc,
+		FullKey:        helpKey,
+		FullDesc:       helpDesc,
+		FullSeparator:  helpDesc,
+	}
+
+	diffInsertStyle = lipgloss.NewStyle().Foreground(t.Success.Terminal())
+	diffDeleteStyle = lipgloss.NewStyle().Foreground(t.Error.Terminal())
+	highlightStyle = lipgloss.NewStyle().Bold(true).Foreground(t.Highlight.Terminal())
+
+	heatmapColors = make([]lipgloss.TerminalColor, len(t.Heatmap))
+	for i, c := range t.Heatmap {
+		heatmapColors[i] = c.Terminal()
+	}
+	insightsLabelStyle = lipgloss.NewStyle().Foreground(t.Muted.Terminal())
+	insightsValueStyle = lipgloss.NewStyle().Foreground(t.Secondary.Terminal())
+	insightsBarStyle = lipgloss.NewStyle().Foreground(t.Primary.Terminal())
+}
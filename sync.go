@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"journalCli/client"
+	"journalCli/offline"
+	"journalCli/utils"
+	neturl "net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// syncInterval is how often the local store is synced in the background.
+const syncInterval = 30 * time.Second
+
+type syncTickMsg time.Time
+
+type SyncDoneMsg struct {
+	Result  offline.Result
+	Pending int
+	Err     error
+}
+
+// httpRemote is the offline.Remote backed by the entries API. Local entries
+// are plaintext; they are sealed with the vault on their way to the server.
+type httpRemote struct {
+	api   *client.Client
+	vault *vault
+}
+
+func entryToLocal(entry client.Entry) offline.Entry {
+	return offline.Entry{
+		ServerID:  entry.ID,
+		Body:      entry.Body,
+		Nonce:     entry.Nonce,
+		KeyCheck:  entry.KeyCheck,
+		Mood:      entry.Mood,
+		Tags:      entry.Tags,
+		Version:   entry.Version,
+		CreatedAt: entry.CreatedAt,
+		UpdatedAt: entry.UpdatedAt,
+	}
+}
+
+// localIdPrefix marks the ids of entries that only exist in the local store.
+const localIdPrefix = "local-"
+
+func entryFromLocal(entry offline.Entry) client.Entry {
+	id := entry.ServerID
+	if id == "" {
+		id = localIdPrefix + entry.LocalID
+	}
+	return client.Entry{
+		ID:         id,
+		Body:       entry.Body,
+		Encryption: client.Encryption{Nonce: entry.Nonce, KeyCheck: entry.KeyCheck},
+		Mood:       entry.Mood,
+		Tags:       entry.Tags,
+		Version:    entry.Version,
+		CreatedAt:  entry.CreatedAt,
+		UpdatedAt:  entry.UpdatedAt,
+	}
+}
+
+// seal encrypts the body of a local entry unless it already is.
+func (r httpRemote) seal(entry offline.Entry) (string, client.Encryption, error) {
+	if entry.Nonce != "" {
+		return entry.Body, client.Encryption{Nonce: entry.Nonce, KeyCheck: entry.KeyCheck}, nil
+	}
+	return r.vault.seal(entry.Body)
+}
+
+func (r httpRemote) Create(entry offline.Entry) (offline.Entry, error) {
+	body, enc, err := r.seal(entry)
+	if err != nil {
+		return offline.Entry{}, err
+	}
+	req := client.CreateEntryRequest{Body: body, Encryption: enc, Mood: entry.Mood, CreatedAt: entry.CreatedAt}
+	created, err := r.api.CreateEntry(context.Background(), req)
+	if err != nil {
+		return offline.Entry{}, err
+	}
+	return entryToLocal(*created), nil
+}
+
+func (r httpRemote) Update(entry offline.Entry) (offline.Entry, error) {
+	body, enc, err := r.seal(entry)
+	if err != nil {
+		return offline.Entry{}, err
+	}
+	version := entry.Version
+	req := client.UpdateEntryRequest{Body: body, Encryption: enc, Mood: entry.Mood, BaseVersion: &version}
+	updated, err := r.api.UpdateEntry(context.Background(), entry.ServerID, req)
+	switch {
+	case errors.Is(err, client.ErrVersionConflict):
+		return offline.Entry{}, offline.ErrConflict
+	case errors.Is(err, client.ErrNotFound):
+		return offline.Entry{}, offline.ErrNotFound
+	case err != nil:
+		return offline.Entry{}, err
+	}
+	return entryToLocal(*updated), nil
+}
+
+func (r httpRemote) Delete(serverID string) error {
+	err := r.api.DeleteEntry(context.Background(), serverID)
+	if errors.Is(err, client.ErrNotFound) {
+		return offline.ErrNotFound
+	}
+	return err
+}
+
+func (r httpRemote) Changes(since time.Time) ([]offline.Entry, []string, time.Time, error) {
+	changes, err := r.api.EntryChanges(context.Background(), since)
+	if err != nil {
+		return nil, nil, time.Time{}, err
+	}
+
+	entries := make([]offline.Entry, 0, len(changes.Entries))
+	for _, entry := range changes.Entries {
+		entries = append(entries, entryToLocal(entry))
+	}
+	return entries, changes.Deleted, changes.Cursor, nil
+}
+
+func syncEvery() tea.Cmd {
+	return tea.Tick(syncInterval, func(t time.Time) tea.Msg {
+		return syncTickMsg(t)
+	})
+}
+
+func runSync(store *offline.Store, userId string, v *vault, api *client.Client) tea.Msg {
+	result, err := offline.Sync(store, httpRemote{api: api, vault: v}, userId)
+	pending, perr := store.Pending(userId)
+	if err == nil {
+		err = perr
+	}
+	return SyncDoneMsg{Result: result, Pending: len(pending), Err: err}
+}
+
+// startSync syncs the local store in the background unless a sync is already
+// running or nobody is logged in.
+func (m *Model) startSync() tea.Cmd {
+	if m.local == nil || m.syncing || m.user.ID == "" {
+		return nil
+	}
+	m.syncing = true
+	store, userId, v, api := m.local, m.user.ID, m.vault, m.api
+	return func() tea.Msg { return runSync(store, userId, v, api) }
+}
+
+// listEntry finds an entry shown on the Read page by its server id.
+func (m Model) listEntry(id string) (client.Entry, bool) {
+	for _, item := range m.entries.Items() {
+		if entry := item.(entryItem).entry; entry.ID == id {
+			return entry, true
+		}
+	}
+	return client.Entry{}, false
+}
+
+// localEntry returns the local copy of a server entry, starting one from the
+// copy shown on the Read page if the store doesn't have it yet.
+func (m Model) localEntry(id string) (offline.Entry, error) {
+	if localId, ok := strings.CutPrefix(id, localIdPrefix); ok {
+		return m.local.Get(m.user.ID, localId)
+	}
+	local, found, err := m.local.FindByServerID(m.user.ID, id)
+	if err != nil || found {
+		return local, err
+	}
+	entry, found := m.listEntry(id)
+	if !found {
+		// Saving it anyway would push the edit as a new entry.
+		return offline.Entry{}, fmt.Errorf("Entry %s not found, reload the entries and try again", id)
+	}
+	return entryToLocal(entry), nil
+}
+
+// saveLocal writes the journal to the local store first; the next sync pushes it.
+func (m Model) saveLocal(body string, mood *int) tea.Cmd {
+	store, userId, editingId := m.local, m.user.ID, m.editingEntryId
+	entry := offline.Entry{}
+	if editingId != "" {
+		var err error
+		if entry, err = m.localEntry(editingId); err != nil {
+			return func() tea.Msg { return ErrMsg{err} }
+		}
+	}
+	entry.Body = body
+	entry.Nonce, entry.KeyCheck = "", ""
+	entry.Tags = utils.ParseHashtags(body)
+	if mood != nil {
+		entry.Mood = mood
+	}
+
+	return func() tea.Msg {
+		saved, err := store.Save(userId, entry)
+		if err != nil {
+			return ErrMsg{err}
+		}
+		if editingId != "" {
+			return EntryUpdatedMsg{Entry: entryFromLocal(saved)}
+		}
+		return EntrySavedMsg{Entry: entryFromLocal(saved)}
+	}
+}
+
+func (m Model) deleteLocal(id string) tea.Cmd {
+	store, userId := m.local, m.user.ID
+	entry, err := m.localEntry(id)
+	return func() tea.Msg {
+		if err != nil {
+			return ErrMsg{err}
+		}
+		if err := store.Delete(userId, entry); err != nil {
+			return ErrMsg{err}
+		}
+		return EntryDeletedMsg{Id: id}
+	}
+}
+
+// localEntriesPrefix marks cursors that page through the local store, used
+// when the server can't be reached.
+const localEntriesPrefix = "local:"
+
+func fetchLocalEntries(store *offline.Store, userId, cursor, tag string, v *vault) tea.Msg {
+	offset, _ := strconv.Atoi(strings.TrimPrefix(cursor, localEntriesPrefix))
+	local, err := store.List(userId, tag, offset, entriesPageSize+1)
+	if err != nil {
+		return ErrMsg{err}
+	}
+
+	nextCursor := ""
+	if len(local) > entriesPageSize {
+		local = local[:entriesPageSize]
+		nextCursor = localEntriesPrefix + strconv.Itoa(offset+entriesPageSize)
+	}
+
+	entries := make([]client.Entry, 0, len(local))
+	for _, entry := range local {
+		local := entryFromLocal(entry)
+		v.openEntry(&local)
+		entries = append(entries, local)
+	}
+	return EntriesLoadedMsg{Entries: entries, NextCursor: nextCursor, Reset: offset == 0, Offline: true}
+}
+
+// fetchEntriesOrLocal lists entries from the server, falling back to the local
+// store when the server can't be reached.
+func fetchEntriesOrLocal(store *offline.Store, userId, cursor, tag string, v *vault, api *client.Client) tea.Msg {
+	if store != nil && strings.HasPrefix(cursor, localEntriesPrefix) {
+		return fetchLocalEntries(store, userId, cursor, tag, v)
+	}
+	msg := fetchEntries(cursor, tag, v, api)
+	var urlErr *neturl.Error
+	if errMsg, ok := msg.(ErrMsg); ok && store != nil && cursor == "" && errors.As(errMsg.err, &urlErr) {
+		return fetchLocalEntries(store, userId, localEntriesPrefix+"0", tag, v)
+	}
+	return msg
+}
+
+func renderSyncStatus(m Model) string {
+	if m.local == nil {
+		return ""
+	}
+
+	var status string
+	color := currentTheme.Success.Terminal()
+	switch {
+	case m.syncing:
+		status = "⟳ Syncing..."
+		color = currentTheme.Primary.Terminal()
+	case errors.Is(m.syncErr, errVaultLocked):
+		status = fmt.Sprintf("🔒 Unlock to sync · %d pending", m.pendingSync)
+		color = currentTheme.Highlight.Terminal()
+	case m.syncErr != nil:
+		status = fmt.Sprintf("⚠ Offline · %d pending", m.pendingSync)
+		color = currentTheme.Highlight.Terminal()
+	case m.lastSync.IsZero():
+		status = "… Not synced yet"
+		color = currentTheme.Muted.Terminal()
+	case m.pendingSync > 0:
+		status = fmt.Sprintf("↑ %d pending", m.pendingSync)
+		color = currentTheme.Highlight.Terminal()
+	default:
+		status = "✓ Synced " + m.lastSync.Format("15:04")
+	}
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(color).
+		Foreground(color).
+		Render(status)
+}
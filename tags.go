@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"journalCli/client"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type TagsLoadedMsg struct {
+	Tags []client.TagCount
+}
+
+func fetchTags(api *client.Client) tea.Msg {
+	tags, err := api.Tags(context.Background())
+	if err != nil {
+		return ErrMsg{err}
+	}
+	return TagsLoadedMsg{Tags: tags}
+}
+
+func (m *Model) openTagFilter() tea.Cmd {
+	m.readMode = readTags
+	m.tags = nil
+	m.tagCursor = 0
+	api := m.api
+	return func() tea.Msg { return fetchTags(api) }
+}
+
+// applyTagFilter reloads the entries showing only those tagged with tag, or all
+// of them when tag is empty.
+func (m *Model) applyTagFilter(tag string) tea.Cmd {
+	m.readMode = readList
+	m.tagFilter = tag
+	m.searchQuery = ""
+	m.entries.Title = entriesTitle(tag, false)
+	return m.loadEntries()
+}
+
+func entriesTitle(tag string, offline bool) string {
+	title := "📖 Your Entries"
+	if tag != "" {
+		title = "📖 Entries tagged #" + tag
+	}
+	if offline {
+		title += " (offline)"
+	}
+	return title
+}
+
+func (m Model) updateTagFilter(msg tea.KeyMsg) (Model, tea.Cmd) {
+	// Row 0 is "all entries", the tags follow.
+	switch {
+	case key.Matches(msg, tagKeys.Back):
+		m.readMode = readList
+	case key.Matches(msg, tagKeys.Up):
+		if m.tagCursor > 0 {
+			m.tagCursor--
+		}
+	case key.Matches(msg, tagKeys.Down):
+		if m.tagCursor < len(m.tags) {
+			m.tagCursor++
+		}
+	case key.Matches(msg, tagKeys.Filter):
+		if m.tagCursor == 0 {
+			return m, m.applyTagFilter("")
+		}
+		return m, m.applyTagFilter(m.tags[m.tagCursor-1].Name)
+	}
+	return m, nil
+}
+
+func renderTagFilter(m Model) string {
+	rows := []string{"All entries"}
+	for _, tag := range m.tags {
+		rows = append(rows, fmt.Sprintf("#%s (%d)", tag.Name, tag.Count))
+	}
+	for i, row := range rows {
+		if i == m.tagCursor {
+			rows[i] = selectedStyle.Render("> " + row)
+		} else {
+			rows[i] = "  " + row
+		}
+	}
+
+	footer := "\n" + keyHints(tagKeyMap, m.width)
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		titleStyle.Render("🏷️ Filter by Tag"),
+		strings.Join(rows, "\n"),
+		footer,
+	)
+}
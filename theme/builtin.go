@@ -0,0 +1,104 @@
+package theme
+
+// Builtin are the themes that come with journalCli, the default first.
+var Builtin = []Theme{
+	{Name: "default", Palette: defaultPalette},
+	{Name: "dark", Palette: fixed(defaultPalette, func(c Color) string { return c.Dark })},
+	{Name: "light", Palette: fixed(defaultPalette, func(c Color) string { return c.Light })},
+	{Name: "high-contrast", Palette: highContrastPalette},
+	{Name: "catppuccin", Palette: catppuccinPalette},
+}
+
+// defaultPalette is journalCli's original look on dark terminals, with darker
+// shades of it on light ones.
+var defaultPalette = Palette{
+	Primary:    Color{Light: "#7C3AED", Dark: "#A78BFA", ANSI: "5"},
+	Title:      Color{Light: "#8A5A62", Dark: "#BA8F95", ANSI: "13"},
+	Muted:      Color{Light: "#6B6266", Dark: "#897C80", ANSI: "8"},
+	Text:       Color{Light: "#1F1F1F", Dark: "#E6E6E6"},
+	Accent:     Color{Light: "#4F46E5", Dark: "#6C63FF", ANSI: "12"},
+	Secondary:  Color{Light: "#B45F4B", Dark: "#E0AFA0", ANSI: "3"},
+	Highlight:  Color{Light: "#B7791F", Dark: "#F4B400", ANSI: "11"},
+	Error:      Color{Light: "#DC2626", Dark: "#EF4444", ANSI: "9"},
+	Success:    Color{Light: "#16A34A", Dark: "#22C55E", ANSI: "10"},
+	ButtonText: Color{Light: "#0D0D1A", Dark: "#0D0D1A", ANSI: "0"},
+	Button:     Color{Light: "#CFBCDF", Dark: "#CFBCDF", ANSI: "13"},
+	Heatmap: []Color{
+		{Light: "#EBEDF0", Dark: "#2D333B", ANSI: "8"},
+		{Light: "#9BE9A8", Dark: "#0E4429", ANSI: "2"},
+		{Light: "#40C463", Dark: "#006D32", ANSI: "2"},
+		{Light: "#30A14E", Dark: "#26A641", ANSI: "10"},
+		{Light: "#216E39", Dark: "#39D353", ANSI: "10"},
+	},
+}
+
+// highContrastPalette sticks to the most saturated colors and pure black and
+// white.
+var highContrastPalette = Palette{
+	Primary:    Color{Light: "#0000CC", Dark: "#FFFF00", ANSI: "11"},
+	Title:      Color{Light: "#000000", Dark: "#FFFFFF", ANSI: "15"},
+	Muted:      Color{Light: "#333333", Dark: "#C0C0C0", ANSI: "7"},
+	Text:       Color{Light: "#000000", Dark: "#FFFFFF"},
+	Accent:     Color{Light: "#8B008B", Dark: "#00FFFF", ANSI: "14"},
+	Secondary:  Color{Light: "#7A3E00", Dark: "#FFA500", ANSI: "3"},
+	Highlight:  Color{Light: "#C00060", Dark: "#FF00FF", ANSI: "13"},
+	Error:      Color{Light: "#B00000", Dark: "#FF5555", ANSI: "9"},
+	Success:    Color{Light: "#006400", Dark: "#00FF00", ANSI: "10"},
+	ButtonText: Color{Light: "#FFFFFF", Dark: "#000000", ANSI: "0"},
+	Button:     Color{Light: "#000000", Dark: "#FFFF00", ANSI: "11"},
+	Heatmap: []Color{
+		{Light: "#DDDDDD", Dark: "#333333", ANSI: "8"},
+		{Light: "#87D787", Dark: "#005F00", ANSI: "2"},
+		{Light: "#00AF00", Dark: "#008700", ANSI: "2"},
+		{Light: "#008700", Dark: "#00D700", ANSI: "10"},
+		{Light: "#005F00", Dark: "#00FF00", ANSI: "10"},
+	},
+}
+
+// catppuccinPalette is Catppuccin Latte on light terminals and Mocha on dark
+// ones.
+var catppuccinPalette = Palette{
+	Primary:    Color{Light: "#8839EF", Dark: "#CBA6F7", ANSI: "5"},
+	Title:      Color{Light: "#EA76CB", Dark: "#F5C2E7", ANSI: "13"},
+	Muted:      Color{Light: "#8C8FA1", Dark: "#7F849C", ANSI: "8"},
+	Text:       Color{Light: "#4C4F69", Dark: "#CDD6F4"},
+	Accent:     Color{Light: "#7287FD", Dark: "#B4BEFE", ANSI: "12"},
+	Secondary:  Color{Light: "#FE640B", Dark: "#FAB387", ANSI: "3"},
+	Highlight:  Color{Light: "#DF8E1D", Dark: "#F9E2AF", ANSI: "11"},
+	Error:      Color{Light: "#D20F39", Dark: "#F38BA8", ANSI: "9"},
+	Success:    Color{Light: "#40A02B", Dark: "#A6E3A1", ANSI: "10"},
+	ButtonText: Color{Light: "#EFF1F5", Dark: "#1E1E2E", ANSI: "0"},
+	Button:     Color{Light: "#8839EF", Dark: "#CBA6F7", ANSI: "5"},
+	Heatmap: []Color{
+		{Light: "#CCD0DA", Dark: "#313244", ANSI: "8"},
+		{Light: "#A6D49A", Dark: "#3E5A3F", ANSI: "2"},
+		{Light: "#7CC06C", Dark: "#5C8A5A", ANSI: "2"},
+		{Light: "#5AAE47", Dark: "#82B97E", ANSI: "10"},
+		{Light: "#40A02B", Dark: "#A6E3A1", ANSI: "10"},
+	},
+}
+
+// fixed returns palette with the shade variant picks of each color used on
+// any background, for terminals whose background is detected wrong.
+func fixed(palette Palette, variant func(Color) string) Palette {
+	pick := func(c Color) Color {
+		return Color{Light: variant(c), Dark: variant(c), ANSI: c.ANSI}
+	}
+	out := Palette{
+		Primary:    pick(palette.Primary),
+		Title:      pick(palette.Title),
+		Muted:      pick(palette.Muted),
+		Text:       pick(palette.Text),
+		Accent:     pick(palette.Accent),
+		Secondary:  pick(palette.Secondary),
+		Highlight:  pick(palette.Highlight),
+		Error:      pick(palette.Error),
+		Success:    pick(palette.Success),
+		ButtonText: pick(palette.ButtonText),
+		Button:     pick(palette.Button),
+	}
+	for _, c := range palette.Heatmap {
+		out.Heatmap = append(out.Heatmap, pick(c))
+	}
+	return out
+}
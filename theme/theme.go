@@ -0,0 +1,234 @@
+// Package theme holds the color palettes of the TUI: the built-in ones and
+// user themes loaded from TOML files, one theme per file:
+//
+//	# ~/.config/journalcli/themes/solarized.toml
+//	name = "solarized"  # defaults to the file name
+//	base = "dark"       # the built-in theme to start from, "default" if unset
+//
+//	[colors]
+//	primary = "#268BD2"                                        # any background
+//	error = { light = "#DC322F", dark = "#FF6E67", ansi = "1" } # per background
+//	heatmap = ["#073642", "#0E4B2C", "#1B6E3A", "#2AA14F", "#3FD468"]
+//
+// Colors missing from a file keep the base theme's.
+package theme
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// HeatmapLevels is how many shades a heatmap has, from no entries to the
+// busiest day.
+const HeatmapLevels = 5
+
+// validName matches theme names, which are saved in the user's settings on
+// the server.
+var validName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+// Color is a theme color: a hex color for terminals with a light background
+// and one for a dark background, and optionally an ANSI color number 0-15 for
+// terminals with only those, which would otherwise get the closest one.
+type Color struct {
+	Light string
+	Dark  string
+	ANSI  string
+}
+
+// Terminal returns the color for lipgloss, which picks the variant that suits
+// the terminal and drops colors altogether under NO_COLOR.
+func (c Color) Terminal() lipgloss.TerminalColor {
+	if c.ANSI == "" {
+		return lipgloss.AdaptiveColor{Light: c.Light, Dark: c.Dark}
+	}
+	return lipgloss.CompleteAdaptiveColor{
+		Light: lipgloss.CompleteColor{TrueColor: c.Light, ANSI256: c.Light, ANSI: c.ANSI},
+		Dark:  lipgloss.CompleteColor{TrueColor: c.Dark, ANSI256: c.Dark, ANSI: c.ANSI},
+	}
+}
+
+// UnmarshalTOML reads a color written as a single "#RRGGBB" string or as a
+// table of light, dark and ansi.
+func (c *Color) UnmarshalTOML(value any) error {
+	switch v := value.(type) {
+	case string:
+		*c = Color{Light: v, Dark: v}
+	case map[string]any:
+		*c = Color{}
+		for key, field := range v {
+			s, ok := field.(string)
+			if !ok {
+				return fmt.Errorf("%s must be a string", key)
+			}
+			switch key {
+			case "light":
+				c.Light = s
+			case "dark":
+				c.Dark = s
+			case "ansi":
+				c.ANSI = s
+			default:
+				return fmt.Errorf("unknown key %q, expected light, dark or ansi", key)
+			}
+		}
+		// A color given for one background only is used on both.
+		if c.Light == "" {
+			c.Light = c.Dark
+		}
+		if c.Dark == "" {
+			c.Dark = c.Light
+		}
+	default:
+		return fmt.Errorf("a color must be a string like \"#A78BFA\" or a table of light, dark and ansi")
+	}
+	return c.validate()
+}
+
+func (c Color) validate() error {
+	for _, hex := range []string{c.Light, c.Dark} {
+		if _, err := strconv.ParseUint(strings.TrimPrefix(hex, "#"), 16, 32); err != nil || len(hex) != 7 || hex[0] != '#' {
+			return fmt.Errorf("%q is not a color like #A78BFA", hex)
+		}
+	}
+	if c.ANSI != "" {
+		if n, err := strconv.Atoi(c.ANSI); err != nil || n < 0 || n > 15 {
+			return fmt.Errorf("ansi %q is not a color number from 0 to 15", c.ANSI)
+		}
+	}
+	return nil
+}
+
+// Palette is what each color of a theme is used for.
+type Palette struct {
+	// Primary marks what has the focus, and the hints of each page.
+	Primary Color `toml:"primary"`
+	Title   Color `toml:"title"`
+	// Muted is for borders and labels that shouldn't draw the eye.
+	Muted Color `toml:"muted"`
+	Text  Color `toml:"text"`
+	// Accent is the user's name in the welcome message.
+	Accent Color `toml:"accent"`
+	// Secondary is for the clock, the streak and figures.
+	Secondary Color `toml:"secondary"`
+	// Highlight marks search hits and warnings.
+	Highlight  Color `toml:"highlight"`
+	Error      Color `toml:"error"`
+	Success    Color `toml:"success"`
+	ButtonText Color `toml:"button_text"`
+	Button     Color `toml:"button"`
+	// Heatmap shades the Insights calendar, HeatmapLevels colors from no
+	// entries to the busiest day.
+	Heatmap []Color `toml:"heatmap"`
+}
+
+type Theme struct {
+	Name string
+	Palette
+}
+
+// Find returns the theme named name among themes.
+func Find(themes []Theme, name string) (Theme, bool) {
+	for _, theme := range themes {
+		if theme.Name == name {
+			return theme, true
+		}
+	}
+	return Theme{}, false
+}
+
+// Names returns the names of themes, in order.
+func Names(themes []Theme) []string {
+	names := make([]string, len(themes))
+	for i, theme := range themes {
+		names[i] = theme.Name
+	}
+	return names
+}
+
+// Dir is where user themes are loaded from, themes under the user's config
+// directory.
+func Dir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "journalcli", "themes"), nil
+}
+
+// LoadDir loads the *.toml themes in dir, which doesn't have to exist. A file
+// that fails to load is reported in the error without keeping the others
+// from loading.
+func LoadDir(dir string) ([]Theme, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.toml"))
+	if err != nil {
+		return nil, err
+	}
+
+	var themes []Theme
+	var errs []error
+	for _, path := range paths {
+		theme, err := Load(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		themes = append(themes, theme)
+	}
+	return themes, errors.Join(errs...)
+}
+
+// Load loads the theme in the TOML file at path.
+func Load(path string) (Theme, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Theme{}, err
+	}
+
+	var header struct {
+		Name string `toml:"name"`
+		Base string `toml:"base"`
+	}
+	if _, err := toml.Decode(string(data), &header); err != nil {
+		return Theme{}, fmt.Errorf("theme %s: %w", path, err)
+	}
+	if header.Name == "" {
+		header.Name = strings.ToLower(strings.TrimSuffix(filepath.Base(path), ".toml"))
+	}
+	if !validName.MatchString(header.Name) {
+		return Theme{}, fmt.Errorf("theme %s: name %q must be lowercase letters, digits and dashes", path, header.Name)
+	}
+	if header.Base == "" {
+		header.Base = Builtin[0].Name
+	}
+	base, ok := Find(Builtin, header.Base)
+	if !ok {
+		return Theme{}, fmt.Errorf("theme %s: unknown base theme %q, expected one of %s", path, header.Base, strings.Join(Names(Builtin), ", "))
+	}
+
+	// Decoding over the base palette keeps the colors the file leaves out.
+	file := struct {
+		Name   string  `toml:"name"`
+		Base   string  `toml:"base"`
+		Colors Palette `toml:"colors"`
+	}{Colors: base.Palette}
+	file.Colors.Heatmap = append([]Color(nil), base.Heatmap...)
+	meta, err := toml.Decode(string(data), &file)
+	if err != nil {
+		return Theme{}, fmt.Errorf("theme %s: %w", path, err)
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		return Theme{}, fmt.Errorf("theme %s: unknown key %s", path, undecoded[0])
+	}
+	if len(file.Colors.Heatmap) != HeatmapLevels {
+		return Theme{}, fmt.Errorf("theme %s: heatmap must have %d colors", path, HeatmapLevels)
+	}
+	return Theme{Name: header.Name, Palette: file.Colors}, nil
+}
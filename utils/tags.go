@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// MaxTagLength is the most characters a tag keeps, as many as the tags
+// table's name column holds.
+const MaxTagLength = 64
+
+var hashtagPattern = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_-]+)`)
+
+// ParseHashtags returns the distinct #hashtags of a text, lowercased and
+// without the leading '#', in the order they first appear. Longer hashtags
+// are cut to MaxTagLength characters.
+func ParseHashtags(text string) []string {
+	seen := map[string]bool{}
+	tags := []string{}
+	for _, match := range hashtagPattern.FindAllStringSubmatch(text, -1) {
+		tag := strings.ToLower(match[1])
+		if runes := []rune(tag); len(runes) > MaxTagLength {
+			tag = string(runes[:MaxTagLength])
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,415 @@
+package main
+
+import (
+	"fmt"
+	"journalCli/client"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// vimMode is the mode of the journal editor when the editor mode setting is
+// vim.
+type vimMode int
+
+const (
+	vimNormal vimMode = iota
+	vimInsert
+	vimVisual
+	vimVisualLine
+	vimCommand
+)
+
+var vimModeNames = map[vimMode]string{
+	vimNormal:     "-- NORMAL --",
+	vimInsert:     "-- INSERT --",
+	vimVisual:     "-- VISUAL --",
+	vimVisualLine: "-- VISUAL LINE --",
+}
+
+// The keys shown under the journal in each mode; the Help page lists them
+// all.
+var (
+	vimNormalHints = keyMap{bindings: []binding{{"", &vimKeys.Insert}, {"", &vimKeys.Visual},
+		{"", &vimKeys.Delete}, {"", &vimKeys.Yank}, {"", &vimKeys.Paste}, {"", &vimKeys.Command}}}
+	vimInsertHints = keyMap{bindings: []binding{{"", &vimKeys.Normal}, {"", &journalKeys.Save}}}
+)
+
+// vimState is the state of the modal editing layer over the journal
+// textarea.
+type vimState struct {
+	mode vimMode
+	// pending is the key of an operator waiting to be typed again, like the
+	// first d of dd.
+	pending *key.Binding
+	// command is the : command being typed.
+	command string
+	// anchorRow and anchorCol are where the selection of visual mode started.
+	anchorRow, anchorCol int
+	// register holds what was last deleted or yanked, whole lines when
+	// linewise is set.
+	register string
+	linewise bool
+	// quitAfterSave leaves the journal once the entry is saved, for :wq.
+	quitAfterSave bool
+}
+
+// vimEnabled reports whether the journal editor is in vim mode.
+func (m Model) vimEnabled() bool {
+	return m.settings.EditorMode == client.EditorVim
+}
+
+// resetVim starts the vim layer over in normal mode, keeping the register.
+func (m *Model) resetVim() {
+	m.vim = vimState{register: m.vim.register, linewise: m.vim.linewise}
+}
+
+func (m Model) updateVim(msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch m.vim.mode {
+	case vimInsert:
+		if key.Matches(msg, vimKeys.Normal) {
+			m.vim.mode = vimNormal
+			// Like Vim, leaving insert mode steps back onto the last character.
+			if _, col := textCursor(&m.journal); col > 0 {
+				m.journal.SetCursor(col - 1)
+			}
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.journal, cmd = m.journal.Update(msg)
+		return m, cmd
+	case vimCommand:
+		return m.updateVimCommand(msg)
+	}
+
+	visual := m.vim.mode == vimVisual || m.vim.mode == vimVisualLine
+	pending := m.vim.pending
+	m.vim.pending = nil
+
+	switch {
+	case key.Matches(msg, vimKeys.Normal):
+		m.vim.mode = vimNormal
+	case key.Matches(msg, vimKeys.Command):
+		m.vim.mode = vimCommand
+		m.vim.command = ""
+
+	case key.Matches(msg, vimKeys.Left):
+		if _, col := textCursor(&m.journal); col > 0 {
+			m.journal.SetCursor(col - 1)
+		}
+	case key.Matches(msg, vimKeys.Right):
+		row, col := textCursor(&m.journal)
+		if col < len(textLines(m.journal.Value())[row])-1 {
+			m.journal.SetCursor(col + 1)
+		}
+	case key.Matches(msg, vimKeys.Down):
+		return m.sendToJournal(tea.KeyMsg{Type: tea.KeyDown})
+	case key.Matches(msg, vimKeys.Up):
+		return m.sendToJournal(tea.KeyMsg{Type: tea.KeyUp})
+	case key.Matches(msg, vimKeys.WordForward):
+		m.wordForward()
+	case key.Matches(msg, vimKeys.WordBackward):
+		return m.sendToJournal(tea.KeyMsg{Type: tea.KeyLeft, Alt: true})
+	case key.Matches(msg, vimKeys.LineStart):
+		m.journal.CursorStart()
+	case key.Matches(msg, vimKeys.LineEnd):
+		m.journal.CursorEnd()
+		if _, col := textCursor(&m.journal); col > 0 {
+			m.journal.SetCursor(col - 1)
+		}
+	case key.Matches(msg, vimKeys.Top):
+		if pending != &vimKeys.Top {
+			m.vim.pending = &vimKeys.Top
+			return m, nil
+		}
+		return m.sendToJournal(tea.KeyMsg{Type: tea.KeyCtrlHome})
+	case key.Matches(msg, vimKeys.Bottom):
+		return m.sendToJournal(tea.KeyMsg{Type: tea.KeyCtrlEnd})
+
+	case visual && key.Matches(msg, vimKeys.Delete, vimKeys.DeleteChar):
+		m.cutSelection(true)
+	case visual && key.Matches(msg, vimKeys.Yank):
+		m.cutSelection(false)
+	case visual:
+		// Anything else doesn't apply to a selection.
+
+	case key.Matches(msg, vimKeys.Visual):
+		m.vim.mode = vimVisual
+		m.vim.anchorRow, m.vim.anchorCol = textCursor(&m.journal)
+	case key.Matches(msg, vimKeys.VisualLine):
+		m.vim.mode = vimVisualLine
+		m.vim.anchorRow, m.vim.anchorCol = textCursor(&m.journal)
+
+	case key.Matches(msg, vimKeys.Insert):
+		m.vim.mode = vimInsert
+	case key.Matches(msg, vimKeys.InsertStart):
+		m.vim.mode = vimInsert
+		m.journal.CursorStart()
+	case key.Matches(msg, vimKeys.Append):
+		m.vim.mode = vimInsert
+		row, col := textCursor(&m.journal)
+		if col < len(textLines(m.journal.Value())[row]) {
+			m.journal.SetCursor(col + 1)
+		}
+	case key.Matches(msg, vimKeys.AppendEnd):
+		m.vim.mode = vimInsert
+		m.journal.CursorEnd()
+	case key.Matches(msg, vimKeys.OpenBelow):
+		m.vim.mode = vimInsert
+		m.journal.CursorEnd()
+		m.journal.InsertString("\n")
+	case key.Matches(msg, vimKeys.OpenAbove):
+		m.vim.mode = vimInsert
+		m.journal.CursorStart()
+		m.journal.InsertString("\n")
+		m.journal.CursorUp()
+
+	case key.Matches(msg, vimKeys.DeleteChar):
+		row, col := textCursor(&m.journal)
+		lines := textLines(m.journal.Value())
+		if col < len(lines[row]) {
+			m.vim.register, m.vim.linewise = string(lines[row][col]), false
+			lines[row] = append(lines[row][:col:col], lines[row][col+1:]...)
+			setText(&m.journal, lines, row, min(col, max(len(lines[row])-1, 0)))
+		}
+	case key.Matches(msg, vimKeys.Delete, vimKeys.Yank):
+		operator := &vimKeys.Delete
+		if key.Matches(msg, vimKeys.Yank) {
+			operator = &vimKeys.Yank
+		}
+		if pending != operator {
+			m.vim.pending = operator
+			return m, nil
+		}
+		row, _ := textCursor(&m.journal)
+		m.vim.anchorRow = row
+		m.vim.mode = vimVisualLine
+		m.cutSelection(operator == &vimKeys.Delete)
+	case key.Matches(msg, vimKeys.Paste):
+		m.paste(true)
+	case key.Matches(msg, vimKeys.PasteBefore):
+		m.paste(false)
+	}
+	return m, nil
+}
+
+// renderVimStatus shows the mode of the editor, or the : command being typed,
+// next to the keys that work in it.
+func renderVimStatus(m Model) string {
+	if m.vim.mode == vimCommand {
+		return ":" + m.vim.command
+	}
+	status := vimModeNames[m.vim.mode]
+	keys := vimNormalHints
+	switch m.vim.mode {
+	case vimInsert:
+		keys = vimInsertHints
+	case vimVisual, vimVisualLine:
+		// Delete and Yank take a selection typed once, not twice.
+		del, yank := selectionKey(vimKeys.Delete, "delete"), selectionKey(vimKeys.Yank, "yank")
+		keys = keyMap{bindings: []binding{{"", &del}, {"", &yank}, {"", &vimKeys.Normal}}}
+	}
+	width := m.width - lipgloss.Width(status) - 2
+	return hintStyle.Render(status) + "  " + keyHints(keys, width)
+}
+
+func selectionKey(b key.Binding, desc string) key.Binding {
+	selection := key.NewBinding(key.WithKeys(b.Keys()...), key.WithHelp(strings.Join(b.Keys(), "/"), desc))
+	selection.SetEnabled(b.Enabled())
+	return selection
+}
+
+// sendToJournal hands a key to the textarea, for the motions it already
+// knows.
+func (m Model) sendToJournal(msg tea.KeyMsg) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+	m.journal, cmd = m.journal.Update(msg)
+	return m, cmd
+}
+
+func (m Model) updateVimCommand(msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.vim.mode = vimNormal
+		return m, nil
+	case tea.KeyBackspace:
+		if m.vim.command == "" {
+			m.vim.mode = vimNormal
+			return m, nil
+		}
+		m.vim.command = m.vim.command[:len(m.vim.command)-1]
+		return m, nil
+	case tea.KeyRunes, tea.KeySpace:
+		m.vim.command += string(msg.Runes)
+		return m, nil
+	case tea.KeyEnter:
+	default:
+		return m, nil
+	}
+
+	m.vim.mode = vimNormal
+	switch strings.TrimSpace(m.vim.command) {
+	case "w":
+		m.requestSave()
+	case "wq", "x":
+		m.requestSave()
+		m.vim.quitAfterSave = m.pickingMood
+	case "q":
+		m.leaveJournal(false)
+	case "q!":
+		m.leaveJournal(true)
+	default:
+		m.err = fmt.Errorf("Not an editor command: %s", m.vim.command)
+	}
+	return m, nil
+}
+
+// wordForward moves to the start of the next word, where the textarea's own
+// word motion stops at the end of the current one.
+func (m *Model) wordForward() {
+	row, col := textCursor(&m.journal)
+	lines := textLines(m.journal.Value())
+	text := []rune(m.journal.Value())
+	i := textOffset(lines, row, col)
+	for i < len(text) && !unicode.IsSpace(text[i]) {
+		i++
+	}
+	for i < len(text) && unicode.IsSpace(text[i]) {
+		i++
+	}
+	row, col = textPosition(lines, min(i, max(len(text)-1, 0)))
+	moveTextCursor(&m.journal, row, col)
+}
+
+// cutSelection yanks the text selected in visual mode into the register,
+// deleting it too when del is set, and goes back to normal mode.
+func (m *Model) cutSelection(del bool) {
+	row, col := textCursor(&m.journal)
+	lines := textLines(m.journal.Value())
+	linewise := m.vim.mode == vimVisualLine
+	m.vim.mode = vimNormal
+
+	if linewise {
+		first, last := min(row, m.vim.anchorRow), max(row, m.vim.anchorRow)
+		m.vim.register, m.vim.linewise = joinLines(lines[first:last+1]), true
+		if !del {
+			moveTextCursor(&m.journal, first, 0)
+			return
+		}
+		lines = append(lines[:first:first], lines[last+1:]...)
+		if len(lines) == 0 {
+			lines = [][]rune{{}}
+		}
+		setText(&m.journal, lines, min(first, len(lines)-1), 0)
+		return
+	}
+
+	// The selection takes in the characters under both ends, like Vim's.
+	text := []rune(m.journal.Value())
+	start, end := textOffset(lines, m.vim.anchorRow, m.vim.anchorCol), textOffset(lines, row, col)
+	if start > end {
+		start, end = end, start
+	}
+	end = min(end+1, len(text))
+	m.vim.register, m.vim.linewise = string(text[start:end]), false
+	if del {
+		text = append(text[:start:start], text[end:]...)
+		m.journal.SetValue(string(text))
+	}
+	row, col = textPosition(textLines(string(text)), start)
+	moveTextCursor(&m.journal, row, col)
+}
+
+// paste puts the register after the cursor, or before it, like p and P.
+func (m *Model) paste(after bool) {
+	if m.vim.register == "" {
+		return
+	}
+	row, col := textCursor(&m.journal)
+	lines := textLines(m.journal.Value())
+
+	if m.vim.linewise {
+		at := row
+		if after {
+			at++
+		}
+		pasted := textLines(m.vim.register)
+		lines = append(lines[:at:at], append(pasted, lines[at:]...)...)
+		setText(&m.journal, lines, at, 0)
+		return
+	}
+
+	if after {
+		col = min(col+1, len(lines[row]))
+	}
+	text := []rune(m.journal.Value())
+	at := textOffset(lines, row, col)
+	pasted := []rune(m.vim.register)
+	text = append(text[:at:at], append(pasted, text[at:]...)...)
+	m.journal.SetValue(string(text))
+	// The cursor ends on the last pasted character.
+	row, col = textPosition(textLines(string(text)), at+len(pasted)-1)
+	moveTextCursor(&m.journal, row, col)
+}
+
+// textLines splits text into lines of runes, which is how the textarea
+// counts columns.
+func textLines(text string) [][]rune {
+	var lines [][]rune
+	for _, line := range strings.Split(text, "\n") {
+		lines = append(lines, []rune(line))
+	}
+	return lines
+}
+
+func joinLines(lines [][]rune) string {
+	parts := make([]string, len(lines))
+	for i, line := range lines {
+		parts[i] = string(line)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// textOffset is the rune offset in the text of a row and column.
+func textOffset(lines [][]rune, row, col int) int {
+	offset := col
+	for _, line := range lines[:row] {
+		offset += len(line) + 1
+	}
+	return offset
+}
+
+// textPosition is the row and column of a rune offset in the text.
+func textPosition(lines [][]rune, offset int) (row, col int) {
+	for row < len(lines)-1 && offset > len(lines[row]) {
+		offset -= len(lines[row]) + 1
+		row++
+	}
+	return row, max(offset, 0)
+}
+
+// textCursor is the row and column of the textarea's cursor, in lines of the
+// text rather than of the screen.
+func textCursor(t *textarea.Model) (row, col int) {
+	info := t.LineInfo()
+	return t.Line(), info.StartColumn + info.ColumnOffset
+}
+
+func moveTextCursor(t *textarea.Model, row, col int) {
+	for t.Line() > row {
+		t.CursorUp()
+	}
+	for t.Line() < row {
+		t.CursorDown()
+	}
+	t.SetCursor(col)
+}
+
+func setText(t *textarea.Model, lines [][]rune, row, col int) {
+	t.SetValue(joinLines(lines))
+	moveTextCursor(t, row, col)
+}